@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller"
+)
+
+// registerDebugHandlers serves the states of controller under /debug/manba/.
+// Requests must carry token in the header "Authorization: Bearer <token>",
+// query parameters namespace and ingress filter the returned entities.
+func registerDebugHandlers(mux *http.ServeMux, token string, manbaC *controller.ManbaController) {
+	handle := func(path string, fn func(controller.DebugFilter) (interface{}, error)) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, "Bearer ") ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			f := controller.DebugFilter{
+				Namespace: r.URL.Query().Get("namespace"),
+				Ingress:   r.URL.Query().Get("ingress"),
+			}
+			if f.Ingress != "" && f.Namespace == "" {
+				http.Error(w, "namespace is required when filtering by ingress", http.StatusBadRequest)
+				return
+			}
+
+			data, err := fn(f)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			b, err := json.MarshalIndent(data, "", "  ")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(b)
+		})
+	}
+
+	handle("/debug/manba/parsed", func(f controller.DebugFilter) (interface{}, error) {
+		return manbaC.DebugParsed(f), nil
+	})
+	handle("/debug/manba/target", func(f controller.DebugFilter) (interface{}, error) {
+		return manbaC.DebugTarget(f), nil
+	})
+	handle("/debug/manba/live", func(f controller.DebugFilter) (interface{}, error) {
+		return manbaC.DebugLive(f)
+	})
+	handle("/debug/manba/diff", func(f controller.DebugFilter) (interface{}, error) {
+		return manbaC.DebugDiff(f)
+	})
+}
@@ -20,6 +20,13 @@ type Config struct {
 	// Admission controller server properties
 	AdmissionWebhookListen  string
 	AdmissionWebhookCertDir string
+	// Self-managed certificate of admission webhook
+	AdmissionWebhookManageCerts      bool
+	AdmissionWebhookSecret           string
+	AdmissionWebhookService          string
+	AdmissionWebhookValidatingConfig string
+	AdmissionWebhookMutatingConfig   string
+	AdmissionWebhookCertValidity     time.Duration
 
 	// Manba connection details
 	ManbaAPIServer        string
@@ -27,6 +34,18 @@ type Config struct {
 	ManbaWorkspace        string
 	ManbaConcurrency      int
 
+	// Manba connection security and resilience
+	ManbaAPIServerTLS              bool
+	ManbaAPIServerCertDir          string
+	ManbaAPIServerTLSServerName    string
+	ManbaAPIServerTLSSkipVerify    bool
+	ManbaAPIServerToken            string
+	ManbaAPIServerTokenFile        string
+	ManbaAPIServerRetries          int
+	ManbaAPIServerRetryBackoff     time.Duration
+	ManbaAPIServerBreakerThreshold int
+	ManbaAPIServerBreakerCooldown  time.Duration
+
 	// Resource filtering
 	WatchNamespace string
 	IngressClass   string
@@ -35,6 +54,7 @@ type Config struct {
 	// Runtime behavior
 	SyncPeriod    time.Duration
 	SyncRateLimit float32
+	MaxSyncAge    time.Duration
 
 	// k8s connection details
 	APIServerHost      string
@@ -45,6 +65,14 @@ type Config struct {
 	PublishStatusAddress string
 	UpdateStatus         bool
 	EnableProfiling      bool
+	DebugToken           string
+
+	// Canary rollout
+	CanaryPrometheusAddress string
+	CanaryCheckPeriod       time.Duration
+
+	// DefaultTrafficPolicy is the namespace/name of ManbaTrafficPolicy inherited by all manba clusters
+	DefaultTrafficPolicy string
 }
 
 func flagSet() *pflag.FlagSet {
@@ -56,12 +84,46 @@ Setting it to 'off' disables the admission controller.`)
 	flags.String("admission-webhook-cert-dir", "/admission-webhook",
 		`Path to the PEM-encoded certificate dir for
 TLS handshake`)
+	flags.Bool("admission-webhook-manage-certs", false,
+		`Generate and rotate the serving certificate of admission controller,
+store it in --admission-webhook-secret and write it to --admission-webhook-cert-dir.
+Webhook configurations are created or their caBundle is patched to trust it.`)
+	flags.String("admission-webhook-secret", "manba-validation-webhook",
+		`Name of the secret storing the self-managed certificate in the namespace of controller`)
+	flags.String("admission-webhook-service", "manba-validation-webhook",
+		`Name of the service of admission controller in the namespace of controller,
+the self-managed certificate is issued to its DNS names`)
+	flags.String("admission-webhook-validating-config", "manba-validations",
+		`Name of the ValidatingWebhookConfiguration managed with the certificate,
+setting it to empty leaves it unmanaged`)
+	flags.String("admission-webhook-mutating-config", "manba-defaults",
+		`Name of the MutatingWebhookConfiguration managed with the certificate,
+setting it to empty leaves it unmanaged`)
+	flags.Duration("admission-webhook-cert-validity", 365*24*time.Hour,
+		`Validity of the self-managed certificate, it's rotated when a third of it is left`)
 
 	flags.StringP("manba-api-server-addr", "s", "", "The address of the Manba API Server to connect to in the format of protocol://address:port, e.g. grpc://localhost:9092")
-	flags.Duration("manba-api-server-timeout", time.Second*10, "The timeout of connection to Manba API Server")
+	flags.Duration("manba-api-server-timeout", time.Second*10, "The timeout of requests to Manba API Server, including receiving whole lists")
 	flags.String("manba-workspace", "",
 		"Workspace in Manba Enterprise to be configured")
 	flags.Int("manba-concurrency", 10, "Max number of concurrent requests sent to Manba's Admin API")
+	flags.Bool("manba-api-server-tls", false, "Connect to Manba API Server with TLS")
+	flags.String("manba-api-server-cert-dir", "",
+		`Path to the PEM-encoded certificate dir used to connect to Manba API Server,
+ca.crt verifies the server, tls.crt and tls.key enable mutual TLS.
+Files which don't exist are skipped.`)
+	flags.String("manba-api-server-tls-server-name", "", "Server name used to verify the certificate of Manba API Server")
+	flags.Bool("manba-api-server-tls-skip-verify", false, "Skip verifying the certificate of Manba API Server, do not use in production")
+	flags.String("manba-api-server-token", "", "Bearer token sent to Manba API Server")
+	flags.String("manba-api-server-token-file", "",
+		`Path to the file containing the bearer token sent to Manba API Server,
+the file is read on every request so that a mounted secret can be rotated.`)
+	flags.Int("manba-api-server-retries", 3, "Max number of retries of requests to Manba API Server failed with transient errors")
+	flags.Duration("manba-api-server-retry-backoff", 200*time.Millisecond, "Initial backoff between retries, it's doubled on each retry")
+	flags.Int("manba-api-server-breaker-threshold", 5,
+		`Number of continuous failures of Manba API Server before requests fail fast.
+Setting it to 0 disables the circuit breaker.`)
+	flags.Duration("manba-api-server-breaker-cooldown", 30*time.Second, "Time requests fail fast before a trial request is sent to Manba API Server")
 
 	// Resource filtering
 	flags.String("watch-namespace", apiv1.NamespaceAll,
@@ -75,6 +137,9 @@ TLS handshake`)
 		`Relist and confirm cloud resources this often.`)
 	flags.Float32("sync-rate-limit", 0.3,
 		`Define the sync frequency upper limit`)
+	flags.Duration("max-sync-age", 30*time.Minute,
+		`Max age of the last successful sync before the controller
+is reported as not ready on /readyz. Setting it to 0 disables the check.`)
 
 	// Ingress Status publish resource
 	flags.String("publish-service", "",
@@ -89,6 +154,16 @@ ingress using this address.`)
 should update the Ingress status IP/hostname.`)
 
 	flags.Bool("profiling", true, `Enable profiling via web interface host:port/debug/pprof/`)
+	flags.String("debug-token", "", `Token required to access host:port/debug/manba/,
+the endpoints expose parsed, target and live configuration and the pending diff.
+Leaving it empty disables the endpoints.`)
+	flags.String("canary-prometheus-address", "",
+		`Address of Prometheus queried by ManbaCanaries without address, e.g. http://prometheus:9090`)
+	flags.Duration("canary-check-period", 10*time.Second,
+		`How often ManbaCanaries are checked and stepped.`)
+	flags.String("default-traffic-policy", "",
+		`ManbaTrafficPolicy inherited by all ManbaClusters after the default one of their namespace.
+Takes the form namespace/name, the namespace must be watched.`)
 	// k8s connection details
 	flags.String("apiserver-host", "",
 		`The address of the Kubernetes Apiserver to connect to in the format of 
@@ -125,12 +200,28 @@ func parseFlags() (cfg Config, err error) {
 	cfg.AdmissionWebhookListen = viper.GetString("admission-webhook-listen")
 	cfg.AdmissionWebhookCertDir =
 		viper.GetString("admission-webhook-cert-dir")
-
-		// manba detail
+	cfg.AdmissionWebhookManageCerts = viper.GetBool("admission-webhook-manage-certs")
+	cfg.AdmissionWebhookSecret = viper.GetString("admission-webhook-secret")
+	cfg.AdmissionWebhookService = viper.GetString("admission-webhook-service")
+	cfg.AdmissionWebhookValidatingConfig = viper.GetString("admission-webhook-validating-config")
+	cfg.AdmissionWebhookMutatingConfig = viper.GetString("admission-webhook-mutating-config")
+	cfg.AdmissionWebhookCertValidity = viper.GetDuration("admission-webhook-cert-validity")
+
+	// manba detail
 	cfg.ManbaAPIServer = viper.GetString("manba-api-server-addr")
 	cfg.ManbaWorkspace = viper.GetString("manba-workspace")
 	cfg.ManbaAPIServerTimeout = viper.GetDuration("manba-api-server-timeout")
 	cfg.ManbaConcurrency = viper.GetInt("manba-concurrency")
+	cfg.ManbaAPIServerTLS = viper.GetBool("manba-api-server-tls")
+	cfg.ManbaAPIServerCertDir = viper.GetString("manba-api-server-cert-dir")
+	cfg.ManbaAPIServerTLSServerName = viper.GetString("manba-api-server-tls-server-name")
+	cfg.ManbaAPIServerTLSSkipVerify = viper.GetBool("manba-api-server-tls-skip-verify")
+	cfg.ManbaAPIServerToken = viper.GetString("manba-api-server-token")
+	cfg.ManbaAPIServerTokenFile = viper.GetString("manba-api-server-token-file")
+	cfg.ManbaAPIServerRetries = viper.GetInt("manba-api-server-retries")
+	cfg.ManbaAPIServerRetryBackoff = viper.GetDuration("manba-api-server-retry-backoff")
+	cfg.ManbaAPIServerBreakerThreshold = viper.GetInt("manba-api-server-breaker-threshold")
+	cfg.ManbaAPIServerBreakerCooldown = viper.GetDuration("manba-api-server-breaker-cooldown")
 
 	// Resource filtering
 	cfg.WatchNamespace = viper.GetString("watch-namespace")
@@ -140,6 +231,7 @@ func parseFlags() (cfg Config, err error) {
 	// Rutnime behavior
 	cfg.SyncPeriod = viper.GetDuration("sync-period")
 	cfg.SyncRateLimit = (float32)(viper.GetFloat64("sync-rate-limit"))
+	cfg.MaxSyncAge = viper.GetDuration("max-sync-age")
 
 	// k8s connection details
 	cfg.APIServerHost = viper.GetString("apiserver-host")
@@ -151,5 +243,10 @@ func parseFlags() (cfg Config, err error) {
 	cfg.UpdateStatus = viper.GetBool("update-status")
 
 	cfg.EnableProfiling = viper.GetBool("profiling")
+	cfg.DebugToken = viper.GetString("debug-token")
+
+	cfg.CanaryPrometheusAddress = viper.GetString("canary-prometheus-address")
+	cfg.CanaryCheckPeriod = viper.GetDuration("canary-check-period")
+	cfg.DefaultTrafficPolicy = viper.GetString("default-traffic-policy")
 	return
 }
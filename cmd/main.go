@@ -12,6 +12,9 @@ import (
 	"time"
 
 	"github.com/domgoer/manba-ingress/pkg/admission"
+	"github.com/domgoer/manba-ingress/pkg/promotion"
+	"github.com/domgoer/manba-ingress/pkg/schedule"
+	"github.com/domgoer/manba-ingress/pkg/trafficpolicy"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/domgoer/manba-ingress/pkg/utils"
@@ -23,8 +26,10 @@ import (
 	"github.com/domgoer/manba-ingress/pkg/ingress/store"
 
 	cache2 "github.com/domgoer/manba-ingress/pkg/cache"
+	"github.com/domgoer/manba-ingress/pkg/canary"
+	configurationclientv1 "github.com/domgoer/manba-ingress/pkg/client/clientset/versioned"
 	"github.com/domgoer/manba-ingress/pkg/ingress/controller"
-	manbaClient "github.com/fagongzi/gateway/pkg/client"
+	manbaClient "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/golang/glog"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
@@ -94,7 +99,7 @@ func main() {
 	}
 
 	// init manba client
-	manbaCli, err := manbaClient.NewClient(cfg.ManbaAPIServerTimeout, cfg.ManbaAPIServer)
+	manbaCli, err := manbaClient.NewClient(manbaClientConfigFromCLIConfig(cfg))
 	if err != nil {
 		glog.Fatalf("create manba client failed, err: %v", err)
 	}
@@ -117,15 +122,44 @@ func main() {
 		runtime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 	}
 
+	controllerConfig.InformersSynced = synced
+
 	s := store.New(kubeClient, factory, manbaFactory, annotations.IngressClassValidatorFuncFromObjectMeta(controllerConfig.IngressClass))
+	trafficPolicies, err := trafficpolicy.NewResolver(s, cfg.DefaultTrafficPolicy)
+	if err != nil {
+		glog.Fatalf("invalid default traffic policy, err: %v", err)
+	}
+	controllerConfig.TrafficPolicies = trafficPolicies
 	manbaController, err := controller.NewManbaController(controllerConfig, updateChannel, s)
 	if err != nil {
 		glog.Fatalf("create manba controller failed, err: %v", err)
 	}
 
+	confClient, err := configurationclientv1.NewForConfig(restCfg)
+	if err != nil {
+		glog.Fatalf("create configuration client failed, err: %v", err)
+	}
+	canaryController := canary.New(confClient, s, manbaController.IsLeader, cfg.CanaryPrometheusAddress)
+	go canaryController.Run(cfg.CanaryCheckPeriod, stopCh)
+	promotionController := promotion.New(confClient, s, manbaController.IsLeader)
+	go promotionController.Run(5*time.Second, stopCh)
+	scheduleController := schedule.New(confClient, s, manbaController.IsLeader)
+	go scheduleController.Run(5*time.Second, stopCh)
+	trafficPolicyController := trafficpolicy.New(confClient, s, trafficPolicies, manbaController.IsLeader)
+	go trafficPolicyController.Run(5*time.Second, stopCh)
+
 	// if admissionWebhookListen is "off", wont start admissionServer
 	if cfg.AdmissionWebhookListen != "off" {
-		admissionServer, err := admission.New(restCfg, cfg.AdmissionWebhookListen, cfg.AdmissionWebhookCertDir, admission.NewValidator(manbaFactory))
+		if cfg.AdmissionWebhookManageCerts {
+			certManager := admission.NewCertManager(kubeClient, certConfigFromCLIConfig(cfg, os.Getenv("POD_NAMESPACE")))
+			// the server loads the certificate on start
+			if err := certManager.Ensure(); err != nil {
+				glog.Fatalf("ensure admission webhook certificate failed, err: %v", err)
+			}
+			go certManager.Run(time.Hour, stopCh)
+		}
+
+		admissionServer, err := admission.New(restCfg, cfg.AdmissionWebhookListen, cfg.AdmissionWebhookCertDir, admission.NewValidator(manbaFactory, s, cfg.IngressClass))
 		if err != nil {
 			glog.Fatalf("create admission server failed, err: %v", err)
 		}
@@ -143,7 +177,7 @@ func main() {
 	})
 
 	mux := http.NewServeMux()
-	go registerHandlers(cfg.EnableProfiling, 10254, mux)
+	go registerHandlers(cfg.EnableProfiling, cfg.DebugToken, 10254, mux, manbaController)
 
 	manbaController.Start()
 }
@@ -219,6 +253,7 @@ func controllerConfigFromCLIConfig(cfg Config) controller.Config {
 		IngressClass:  cfg.IngressClass,
 		ResyncPeriod:  cfg.SyncPeriod,
 		SyncRateLimit: cfg.SyncRateLimit,
+		MaxSyncAge:    cfg.MaxSyncAge,
 		Concurrency:   cfg.ManbaConcurrency,
 
 		PublishService:       cfg.PublishService,
@@ -227,6 +262,45 @@ func controllerConfigFromCLIConfig(cfg Config) controller.Config {
 	}
 }
 
+func certConfigFromCLIConfig(cfg Config, namespace string) admission.CertConfig {
+	return admission.CertConfig{
+		SecretNamespace:   namespace,
+		SecretName:        cfg.AdmissionWebhookSecret,
+		ServiceNamespace:  namespace,
+		ServiceName:       cfg.AdmissionWebhookService,
+		CertDir:           cfg.AdmissionWebhookCertDir,
+		ValidatingWebhook: cfg.AdmissionWebhookValidatingConfig,
+		MutatingWebhook:   cfg.AdmissionWebhookMutatingConfig,
+		Validity:          cfg.AdmissionWebhookCertValidity,
+		RotateBefore:      cfg.AdmissionWebhookCertValidity / 3,
+	}
+}
+
+func manbaClientConfigFromCLIConfig(cfg Config) manbaClient.Config {
+	return manbaClient.Config{
+		Addr:    cfg.ManbaAPIServer,
+		Timeout: cfg.ManbaAPIServerTimeout,
+		TLS: manbaClient.TLSConfig{
+			Enabled:            cfg.ManbaAPIServerTLS,
+			CertDir:            cfg.ManbaAPIServerCertDir,
+			ServerName:         cfg.ManbaAPIServerTLSServerName,
+			InsecureSkipVerify: cfg.ManbaAPIServerTLSSkipVerify,
+		},
+		Token:     cfg.ManbaAPIServerToken,
+		TokenFile: cfg.ManbaAPIServerTokenFile,
+		Retry: wait.Backoff{
+			Duration: cfg.ManbaAPIServerRetryBackoff,
+			Factor:   2,
+			Jitter:   0.1,
+			Steps:    cfg.ManbaAPIServerRetries,
+		},
+		Breaker: manbaClient.BreakerConfig{
+			Threshold: cfg.ManbaAPIServerBreakerThreshold,
+			Cooldown:  cfg.ManbaAPIServerBreakerCooldown,
+		},
+	}
+}
+
 type exiter func(code int)
 
 func handleSigterm(manbaC *controller.ManbaController, stopCh chan struct{},
@@ -250,12 +324,40 @@ func handleSigterm(manbaC *controller.ManbaController, stopCh chan struct{},
 	exit(exitCode)
 }
 
-func registerHandlers(enableProfiling bool, port int, mux *http.ServeMux) {
+func registerHandlers(enableProfiling bool, debugToken string, port int, mux *http.ServeMux, manbaC *controller.ManbaController) {
 
+	// liveness, the process is able to serve requests
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// readiness, the controller is able to sync configuration to manba,
+	// every replica syncs manba so the role in the election is only reported
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		role := "standby"
+		if manbaC.IsLeader() {
+			role = "leader"
+		}
+		if err := manbaC.CheckReady(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "%s: %v", role, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "ok: %s", role)
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(manbaC.Status())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	})
+
 	mux.Handle("/metrics", promhttp.Handler())
 
 	mux.HandleFunc("/build", func(w http.ResponseWriter, r *http.Request) {
@@ -271,6 +373,10 @@ func registerHandlers(enableProfiling bool, port int, mux *http.ServeMux) {
 		}
 	})
 
+	if debugToken != "" {
+		registerDebugHandlers(mux, debugToken, manbaC)
+	}
+
 	if enableProfiling {
 		mux.HandleFunc("/debug/pprof/", pprof.Index)
 		mux.HandleFunc("/debug/pprof/heap", pprof.Index)
@@ -4,7 +4,11 @@ go 1.13
 
 replace k8s.io/client-go v11.0.0+incompatible => k8s.io/client-go v0.0.0-20190918160344-1fbdaa4c8d90
 
+// the rate limits shared with the proxy plugin, it's a module to keep dependencies of the plugin apart
+replace github.com/domgoer/manba-ingress/pkg/ratelimit => ./pkg/ratelimit
+
 require (
+	github.com/domgoer/manba-ingress/pkg/ratelimit v0.0.0-00010101000000-000000000000
 	github.com/eapache/channels v1.1.0
 	github.com/eapache/queue v1.1.0 // indirect
 	github.com/emirpasic/gods v1.12.0 // indirect
@@ -25,6 +29,7 @@ require (
 	github.com/valyala/fasttemplate v1.1.0 // indirect
 	github.com/yudai/gojsondiff v1.0.0
 	github.com/yudai/golcs v0.0.0-20170316035057-ecda9a501e82 // indirect
+	google.golang.org/grpc v1.23.1
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/go-playground/pool.v3 v3.1.1
 	k8s.io/api v0.17.3
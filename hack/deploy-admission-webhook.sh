@@ -1,5 +1,9 @@
 #!/bin/bash
 
+# NOTE: the controller manages the certificate and webhook configurations itself
+# when started with --admission-webhook-manage-certs, this script is only needed
+# for certificates issued out of the controller.
+
 # create a self-signed certificate
 openssl req -x509 -newkey rsa:2048 -keyout tls.key -out tls.crt -days 365 \
   -nodes -subj "/CN=manba-validation-webhook.manba.svc"
@@ -9,7 +13,8 @@ kubectl create secret tls manba-validation-webhook -n manba \
 # enable the Admission Webhook Server server
 kubectl patch deploy -n manba manba-ingress \
   -p '{"spec":{"template":{"spec":{"containers":[{"name":"ingress-controller","env":[{"name":"CONTROLLER_ADMISSION_WEBHOOK_LISTEN","value":":8081"}],"volumeMounts":[{"name":"validation-webhook","mountPath":"/admission-webhook"}]}],"volumes":[{"secret":{"secretName":"manba-validation-webhook"},"name":"validation-webhook"}]}}}}'
-# configure k8s apiserver to send validations to the webhook
+# configure k8s apiserver to send validations to the webhook,
+# the rules are the ones the controller writes, see validatingRules and mutatingRules in pkg/admission
 echo "apiVersion: admissionregistration.k8s.io/v1beta1
 kind: ValidatingWebhookConfiguration
 metadata:
@@ -29,19 +34,71 @@ webhooks:
     - UPDATE
     resources:
     - manbaingresses
+    scope: '*'
+  - apiGroups:
+    - configuration.manba.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    - DELETE
+    resources:
     - manbaclusters
+    scope: '*'
+  - apiGroups:
+    - configuration.manba.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - manbacachepolicies
+    scope: '*'
   - apiGroups:
-    - ''
+    - configuration.manba.io
     apiVersions:
-    - 'v1'
+    - '*'
     operations:
     - CREATE
     - UPDATE
+    - DELETE
     resources:
-    - secrets
+    - manbatrafficpolicies
+    scope: '*'
   clientConfig:
     service:
       namespace: manba
       name: manba-validation-webhook
+      port: 443
     caBundle: $(cat tls.crt  | base64 | tr -d '\n') " | kubectl apply -f -
 
+# configure k8s apiserver to send manba ingresses to the webhook to fill defaults
+echo "apiVersion: admissionregistration.k8s.io/v1beta1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: manba-defaults
+webhooks:
+- name: defaults.manba.io
+  failurePolicy: Fail
+  sideEffects: None
+  admissionReviewVersions: [\"v1beta1\"]
+  rules:
+  - apiGroups:
+    - configuration.manba.io
+    apiVersions:
+    - '*'
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - manbaingresses
+    scope: '*'
+  clientConfig:
+    service:
+      namespace: manba
+      name: manba-validation-webhook
+      port: 443
+      path: /mutate
+    caBundle: $(cat tls.crt  | base64 | tr -d '\n') " | kubectl apply -f -
@@ -0,0 +1,308 @@
+package admission
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CACertKey is the key of the ca certificate in secret
+	CACertKey = "ca.crt"
+	// CAKeyKey is the key of the ca private key in secret
+	CAKeyKey = "ca.key"
+
+	// caValidityFactor makes ca live longer than serving certificates,
+	// so that rotating serving certificates doesn't change caBundle
+	caValidityFactor = 10
+)
+
+// CertConfig configures the serving certificate managed by controller
+type CertConfig struct {
+	// SecretNamespace and SecretName refer to the secret storing certificates,
+	// it's shared by all replicas
+	SecretNamespace string
+	SecretName      string
+	// ServiceNamespace and ServiceName refer to the service of webhook,
+	// the serving certificate is issued to its DNS names
+	ServiceNamespace string
+	ServiceName      string
+	// CertDir is where the webhook server loads tls.crt and tls.key
+	CertDir string
+	// ValidatingWebhook and MutatingWebhook are the names of webhook configurations
+	// whose caBundle is kept in sync
+	ValidatingWebhook string
+	MutatingWebhook   string
+	// Validity of serving certificate
+	Validity time.Duration
+	// RotateBefore is how long before expiration the certificate is rotated
+	RotateBefore time.Duration
+}
+
+// CertManager generates and rotates the serving certificate of webhook,
+// and keeps webhook configurations trusting it
+type CertManager struct {
+	cfg    CertConfig
+	client kubernetes.Interface
+	now    func() time.Time
+}
+
+// NewCertManager returns a CertManager
+func NewCertManager(client kubernetes.Interface, cfg CertConfig) *CertManager {
+	return &CertManager{
+		cfg:    cfg,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Run checks the certificate every interval until stopCh is closed
+func (c *CertManager) Run(interval time.Duration, stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if err := c.Ensure(); err != nil {
+			glog.Errorf("ensuring webhook certificate: %v", err)
+		}
+	}, interval, stopCh)
+}
+
+// Ensure makes sure the stored certificate is valid, the webhook server
+// serves it and webhook configurations trust it
+func (c *CertManager) Ensure() error {
+	secret, err := c.ensureSecret()
+	if err != nil {
+		return err
+	}
+	if err := c.writeCertFiles(secret); err != nil {
+		return err
+	}
+	if err := c.ensureValidatingWebhook(secret.Data[CACertKey]); err != nil {
+		return errors.Wrap(err, "ensuring validating webhook configuration")
+	}
+	if err := c.ensureMutatingWebhook(secret.Data[CACertKey]); err != nil {
+		return errors.Wrap(err, "ensuring mutating webhook configuration")
+	}
+	return nil
+}
+
+// ensureSecret returns the secret holding a valid certificate, the certificate is
+// generated if it's missing, invalid or about to expire
+func (c *CertManager) ensureSecret() (*corev1.Secret, error) {
+	secrets := c.client.CoreV1().Secrets(c.cfg.SecretNamespace)
+	secret, err := secrets.Get(c.cfg.SecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: c.cfg.SecretNamespace,
+				Name:      c.cfg.SecretName,
+			},
+			Type: corev1.SecretTypeTLS,
+		}
+		if secret.Data, err = c.generate(nil); err != nil {
+			return nil, err
+		}
+		glog.Infof("creating webhook certificate in secret %s/%s", c.cfg.SecretNamespace, c.cfg.SecretName)
+		created, err := secrets.Create(secret)
+		if apierrors.IsAlreadyExists(err) {
+			// created by another replica
+			return secrets.Get(c.cfg.SecretName, metav1.GetOptions{})
+		}
+		return created, err
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "getting webhook certificate secret")
+	}
+
+	err = c.validate(secret.Data)
+	if err == nil {
+		return secret, nil
+	}
+	glog.Infof("rotating webhook certificate in secret %s/%s: %v", c.cfg.SecretNamespace, c.cfg.SecretName, err)
+
+	secret = secret.DeepCopy()
+	if secret.Data, err = c.generate(secret.Data); err != nil {
+		return nil, err
+	}
+	// conflicts are resolved on next check
+	return secrets.Update(secret)
+}
+
+// dnsNames returns DNS names of webhook service
+func (c *CertManager) dnsNames() []string {
+	svc, ns := c.cfg.ServiceName, c.cfg.ServiceNamespace
+	return []string{
+		svc,
+		fmt.Sprintf("%s.%s", svc, ns),
+		fmt.Sprintf("%s.%s.svc", svc, ns),
+		fmt.Sprintf("%s.%s.svc.cluster.local", svc, ns),
+	}
+}
+
+// validate returns an error if certificates in data should be regenerated
+func (c *CertManager) validate(data map[string][]byte) error {
+	ca, err := parseCert(data[CACertKey])
+	if err != nil {
+		return errors.Wrap(err, "parsing ca certificate")
+	}
+	if _, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey]); err != nil {
+		return errors.Wrap(err, "loading serving certificate")
+	}
+	cert, err := parseCert(data[corev1.TLSCertKey])
+	if err != nil {
+		return errors.Wrap(err, "parsing serving certificate")
+	}
+
+	if deadline := c.now().Add(c.cfg.RotateBefore); cert.NotAfter.Before(deadline) || ca.NotAfter.Before(deadline) {
+		return errors.Errorf("certificate expires at %v", cert.NotAfter)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:     c.dnsNames()[2],
+		Roots:       pool,
+		CurrentTime: c.now(),
+	})
+	return err
+}
+
+// generate issues a serving certificate, the ca in old is reused if it's still valid
+func (c *CertManager) generate(old map[string][]byte) (map[string][]byte, error) {
+	ca, caKey, err := loadCA(old)
+	if err != nil || ca.NotAfter.Before(c.now().Add(c.cfg.RotateBefore)) {
+		ca, caKey, err = c.newCA()
+		if err != nil {
+			return nil, errors.Wrap(err, "generating ca certificate")
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := c.template(c.cfg.Validity)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Subject = pkix.Name{CommonName: c.dnsNames()[2]}
+	tmpl.DNSNames = c.dnsNames()
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating serving certificate")
+	}
+
+	return map[string][]byte{
+		CACertKey:               encodeCert(ca.Raw),
+		CAKeyKey:                encodeKey(caKey),
+		corev1.TLSCertKey:       encodeCert(der),
+		corev1.TLSPrivateKeyKey: encodeKey(key),
+	}, nil
+}
+
+func (c *CertManager) newCA() (*x509.Certificate, *rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := c.template(c.cfg.Validity * caValidityFactor)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.Subject = pkix.Name{CommonName: "manba-ingress-webhook-ca"}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+func (c *CertManager) template(validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := c.now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		// tolerate clock skew between controller and apiserver
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validity),
+	}, nil
+}
+
+// writeCertFiles writes the serving certificate to cert dir if it changed,
+// the webhook server reloads the files on change
+func (c *CertManager) writeCertFiles(secret *corev1.Secret) error {
+	if err := os.MkdirAll(c.cfg.CertDir, 0700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		path := filepath.Join(c.cfg.CertDir, key)
+		if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, secret.Data[key]) {
+			continue
+		}
+		// rename is atomic, so the server never reads a partial file
+		tmp := path + ".tmp"
+		if err := ioutil.WriteFile(tmp, secret.Data[key], 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadCA(data map[string][]byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	ca, err := parseCert(data[CACertKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(data[CAKeyKey])
+	if block == nil {
+		return nil, nil, errors.New("no ca key found")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+func parseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
@@ -0,0 +1,136 @@
+package admission
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCertManager_Ensure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-cert")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	client := fake.NewSimpleClientset()
+	c := NewCertManager(client, CertConfig{
+		SecretNamespace:   "manba",
+		SecretName:        "webhook-cert",
+		ServiceNamespace:  "manba",
+		ServiceName:       "webhook",
+		CertDir:           dir,
+		ValidatingWebhook: "manba-validations",
+		MutatingWebhook:   "manba-defaults",
+		Validity:          90 * 24 * time.Hour,
+		RotateBefore:      30 * 24 * time.Hour,
+	})
+	assert.Nil(t, c.Ensure())
+
+	secret, err := client.CoreV1().Secrets("manba").Get("webhook-cert", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, c.validate(secret.Data))
+	served, err := ioutil.ReadFile(filepath.Join(dir, corev1.TLSCertKey))
+	assert.Nil(t, err)
+	assert.Equal(t, secret.Data[corev1.TLSCertKey], served)
+
+	validating, err := client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get("manba-validations", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, secret.Data[CACertKey], validating.Webhooks[0].ClientConfig.CABundle)
+	mutating, err := client.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get("manba-defaults", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, MutatePath, *mutating.Webhooks[0].ClientConfig.Service.Path)
+
+	// valid certificate is kept
+	assert.Nil(t, c.Ensure())
+	kept, err := client.CoreV1().Secrets("manba").Get("webhook-cert", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, secret.Data, kept.Data)
+
+	// serving certificate is rotated before expiration, ca is kept
+	c.now = func() time.Time { return time.Now().Add(61 * 24 * time.Hour) }
+	assert.NotNil(t, c.validate(secret.Data))
+	assert.Nil(t, c.Ensure())
+	rotated, err := client.CoreV1().Secrets("manba").Get("webhook-cert", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, c.validate(rotated.Data))
+	assert.NotEqual(t, secret.Data[corev1.TLSCertKey], rotated.Data[corev1.TLSCertKey])
+	assert.Equal(t, secret.Data[CACertKey], rotated.Data[CACertKey])
+	served, err = ioutil.ReadFile(filepath.Join(dir, corev1.TLSCertKey))
+	assert.Nil(t, err)
+	assert.Equal(t, rotated.Data[corev1.TLSCertKey], served)
+}
+
+func TestCertManager_EnsureWebhooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-cert")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// configurations of an older release
+	ignore := admissionregistrationv1beta1.Ignore
+	stale := func(resources ...string) []admissionregistrationv1beta1.RuleWithOperations {
+		return []admissionregistrationv1beta1.RuleWithOperations{manbaRule(resources, admissionregistrationv1beta1.Create)}
+	}
+	var timeout int32 = 10
+	client := fake.NewSimpleClientset(
+		&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "manba-validations"},
+			Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{{
+				Name:           validatingWebhookName,
+				ClientConfig:   admissionregistrationv1beta1.WebhookClientConfig{CABundle: []byte("old")},
+				Rules:          stale("manbaingresses", "manbaclusters"),
+				FailurePolicy:  &ignore,
+				TimeoutSeconds: &timeout,
+			}},
+		},
+		&admissionregistrationv1beta1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "manba-defaults"},
+			Webhooks: []admissionregistrationv1beta1.MutatingWebhook{{
+				Name:  mutatingWebhookName,
+				Rules: stale("manbaingresses", "manbaclusters"),
+			}},
+		},
+	)
+	c := NewCertManager(client, CertConfig{
+		SecretNamespace:   "manba",
+		SecretName:        "webhook-cert",
+		ServiceNamespace:  "manba",
+		ServiceName:       "webhook",
+		CertDir:           dir,
+		ValidatingWebhook: "manba-validations",
+		MutatingWebhook:   "manba-defaults",
+		Validity:          90 * 24 * time.Hour,
+		RotateBefore:      30 * 24 * time.Hour,
+	})
+	assert.Nil(t, c.Ensure())
+
+	secret, err := client.CoreV1().Secrets("manba").Get("webhook-cert", metav1.GetOptions{})
+	assert.Nil(t, err)
+	validating, err := client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Get("manba-validations", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, validating.Webhooks, 1)
+	assert.Equal(t, c.validatingWebhook(secret.Data[CACertKey]).ClientConfig, validating.Webhooks[0].ClientConfig)
+	assert.Equal(t, validatingRules(), validating.Webhooks[0].Rules)
+	assert.Equal(t, admissionregistrationv1beta1.Fail, *validating.Webhooks[0].FailurePolicy)
+	assert.Equal(t, admissionregistrationv1beta1.SideEffectClassNone, *validating.Webhooks[0].SideEffects)
+	// fields we don't own are kept
+	assert.Equal(t, timeout, *validating.Webhooks[0].TimeoutSeconds)
+
+	mutating, err := client.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get("manba-defaults", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, mutatingRules(), mutating.Webhooks[0].Rules)
+	assert.Equal(t, MutatePath, *mutating.Webhooks[0].ClientConfig.Service.Path)
+
+	// up to date configurations are not updated again
+	client.ClearActions()
+	assert.Nil(t, c.Ensure())
+	for _, action := range client.Actions() {
+		assert.NotEqual(t, "update", action.GetVerb(), action.GetResource().Resource)
+	}
+}
@@ -0,0 +1,46 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// mutator writes defaults into manba resources on admission,
+// so that stored objects are self-describing.
+// ManbaClusters are not defaulted, the fields of their traffic policies are inherited
+// from ManbaTrafficPolicies, so the defaults are filled at the end of the inheritance
+// when the effective policies are built, see trafficpolicy.Effective
+type mutator struct{}
+
+// Handle responds with patches which fill the defaults of the entity
+func (m *mutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var obj runtime.Object
+	switch req.Resource {
+	case manbaIngressResource:
+		ingress := new(configurationv1beta1.ManbaIngress)
+		if err := decode(req.Object.Raw, ingress); err != nil {
+			return webhook.Errored(http.StatusBadRequest, err)
+		}
+		configurationv1beta1.SetManbaIngressDefaults(ingress)
+		obj = ingress
+	default:
+		return webhook.Allowed("unknown resource type")
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return webhook.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, raw)
+}
+
+func decode(raw []byte, obj runtime.Object) error {
+	_, _, err := codecs.UniversalDeserializer().Decode(raw, nil, obj)
+	return err
+}
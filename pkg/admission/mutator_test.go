@@ -0,0 +1,82 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestMutator_Handle(t *testing.T) {
+	ingress := &configurationv1beta1.ManbaIngress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: configurationv1beta1.SchemeGroupVersion.String(),
+			Kind:       "ManbaIngress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ing",
+			Namespace: "default",
+		},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Match: []configurationv1beta1.ManbaHTTPMatch{{
+					Rules: []configurationv1beta1.ManbaHTTPMatchRule{{}},
+				}},
+				Split: []configurationv1beta1.ManbaHTTPRouting{{}},
+			}},
+		},
+	}
+	raw, err := json.Marshal(ingress)
+	assert.Nil(t, err)
+
+	rsp := (&mutator{}).Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Resource:  manbaIngressResource,
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+	assert.True(t, rsp.Allowed)
+
+	paths := make(map[string]interface{})
+	for _, patch := range rsp.Patches {
+		paths[patch.Path] = patch.Value
+	}
+	assert.Equal(t, map[string]interface{}{
+		"/spec/http/0/match/0/rules/0/uri/pattern": configurationv1beta1.DefaultURIPattern,
+		"/spec/http/0/match/0/rules/0/method":      configurationv1beta1.DefaultMethod,
+		"/spec/http/0/match/0/rules/0/match_type":  configurationv1beta1.DefaultMatchType,
+		"/spec/http/0/split/0/rate":                float64(configurationv1beta1.DefaultRoutingRate),
+	}, paths)
+}
+
+func TestMutator_HandleManbaCluster(t *testing.T) {
+	cluster := &configurationv1beta1.ManbaCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: configurationv1beta1.SchemeGroupVersion.String(),
+			Kind:       "ManbaCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+		},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			TrafficPolicy: &configurationv1beta1.TrafficPolicy{},
+		},
+	}
+	raw, err := json.Marshal(cluster)
+	assert.Nil(t, err)
+
+	// the load balancer is left to inherited policies
+	rsp := (&mutator{}).Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Resource:  manbaClusterResource,
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}})
+	assert.True(t, rsp.Allowed)
+	assert.Empty(t, rsp.Patches)
+}
@@ -0,0 +1,79 @@
+package admission
+
+import (
+	"fmt"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+)
+
+// routeKey identifies the requests matched by a manba api, apis with the same
+// key and overlapping methods match the same requests
+type routeKey struct {
+	Host      string
+	Pattern   string
+	MatchType string
+}
+
+// route is a match rule of a manba ingress
+type route struct {
+	routeKey
+	Method  string
+	Ingress string
+}
+
+func (r route) String() string {
+	return fmt.Sprintf("host=%q pattern=%q method=%q matchType=%q", r.Host, r.Pattern, r.Method, r.MatchType)
+}
+
+// overlaps returns true if r and o match the same requests
+func (r route) overlaps(o route) bool {
+	return r.routeKey == o.routeKey && (r.Method == o.Method || r.Method == configurationv1beta1.DefaultMethod || o.Method == configurationv1beta1.DefaultMethod)
+}
+
+// routeIndex indexes routes of manba ingresses by routeKey
+type routeIndex map[routeKey][]route
+
+// ingressRoutes returns routes of ingress, its defaults must be filled
+func ingressRoutes(ingress *configurationv1beta1.ManbaIngress) []route {
+	var routes []route
+	for _, rule := range ingress.Spec.HTTP {
+		for _, match := range rule.Match {
+			for _, r := range match.Rules {
+				routes = append(routes, route{
+					routeKey: routeKey{
+						Host:      match.Host,
+						Pattern:   r.URI.Pattern,
+						MatchType: metapb.MatchRule(metapb.MatchRule_value[r.MatchType]).String(),
+					},
+					Method:  *r.Method,
+					Ingress: fmt.Sprintf("%s/%s", ingress.GetNamespace(), ingress.GetName()),
+				})
+			}
+		}
+	}
+	return routes
+}
+
+func newRouteIndex(ingresses []*configurationv1beta1.ManbaIngress) routeIndex {
+	index := make(routeIndex)
+	for _, ingress := range ingresses {
+		// stored ingresses may have no defaults
+		ingress = ingress.DeepCopy()
+		configurationv1beta1.SetManbaIngressDefaults(ingress)
+		for _, r := range ingressRoutes(ingress) {
+			index[r.routeKey] = append(index[r.routeKey], r)
+		}
+	}
+	return index
+}
+
+// conflict returns the first route in index overlapping r
+func (index routeIndex) conflict(r route) (route, bool) {
+	for _, o := range index[r.routeKey] {
+		if r.overlaps(o) {
+			return o, true
+		}
+	}
+	return route{}, false
+}
@@ -7,11 +7,13 @@ import (
 	"strconv"
 	"strings"
 
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
 	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/golang/glog"
 
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -19,12 +21,31 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// MutatePath is the path of the webhook which fills defaults of manba resources,
+// other paths validate manba resources
+const MutatePath = "/mutate"
+
 var (
 	manbaIngressResource = metav1.GroupVersionResource{
 		Group:    configurationv1beta1.SchemeGroupVersion.Group,
 		Version:  configurationv1beta1.SchemeGroupVersion.Version,
 		Resource: "manbaingresses",
 	}
+	manbaClusterResource = metav1.GroupVersionResource{
+		Group:    configurationv1beta1.SchemeGroupVersion.Group,
+		Version:  configurationv1beta1.SchemeGroupVersion.Version,
+		Resource: "manbaclusters",
+	}
+	manbaCachePolicyResource = metav1.GroupVersionResource{
+		Group:    configurationv1beta1.SchemeGroupVersion.Group,
+		Version:  configurationv1beta1.SchemeGroupVersion.Version,
+		Resource: "manbacachepolicies",
+	}
+	manbaTrafficPolicyResource = metav1.GroupVersionResource{
+		Group:    configurationv1beta1.SchemeGroupVersion.Group,
+		Version:  configurationv1beta1.SchemeGroupVersion.Version,
+		Resource: "manbatrafficpolicies",
+	}
 
 	scheme = runtime.NewScheme()
 	codecs = serializer.NewCodecFactory(scheme)
@@ -74,11 +95,119 @@ func (s *Server) Handle(ctx context.Context, req admission.Request) admission.Re
 		if !valid {
 			return webhook.Denied(msg)
 		}
+		return allowed(req, msg)
+	case manbaClusterResource:
+		old, cluster, err := decodeManbaClusters(req)
+		if err != nil {
+			return webhook.Errored(http.StatusInternalServerError, err)
+		}
+
+		valid, msg, err := s.Validator.ValidateManbaCluster(old, cluster)
+		if err != nil {
+			return webhook.Errored(http.StatusInternalServerError, err)
+		}
+		if !valid {
+			return webhook.Denied(msg)
+		}
+		return allowed(req, msg)
+	case manbaCachePolicyResource:
+		policy := new(configurationv1beta1.ManbaCachePolicy)
+		deserializer := codecs.UniversalDeserializer()
+		_, _, err := deserializer.Decode(req.Object.Raw,
+			nil, policy)
+		if err != nil {
+			return webhook.Errored(http.StatusInternalServerError, err)
+		}
+
+		valid, msg, err := s.Validator.ValidateManbaCachePolicy(policy)
+		if err != nil {
+			return webhook.Errored(http.StatusInternalServerError, err)
+		}
+		if !valid {
+			return webhook.Denied(msg)
+		}
 		return webhook.Allowed("The resource definition conforms to the specification")
+	case manbaTrafficPolicyResource:
+		old, policy, err := decodeManbaTrafficPolicies(req)
+		if err != nil {
+			return webhook.Errored(http.StatusInternalServerError, err)
+		}
+
+		valid, msg, err := s.Validator.ValidateManbaTrafficPolicy(old, policy)
+		if err != nil {
+			return webhook.Errored(http.StatusInternalServerError, err)
+		}
+		if !valid {
+			return webhook.Denied(msg)
+		}
+		return allowed(req, msg)
 	}
 	return webhook.Allowed("unknown resource type")
 }
 
+// allowed returns the response of a valid object, apiserver drops the message of allowed responses
+// and admission v1beta1 has no warnings, so the warning is only logged here,
+// the controller records the warnings as events on manba ingresses and clusters,
+// the ones of manba traffic policies on the clusters inheriting them
+func allowed(req admission.Request, warning string) admission.Response {
+	if warning != "" {
+		glog.Warningf("%s %s/%s is admitted with warning: %s", req.Kind.Kind, req.Namespace, req.Name, warning)
+	}
+	return webhook.Allowed("The resource definition conforms to the specification")
+}
+
+// decodeManbaClusters returns the old and new manba cluster of request,
+// old is nil on create and new is nil on delete
+func decodeManbaClusters(req admission.Request) (old, cluster *configurationv1beta1.ManbaCluster, err error) {
+	deserializer := codecs.UniversalDeserializer()
+	if req.Operation != admissionv1beta1.Delete {
+		cluster = new(configurationv1beta1.ManbaCluster)
+		if _, _, err = deserializer.Decode(req.Object.Raw, nil, cluster); err != nil {
+			return nil, nil, err
+		}
+	}
+	if req.Operation == admissionv1beta1.Create {
+		return nil, cluster, nil
+	}
+
+	old = new(configurationv1beta1.ManbaCluster)
+	// old object of delete is not sent by apiserver before 1.15
+	if len(req.OldObject.Raw) == 0 {
+		old.Namespace, old.Name = req.Namespace, req.Name
+		return old, cluster, nil
+	}
+	if _, _, err = deserializer.Decode(req.OldObject.Raw, nil, old); err != nil {
+		return nil, nil, err
+	}
+	return old, cluster, nil
+}
+
+// decodeManbaTrafficPolicies returns the old and new manba traffic policy of request,
+// old is nil on create and new is nil on delete
+func decodeManbaTrafficPolicies(req admission.Request) (old, policy *configurationv1beta1.ManbaTrafficPolicy, err error) {
+	deserializer := codecs.UniversalDeserializer()
+	if req.Operation != admissionv1beta1.Delete {
+		policy = new(configurationv1beta1.ManbaTrafficPolicy)
+		if _, _, err = deserializer.Decode(req.Object.Raw, nil, policy); err != nil {
+			return nil, nil, err
+		}
+	}
+	if req.Operation == admissionv1beta1.Create {
+		return nil, policy, nil
+	}
+
+	old = new(configurationv1beta1.ManbaTrafficPolicy)
+	// old object of delete is not sent by apiserver before 1.15
+	if len(req.OldObject.Raw) == 0 {
+		old.Namespace, old.Name = req.Namespace, req.Name
+		return old, policy, nil
+	}
+	if _, _, err = deserializer.Decode(req.OldObject.Raw, nil, old); err != nil {
+		return nil, nil, err
+	}
+	return old, policy, nil
+}
+
 // Start starts all registered Controllers and blocks until the Stop channel is closed.
 // Returns an error if there is an error starting any controller.
 func (s *Server) Start(stopCh <-chan struct{}) error {
@@ -86,6 +215,9 @@ func (s *Server) Start(stopCh <-chan struct{}) error {
 	svr.Register("/", &webhook.Admission{
 		Handler: s,
 	})
+	svr.Register(MutatePath, &webhook.Admission{
+		Handler: &mutator{},
+	})
 	return s.manager.Start(stopCh)
 }
 
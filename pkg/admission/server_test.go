@@ -1 +1,45 @@
 package admission
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestServer_HandleManbaCluster(t *testing.T) {
+	s := &Server{Validator: newTestValidator(t)}
+	raw := func(cls *configurationv1beta1.ManbaCluster) runtime.RawExtension {
+		cls.APIVersion = configurationv1beta1.SchemeGroupVersion.String()
+		cls.Kind = "ManbaCluster"
+		data, err := json.Marshal(cls)
+		assert.Nil(t, err)
+		return runtime.RawExtension{Raw: data}
+	}
+
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Resource:  manbaClusterResource,
+		Operation: admissionv1beta1.Create,
+		Object:    raw(newTestCluster("v1")),
+	}}
+	rsp := s.Handle(context.Background(), req)
+	assert.True(t, rsp.Allowed)
+
+	req.Object = raw(newTestCluster("v1", "v1"))
+	rsp = s.Handle(context.Background(), req)
+	assert.False(t, rsp.Allowed)
+
+	req = admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Resource:  manbaClusterResource,
+		Operation: admissionv1beta1.Delete,
+		Namespace: "default",
+		Name:      "test-cls",
+	}}
+	rsp = s.Handle(context.Background(), req)
+	assert.True(t, rsp.Allowed)
+}
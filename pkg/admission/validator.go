@@ -3,27 +3,60 @@ package admission
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
 	configurationinformer "github.com/domgoer/manba-ingress/pkg/client/informers/externalversions"
+	"github.com/domgoer/manba-ingress/pkg/ingress/annotations"
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/domgoer/manba-ingress/pkg/manba/validation"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
 )
 
+// nameRegexp matches names of rules, which are parts of api names
+var nameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
 // ManbaValidator validates Manba entities.
 type ManbaValidator interface {
 	ValidateManbaIngress(*configurationv1beta1.ManbaIngress) (bool, string, error)
+	// ValidateManbaCluster validates a change of manba cluster,
+	// old is nil on create and cluster is nil on delete
+	ValidateManbaCluster(old, cluster *configurationv1beta1.ManbaCluster) (bool, string, error)
+	ValidateManbaCachePolicy(*configurationv1beta1.ManbaCachePolicy) (bool, string, error)
+	// ValidateManbaTrafficPolicy validates a change of manba traffic policy,
+	// old is nil on create and policy is nil on delete
+	ValidateManbaTrafficPolicy(old, policy *configurationv1beta1.ManbaTrafficPolicy) (bool, string, error)
 }
 
 // validator implements ManbaValidator
 type validator struct {
 	manbaInformer configurationinformer.SharedInformerFactory
+	store         store.Store
+	parser        *parser.Parser
+	// isValidIngressClass returns true if the ingress is handled by this controller
+	isValidIngressClass func(*metav1.ObjectMeta) bool
 }
 
 var _ ManbaValidator = &validator{}
 
-// NewValidator returns new validator with manba factory
-func NewValidator(informer configurationinformer.SharedInformerFactory) *validator {
-	return &validator{manbaInformer: informer}
+// NewValidator returns new validator with manba factory,
+// store is used to build manba configuration in dry run,
+// routes only conflict with the ones of ingresses in ingressClass
+func NewValidator(informer configurationinformer.SharedInformerFactory, s store.Store, ingressClass string) *validator {
+	return &validator{
+		manbaInformer: informer,
+		store:         s,
+		// events of dry run are dropped
+		parser:              parser.New(s, &record.FakeRecorder{}),
+		isValidIngressClass: annotations.IngressClassValidatorFuncFromObjectMeta(ingressClass),
+	}
 }
 
 // ValidatePlugin checks if manba ingress is valid. It does so by
@@ -32,29 +65,60 @@ func NewValidator(informer configurationinformer.SharedInformerFactory) *validat
 // The first boolean communicates if manba ingress is valid or not and string
 // holds a message if the entity is not valid
 func (v *validator) ValidateManbaIngress(ingress *configurationv1beta1.ManbaIngress) (bool, string, error) {
-	var clusters []configurationv1beta1.ManbaHTTPRouteCluster
-	for _, rule := range ingress.Spec.HTTP {
-		for _, route := range rule.Route {
-			clusters = append(clusters, route.Cluster)
+	// the checks see the ingress as the controller does, objects stored before
+	// defaulting was enabled have no defaults
+	ingress = ingress.DeepCopy()
+	configurationv1beta1.SetManbaIngressDefaults(ingress)
 
+	for i, rule := range ingress.Spec.HTTP {
+		for j, route := range rule.Route {
 			// check parameter value
-			if !v.isManbaHTTPRouteMatchValid(route.Match) {
-				return false, "manba http route match value must conform to the regular expression rule", nil
+			if err := route.Match.Validate(); err != nil {
+				return false, fmt.Sprintf("http[%d].route[%d].match: %v", i, j, err), nil
 			}
 		}
+	}
 
-		for _, mirror := range rule.Mirror {
-			clusters = append(clusters, mirror.Cluster)
-		}
+	if msg := validateAPINames(ingress); msg != "" {
+		return false, msg, nil
+	}
 
-		for _, split := range rule.Split {
-			clusters = append(clusters, split.Cluster)
+	if msg := validateRoutingRates(ingress); msg != "" {
+		return false, msg, nil
+	}
+
+	if msg := validateRoutingConditions(ingress); msg != "" {
+		return false, msg, nil
+	}
+
+	if msg := validateMirrors(ingress); msg != "" {
+		return false, msg, nil
+	}
+
+	if msg := validateAggregates(ingress); msg != "" {
+		return false, msg, nil
+	}
+
+	if msg, err := v.validateCachePolicyRefs(ingress); err != nil || msg != "" {
+		return false, msg, err
+	}
+
+	for i, rule := range ingress.Spec.HTTP {
+		if msg := validateSchedules(rule.Schedules, false); msg != "" {
+			return false, fmt.Sprintf("http[%d].%s", i, msg), nil
 		}
+	}
 
+	if msg := validateMaintenance(ingress); msg != "" {
+		return false, msg, nil
+	}
+
+	if msg := validateRateLimits(ingress); msg != "" {
+		return false, msg, nil
 	}
 
 	// check cluster exists
-	for _, cluster := range clusters {
+	for _, cluster := range referencedClusters(ingress) {
 		exist, err := v.isClusterExist(ingress.GetNamespace(), cluster)
 		if err != nil {
 			return false, "", err
@@ -63,7 +127,118 @@ func (v *validator) ValidateManbaIngress(ingress *configurationv1beta1.ManbaIngr
 			return false, fmt.Sprintf("manba cluster %s/%s not found", ingress.GetNamespace(), cluster.Name), nil
 		}
 	}
-	return true, "", nil
+
+	// check ports are exposed by services of subsets
+	for _, cluster := range referencedClusters(ingress) {
+		msg, err := v.validateClusterPort(ingress.GetNamespace(), cluster)
+		if err != nil {
+			return false, "", err
+		}
+		if msg != "" {
+			return false, msg, nil
+		}
+	}
+
+	// build configuration with ingress and run it through gateway validators
+	reasons, err := v.dryRun(ingress)
+	if err != nil {
+		return false, "", err
+	}
+	if len(reasons) != 0 {
+		return false, strings.Join(reasons, "; "), nil
+	}
+
+	// check routes are not claimed by other ingresses
+	if !annotations.AllowRouteOverride(ingress) {
+		msg, err := v.findRouteConflict(ingress)
+		if err != nil {
+			return false, "", err
+		}
+		if msg != "" {
+			return false, msg, nil
+		}
+	}
+
+	// valid ingresses may still get warnings
+	return true, strings.Join(parser.MirrorWarnings(ingress), "; "), nil
+}
+
+// dryRun returns why manba would reject entities generated from ingress
+func (v *validator) dryRun(ingress *configurationv1beta1.ManbaIngress) ([]string, error) {
+	s, err := v.parser.BuildIngress(ingress)
+	if err != nil {
+		return nil, err
+	}
+	var reasons []string
+	for _, e := range s.Errors {
+		if e.Kind == parser.KindManbaIngress && e.Namespace == ingress.GetNamespace() && e.Name == ingress.GetName() {
+			reasons = append(reasons, e.Error())
+		}
+	}
+	for _, e := range validation.Validate(s) {
+		reasons = append(reasons, e.String())
+	}
+	return reasons, nil
+}
+
+// findRouteConflict returns a message if a route of ingress overlaps
+// a route of another manba ingress, ingresses of other classes are served by other controllers,
+// so they never conflict
+func (v *validator) findRouteConflict(ingress *configurationv1beta1.ManbaIngress) (string, error) {
+	if !v.isValidIngressClass(&ingress.ObjectMeta) {
+		return "", nil
+	}
+	all, err := v.manbaInformer.Configuration().V1beta1().ManbaIngresses().Lister().List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	var others []*configurationv1beta1.ManbaIngress
+	for _, other := range all {
+		if !v.isValidIngressClass(&other.ObjectMeta) {
+			continue
+		}
+		if other.GetNamespace() != ingress.GetNamespace() || other.GetName() != ingress.GetName() {
+			others = append(others, other)
+		}
+	}
+
+	index := newRouteIndex(others)
+	for _, r := range ingressRoutes(ingress) {
+		if o, ok := index.conflict(r); ok {
+			return fmt.Sprintf("route %s conflicts with route %s of manba ingress %s, set annotation %s: \"true\" to override it",
+				r, o, o.Ingress, annotations.AllowRouteOverrideKey), nil
+		}
+	}
+	return "", nil
+}
+
+// referencedClusters returns the clusters referenced by routes, aggregate calls, mirrors and splits of ingress
+func referencedClusters(ingress *configurationv1beta1.ManbaIngress) []configurationv1beta1.ManbaHTTPRouteCluster {
+	var clusters []configurationv1beta1.ManbaHTTPRouteCluster
+	for _, rule := range ingress.Spec.HTTP {
+		for _, route := range rule.Route {
+			clusters = append(clusters, route.Cluster)
+		}
+
+		for _, call := range rule.Aggregate.GetCalls() {
+			clusters = append(clusters, call.Cluster)
+		}
+
+		for _, mirror := range rule.Mirror {
+			clusters = append(clusters, mirror.Cluster)
+		}
+
+		for _, split := range rule.Split {
+			clusters = append(clusters, split.Cluster)
+		}
+
+		for _, schedule := range rule.Schedules {
+			for _, split := range schedule.Split {
+				clusters = append(clusters, split.Cluster)
+			}
+		}
+	}
+	return clusters
 }
 
 func (v *validator) isClusterExist(namespace string, cluster configurationv1beta1.ManbaHTTPRouteCluster) (bool, error) {
@@ -74,30 +249,648 @@ func (v *validator) isClusterExist(namespace string, cluster configurationv1beta
 	if err != nil {
 		return false, err
 	}
+	subset, ok := cls.ResolveSubset(cluster.Subset)
+	if !ok {
+		return false, nil
+	}
 	exist := false
 	for _, subSet := range cls.Spec.Subsets {
-		if cluster.Subset == subSet.Name {
+		if subset == subSet.Name {
 			exist = true
 		}
 	}
 	return exist, nil
 }
 
-func (v *validator) isManbaHTTPRouteMatchValid(match *configurationv1beta1.ManbaHTTPRouteMatch) bool {
-	if match == nil {
-		return true
+// validateAPINames returns a message if names of rules are not DNS labels
+// or two match rules get the same api name, defaults of ingress must be filled
+func validateAPINames(ingress *configurationv1beta1.ManbaIngress) string {
+	names := make(map[string]string)
+	for i, rule := range ingress.Spec.HTTP {
+		if rule.Name != "" && !nameRegexp.MatchString(rule.Name) {
+			return fmt.Sprintf("http[%d]: name %q must consist of lower case alphanumeric characters or '-'", i, rule.Name)
+		}
+		for j, match := range rule.Match {
+			for k, matchRule := range match.Rules {
+				path := fmt.Sprintf("http[%d].match[%d].rules[%d]", i, j, k)
+				if matchRule.Name != "" && !nameRegexp.MatchString(matchRule.Name) {
+					return fmt.Sprintf("%s: name %q must consist of lower case alphanumeric characters or '-'", path, matchRule.Name)
+				}
+				name := parser.APIName(ingress, &rule, match.Host, &matchRule)
+				if other, ok := names[name]; ok {
+					return fmt.Sprintf("%s and %s get the same api name %s, name them to tell them apart", other, path, name)
+				}
+				names[name] = path
+			}
+		}
+	}
+	return ""
+}
+
+// validateRoutingRates returns a message if rates of splits or mirrors are out of range,
+// rates of splits are relative weights if ingress normalizes them, defaults of ingress must be filled
+func validateRoutingRates(ingress *configurationv1beta1.ManbaIngress) string {
+	normalize := annotations.NormalizeSplitWeights(ingress)
+
+	for i, rule := range ingress.Spec.HTTP {
+		for j, mirror := range rule.Mirror {
+			if mirror.Sample != nil && mirror.Rate != nil && *mirror.Sample != *mirror.Rate {
+				return fmt.Sprintf("http[%d].mirror[%d]: sample and rate are different, set only sample", i, j)
+			}
+			if sample := mirror.GetSample(); sample < 1 || sample > 100 {
+				return fmt.Sprintf("http[%d].mirror[%d]: sample must be in range [1, 100], got %d", i, j, sample)
+			}
+		}
+
+		if msg := validateSplitRates(rule.Split, fmt.Sprintf("http[%d]", i), normalize); msg != "" {
+			return msg
+		}
+		for j, schedule := range rule.Schedules {
+			if msg := validateSplitRates(schedule.Split, fmt.Sprintf("http[%d].schedules[%d]", i, j), normalize); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+// validateSplitRates returns a message if rates of splits are out of range, path is the path of their parent
+func validateSplitRates(splits []configurationv1beta1.ManbaHTTPRouting, path string, normalize bool) string {
+	var total int32
+	for j, split := range splits {
+		rate := *split.Rate
+		if normalize {
+			if rate < 1 {
+				return fmt.Sprintf("%s.split[%d]: weight must be positive, got %d", path, j, rate)
+			}
+			continue
+		}
+		if rate < 1 || rate > 100 {
+			return fmt.Sprintf("%s.split[%d]: rate must be in range [1, 100], got %d", path, j, rate)
+		}
+		total += rate
+	}
+	if total > 100 {
+		return fmt.Sprintf("%s: rates of splits add up to %d, must not exceed 100, set annotation %s: \"true\" to use them as weights",
+			path, total, annotations.NormalizeSplitWeightsKey)
+	}
+	return ""
+}
+
+// httpMethods are the methods mirrors can filter
+var httpMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true,
+	"DELETE": true, "OPTIONS": true, "CONNECT": true, "TRACE": true,
+}
+
+// validateMirrors returns a message if a mirror asks for what manba can't do,
+// defaults of ingress must be filled
+func validateMirrors(ingress *configurationv1beta1.ManbaIngress) string {
+	for i, rule := range ingress.Spec.HTTP {
+		for j, mirror := range rule.Mirror {
+			path := fmt.Sprintf("http[%d].mirror[%d]", i, j)
+			if len(mirror.Methods) == 0 {
+				continue
+			}
+			for _, method := range mirror.Methods {
+				if !httpMethods[strings.ToUpper(method)] {
+					return fmt.Sprintf("%s: unknown method %q", path, method)
+				}
+			}
+			// the method of request is not a routing condition, so mirrors filter apis by their methods
+			for _, match := range rule.Match {
+				for _, matchRule := range match.Rules {
+					if *matchRule.Method == configurationv1beta1.DefaultMethod {
+						return fmt.Sprintf("%s: methods can't filter requests of match rules of all methods, set method of the match rules", path)
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// validateMaintenance returns a message if the maintenance response or allowlist is invalid
+func validateMaintenance(ingress *configurationv1beta1.ManbaIngress) string {
+	m := ingress.Spec.Maintenance
+	if m == nil {
+		return ""
+	}
+	if code := m.GetResponse().Code; code < 100 || code > 599 {
+		return fmt.Sprintf("maintenance.response.code: %d is not a http status code", code)
+	}
+	if m.BodyFrom != nil && (m.BodyFrom.Name == "" || m.BodyFrom.Key == "") {
+		return "maintenance.bodyFrom: name and key are required"
+	}
+	if _, err := m.AllowlistConditions(); err != nil {
+		return fmt.Sprintf("maintenance.%v", err)
+	}
+	if len(m.Allowlist) == 0 {
+		return ""
+	}
+	// clients of allowlist are routed to the cluster of the only route
+	for i, rule := range ingress.Spec.HTTP {
+		if len(rule.Route)+len(rule.Aggregate.GetCalls()) > 1 {
+			return fmt.Sprintf("maintenance.allowlist: http[%d] has more than one route, allowlist only works with rules of one route", i)
+		}
+	}
+	return ""
+}
+
+// validateRateLimits returns a message if a rate limit is invalid or shared limits of ingress differ,
+// since they are one quota of each client
+func validateRateLimits(ingress *configurationv1beta1.ManbaIngress) string {
+	var shared *configurationv1beta1.ManbaHTTPRateLimit
+	var sharedAt int
+	for i, rule := range ingress.Spec.HTTP {
+		limit := rule.RateLimit
+		if limit == nil {
+			continue
+		}
+		if err := limit.Validate(); err != nil {
+			return fmt.Sprintf("http[%d].rateLimit.%v", i, err)
+		}
+		if !limit.Shared {
+			continue
+		}
+		if shared == nil {
+			shared, sharedAt = limit, i
+			continue
+		}
+		if limit.Key != shared.Key || limit.QPS != shared.QPS || limit.GetBurst() != shared.GetBurst() {
+			return fmt.Sprintf("http[%d].rateLimit: shared limit differs from the one of http[%d]", i, sharedAt)
+		}
+	}
+	return ""
+}
+
+// validateAggregates returns a message if calls of an aggregate are invalid or
+// attributes of calls and routes of a rule are not unique
+func validateAggregates(ingress *configurationv1beta1.ManbaIngress) string {
+	for i, rule := range ingress.Spec.HTTP {
+		attrs := make(map[string]bool)
+		for j, route := range rule.Route {
+			if route.AttrName == "" {
+				continue
+			}
+			if attrs[route.AttrName] {
+				return fmt.Sprintf("http[%d].route[%d]: duplicate attrName %s", i, j, route.AttrName)
+			}
+			attrs[route.AttrName] = true
+		}
+
+		if rule.Aggregate == nil {
+			continue
+		}
+		path := fmt.Sprintf("http[%d].aggregate", i)
+		if len(rule.Route) != 0 {
+			return fmt.Sprintf("%s: route and aggregate can't be set together", path)
+		}
+		if len(rule.Aggregate.Calls) == 0 {
+			return fmt.Sprintf("%s: calls are required", path)
+		}
+		// manba applies routings to all calls of an api
+		if len(rule.Split) != 0 || len(rule.Mirror) != 0 {
+			return fmt.Sprintf("%s: split and mirror can't be used with aggregate, they apply to every call", path)
+		}
+		for _, schedule := range rule.Schedules {
+			if schedule.Split != nil {
+				return fmt.Sprintf("%s: split of schedule %s can't be used with aggregate, it applies to every call", path, schedule.Name)
+			}
+		}
+		for j, call := range rule.Aggregate.Calls {
+			callPath := fmt.Sprintf("%s.calls[%d]", path, j)
+			if call.Name == "" {
+				return fmt.Sprintf("%s: name is required", callPath)
+			}
+			if attrs[call.Name] {
+				return fmt.Sprintf("%s: duplicate name %s", callPath, call.Name)
+			}
+			attrs[call.Name] = true
+			if call.Batch < 0 {
+				return fmt.Sprintf("%s: batch must not be negative", callPath)
+			}
+			if err := call.Match.Validate(); err != nil {
+				return fmt.Sprintf("%s.match: %v", callPath, err)
+			}
+		}
+	}
+	return ""
+}
+
+// validateRoutingConditions returns a message if a canary of split or mirror is invalid,
+// or the gateway would ignore a condition, a condition it ignores never matches
+func validateRoutingConditions(ingress *configurationv1beta1.ManbaIngress) string {
+	check := func(routing configurationv1beta1.ManbaHTTPRouting, path string) string {
+		conds := routing.Conditions
+		if routing.Canary != nil {
+			compiled, err := routing.Canary.ToConditions()
+			if err != nil {
+				return fmt.Sprintf("%s.canary: %v", path, err)
+			}
+			conds = append(conds[:len(conds):len(conds)], compiled...)
+		}
+		for i, cond := range conds {
+			if msg := validateCondition(cond); msg != "" {
+				return fmt.Sprintf("%s.conditions[%d]: %s", path, i, msg)
+			}
+		}
+		return ""
+	}
+
+	for i, rule := range ingress.Spec.HTTP {
+		for j, split := range rule.Split {
+			if msg := check(split, fmt.Sprintf("http[%d].split[%d]", i, j)); msg != "" {
+				return msg
+			}
+		}
+		for j, mirror := range rule.Mirror {
+			if msg := check(mirror.ManbaHTTPRouting, fmt.Sprintf("http[%d].mirror[%d]", i, j)); msg != "" {
+				return msg
+			}
+		}
+		for j, schedule := range rule.Schedules {
+			for k, split := range schedule.Split {
+				if msg := check(split, fmt.Sprintf("http[%d].schedules[%d].split[%d]", i, j, k)); msg != "" {
+					return msg
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// validateCondition returns why the gateway would ignore cond
+func validateCondition(cond metapb.Condition) string {
+	param := cond.Parameter
+	if _, ok := metapb.Source_name[int32(param.Source)]; !ok {
+		return fmt.Sprintf("unknown parameter source %d", param.Source)
+	}
+	if param.Source != metapb.PathValue && param.Name == "" {
+		return "parameter name must not be empty"
+	}
+
+	switch cond.Cmp {
+	case metapb.CMPEQ, metapb.CMPIn:
+		// parameters with empty values never match
+		if cond.Expect == "" {
+			return fmt.Sprintf("expect of %s must not be empty", cond.Cmp)
+		}
+	case metapb.CMPLT, metapb.CMPLE, metapb.CMPGT, metapb.CMPGE:
+		if _, err := strconv.Atoi(cond.Expect); err != nil {
+			return fmt.Sprintf("expect %q of %s must be an integer", cond.Expect, cond.Cmp)
+		}
+	case metapb.CMPMatch:
+		if _, err := regexp.Compile(cond.Expect); err != nil {
+			return fmt.Sprintf("expect %q of %s is not a valid regular expression: %v", cond.Expect, cond.Cmp, err)
+		}
+	default:
+		return fmt.Sprintf("unknown cmp %d", cond.Cmp)
+	}
+	return ""
+}
+
+// validateClusterPort returns a message if no service selected by the subset
+// exposes the port of cluster, subsets without services are not checked
+func (v *validator) validateClusterPort(namespace string, cluster configurationv1beta1.ManbaHTTPRouteCluster) (string, error) {
+	cls, err := v.manbaInformer.Configuration().V1beta1().ManbaClusters().Lister().ManbaClusters(namespace).Get(cluster.Name)
+	if err != nil {
+		return "", err
+	}
+	name, _ := cls.ResolveSubset(cluster.Subset)
+	var selector map[string]string
+	for _, subset := range cls.Spec.Subsets {
+		if subset.Name == name {
+			selector = subset.Labels
+		}
+	}
+	if len(selector) == 0 {
+		return "", nil
+	}
+
+	svcs, err := v.store.ListServices(namespace, selector)
+	if err != nil {
+		return "", err
+	}
+	if len(svcs) == 0 {
+		return "", nil
+	}
+	var names []string
+	for _, svc := range svcs {
+		if _, ok := parser.ServicePort(svc, cluster.Port.String()); ok {
+			return "", nil
+		}
+		names = append(names, svc.Name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("port %s of manba cluster %s/%s subset %s is not exposed by service %s",
+		cluster.Port.String(), namespace, cluster.Name, name, strings.Join(names, ", ")), nil
+}
+
+// ValidateManbaCachePolicy checks if keys, ttl and conditions of the policy are valid
+func (v *validator) ValidateManbaCachePolicy(policy *configurationv1beta1.ManbaCachePolicy) (bool, string, error) {
+	if err := policy.Spec.Validate(); err != nil {
+		return false, err.Error(), nil
 	}
+	for i, cond := range policy.Spec.Conditions {
+		if msg := validateCondition(cond); msg != "" {
+			return false, fmt.Sprintf("conditions[%d]: %s", i, msg), nil
+		}
+	}
+	return true, "", nil
+}
 
-	// return true if v matches regexp rule
-	isValid := func(data map[string]string) bool {
-		for _, v := range data {
-			_, err := regexp.Compile(v)
+// ValidateManbaTrafficPolicy checks if the traffic policy is valid, old is nil on create
+func (v *validator) ValidateManbaTrafficPolicy(old, policy *configurationv1beta1.ManbaTrafficPolicy) (bool, string, error) {
+	if policy == nil {
+		clusters, err := v.listTrafficPolicyReferences(old.GetNamespace(), old.GetName())
+		if err != nil {
+			return false, "", err
+		}
+		if len(clusters) != 0 {
+			return false, fmt.Sprintf("manba traffic policy %s/%s is in use: referenced by trafficPolicyRef of manba cluster %s",
+				old.GetNamespace(), old.GetName(), strings.Join(clusters, ", ")), nil
+		}
+		return true, "", nil
+	}
+	if msg := validateTrafficPolicy(&policy.Spec); msg != "" {
+		return false, msg, nil
+	}
+	var oldOptions map[string]string
+	if old != nil {
+		oldOptions = parser.RateLimitOptions(nil, "", &old.Spec)
+	}
+	msg, warning := checkRateLimitOptions(oldOptions, parser.RateLimitOptions(nil, "", &policy.Spec))
+	if msg != "" {
+		return false, msg, nil
+	}
+	return true, warning, nil
+}
+
+// listTrafficPolicyReferences returns the sorted names of manba clusters
+// referencing the manba traffic policy by trafficPolicyRef
+func (v *validator) listTrafficPolicyReferences(namespace, name string) ([]string, error) {
+	clusters, err := v.manbaInformer.Configuration().V1beta1().ManbaClusters().Lister().ManbaClusters(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, cluster := range clusters {
+		if cluster.Spec.TrafficPolicyRef == name {
+			refs = append(refs, cluster.GetName())
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// validateCachePolicyRefs returns a message if a route or aggregate call sets both cache and cachePolicy,
+// or the policy it references is not found
+func (v *validator) validateCachePolicyRefs(ingress *configurationv1beta1.ManbaIngress) (string, error) {
+	check := func(cache *metapb.Cache, policy, path string) (string, error) {
+		if policy == "" {
+			return "", nil
+		}
+		if cache != nil {
+			return fmt.Sprintf("%s: cache and cachePolicy can't be set together", path), nil
+		}
+		_, err := v.store.GetManbaCachePolicy(ingress.GetNamespace(), policy)
+		if errors.IsNotFound(err) {
+			return fmt.Sprintf("%s: manba cache policy %s/%s not found", path, ingress.GetNamespace(), policy), nil
+		}
+		return "", err
+	}
+	for i, rule := range ingress.Spec.HTTP {
+		for j, route := range rule.Route {
+			if msg, err := check(route.Cache, route.CachePolicy, fmt.Sprintf("http[%d].route[%d]", i, j)); err != nil || msg != "" {
+				return msg, err
+			}
+		}
+		for j, call := range rule.Aggregate.GetCalls() {
+			if msg, err := check(call.Cache, call.CachePolicy, fmt.Sprintf("http[%d].aggregate.calls[%d]", i, j)); err != nil || msg != "" {
+				return msg, err
+			}
+		}
+	}
+	return "", nil
+}
+
+// ValidateManbaCluster checks if the spec of manba cluster is valid and
+// no subset referenced by manba ingresses is removed
+func (v *validator) ValidateManbaCluster(old, cluster *configurationv1beta1.ManbaCluster) (bool, string, error) {
+	var warning string
+	if cluster != nil {
+		if msg := validateManbaClusterSpec(cluster.Spec); msg != "" {
+			return false, msg, nil
+		}
+		var oldOptions map[string]string
+		if old != nil {
+			oldOptions = parser.ClusterRateLimitOptions(old.Spec)
+		}
+		var msg string
+		if msg, warning = checkRateLimitOptions(oldOptions, parser.ClusterRateLimitOptions(cluster.Spec)); msg != "" {
+			return false, msg, nil
+		}
+		if ref := cluster.Spec.TrafficPolicyRef; ref != "" {
+			_, err := v.store.GetManbaTrafficPolicy(cluster.GetNamespace(), ref)
+			if errors.IsNotFound(err) {
+				return false, fmt.Sprintf("trafficPolicyRef: manba traffic policy %s/%s not found", cluster.GetNamespace(), ref), nil
+			}
 			if err != nil {
-				return false
+				return false, "", err
 			}
 		}
-		return true
+	}
+	if old == nil {
+		return true, warning, nil
 	}
 
-	return isValid(match.PathValue) && isValid(match.Query) && isValid(match.JSONBody) && isValid(match.Header) && isValid(match.FormData) && isValid(match.Cookie)
+	removed := make(map[string]bool)
+	for _, subset := range old.Spec.Subsets {
+		removed[subset.Name] = true
+	}
+	if cluster != nil {
+		for _, subset := range cluster.Spec.Subsets {
+			delete(removed, subset.Name)
+		}
+	}
+
+	refs, err := v.listSubsetReferences(old.GetNamespace(), old.GetName())
+	if err != nil {
+		return false, "", err
+	}
+	var inUse []string
+	for subset, ingresses := range refs {
+		// old object may be missing on delete, so every referenced subset is removed,
+		// "@active" and "@preview" are removed when they are unset
+		gone := removed[subset] || cluster == nil
+		if !gone {
+			_, ok := cluster.ResolveSubset(subset)
+			gone = !ok
+		}
+		if gone {
+			inUse = append(inUse, fmt.Sprintf("subset %s is referenced by manba ingress %s", subset, strings.Join(ingresses, ", ")))
+		}
+	}
+	if len(inUse) != 0 {
+		sort.Strings(inUse)
+		return false, fmt.Sprintf("manba cluster %s/%s is in use: %s", old.GetNamespace(), old.GetName(), strings.Join(inUse, "; ")), nil
+	}
+	return true, warning, nil
+}
+
+// listSubsetReferences returns the names of manba ingresses referencing
+// each subset of the manba cluster
+func (v *validator) listSubsetReferences(namespace, name string) (map[string][]string, error) {
+	ingresses, err := v.manbaInformer.Configuration().V1beta1().ManbaIngresses().Lister().ManbaIngresses(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string][]string)
+	for _, ingress := range ingresses {
+		subsets := make(map[string]bool)
+		for _, cluster := range referencedClusters(ingress) {
+			if cluster.Name == name {
+				subsets[cluster.Subset] = true
+			}
+		}
+		for subset := range subsets {
+			refs[subset] = append(refs[subset], ingress.GetName())
+		}
+	}
+	return refs, nil
+}
+
+// validateManbaClusterSpec returns a message if spec is invalid
+func validateManbaClusterSpec(spec configurationv1beta1.ManbaClusterSpec) string {
+	if msg := validateTrafficPolicy(spec.TrafficPolicy); msg != "" {
+		return fmt.Sprintf("trafficPolicy: %s", msg)
+	}
+
+	names := make(map[string]bool)
+	for i, subset := range spec.Subsets {
+		if subset.Name == "" {
+			return fmt.Sprintf("subsets[%d]: name must not be empty", i)
+		}
+		if names[subset.Name] {
+			return fmt.Sprintf("subsets[%d]: duplicate subset name %s", i, subset.Name)
+		}
+		names[subset.Name] = true
+
+		if len(subset.Labels) == 0 {
+			return fmt.Sprintf("subset %s: labels must not be empty", subset.Name)
+		}
+		if msg := validateTrafficPolicy(subset.TrafficPolicy); msg != "" {
+			return fmt.Sprintf("subset %s: trafficPolicy: %s", subset.Name, msg)
+		}
+		if msg := validateSchedules(subset.Schedules, true); msg != "" {
+			return fmt.Sprintf("subset %s: %s", subset.Name, msg)
+		}
+	}
+
+	if spec.ActiveSubset != "" && !names[spec.ActiveSubset] {
+		return fmt.Sprintf("activeSubset: subset %s not found", spec.ActiveSubset)
+	}
+	if spec.PreviewSubset != "" && !names[spec.PreviewSubset] {
+		return fmt.Sprintf("previewSubset: subset %s not found", spec.PreviewSubset)
+	}
+	if spec.PreviewHeader != nil && spec.PreviewHeader.Name == "" {
+		return "previewHeader: name must not be empty"
+	}
+	return ""
+}
+
+// validateSchedules returns a message if a schedule is invalid,
+// schedules of subsets only change traffic policy and ones of rules can't change it
+func validateSchedules(schedules []configurationv1beta1.ManbaSchedule, subset bool) string {
+	names := make(map[string]bool)
+	for i, schedule := range schedules {
+		path := fmt.Sprintf("schedules[%d]", i)
+		if schedule.Name == "" {
+			return fmt.Sprintf("%s: name must not be empty", path)
+		}
+		if names[schedule.Name] {
+			return fmt.Sprintf("%s: duplicate schedule name %s", path, schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		if err := schedule.Window.Validate(); err != nil {
+			return fmt.Sprintf("%s.window: %v", path, err)
+		}
+		if subset {
+			if schedule.DefaultValue != nil || schedule.Split != nil {
+				return fmt.Sprintf("%s: defaultValue and split are only allowed in schedules of rules", path)
+			}
+		} else if schedule.TrafficPolicy != nil {
+			return fmt.Sprintf("%s: trafficPolicy is only allowed in schedules of subsets, manba apis have no traffic policy", path)
+		}
+		if msg := validateTrafficPolicy(schedule.TrafficPolicy); msg != "" {
+			return fmt.Sprintf("%s.trafficPolicy: %s", path, msg)
+		}
+	}
+	return ""
+}
+
+// validateTrafficPolicy returns a message if policy is invalid
+func validateTrafficPolicy(policy *configurationv1beta1.TrafficPolicy) string {
+	if policy == nil {
+		return ""
+	}
+
+	if policy.LoadBalancer != nil {
+		if _, ok := metapb.LoadBalance_value[*policy.LoadBalancer]; !ok {
+			return fmt.Sprintf("unknown loadBalancer %q, must be one of %s",
+				*policy.LoadBalancer, strings.Join(enumNames(metapb.LoadBalance_value), ", "))
+		}
+	}
+	if cb := policy.CircuitBreaker; cb != nil {
+		rates := []struct {
+			name  string
+			value int32
+		}{
+			{"halfTrafficRate", cb.HalfTrafficRate},
+			{"failureRateToClose", cb.FailureRateToClose},
+			{"succeedRateToOpen", cb.SucceedRateToOpen},
+		}
+		for _, rate := range rates {
+			if rate.value < 0 || rate.value > 100 {
+				return fmt.Sprintf("circuitBreaker.%s must be in range [0, 100], got %d", rate.name, rate.value)
+			}
+		}
+		if cb.CloseTimeout < 0 || cb.RateCheckPeriod < 0 {
+			return "circuitBreaker.closeTimeout and circuitBreaker.rateCheckPeriod must not be negative"
+		}
+	}
+	return ""
+}
+
+// checkRateLimitOptions returns a message if an option in current is not in old, and a warning of the others,
+// manba api server has no option of rate limit, so new options are rejected since they'd be dropped silently,
+// but unchanged ones are kept so objects created before they were rejected can be updated
+func checkRateLimitOptions(old, current map[string]string) (msg, warning string) {
+	var paths []string
+	for path := range current {
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return "", ""
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if value, ok := old[path]; !ok || value != current[path] {
+			return fmt.Sprintf("%s %q is not supported by manba", path, current[path]), ""
+		}
+	}
+	return "", fmt.Sprintf("%s not supported by manba and ignored", strings.Join(paths, ", "))
+}
+
+// enumNames returns the sorted names of a protobuf enum
+func enumNames(values map[string]int32) []string {
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -1 +1,758 @@
 package admission
+
+import (
+	"testing"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/fake"
+	configurationinformer "github.com/domgoer/manba-ingress/pkg/client/informers/externalversions"
+	"github.com/domgoer/manba-ingress/pkg/ingress/annotations"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newTestValidator returns a validator whose informers and store contain objects
+func newTestValidator(t *testing.T, objects ...runtime.Object) *validator {
+	factory := configurationinformer.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	var k8sObjects, manbaObjects []runtime.Object
+	for _, obj := range objects {
+		var err error
+		switch o := obj.(type) {
+		case *configurationv1beta1.ManbaIngress:
+			err = factory.Configuration().V1beta1().ManbaIngresses().Informer().GetIndexer().Add(o)
+			manbaObjects = append(manbaObjects, o)
+		case *configurationv1beta1.ManbaCluster:
+			err = factory.Configuration().V1beta1().ManbaClusters().Informer().GetIndexer().Add(o)
+			manbaObjects = append(manbaObjects, o)
+		case *configurationv1beta1.ManbaCachePolicy, *configurationv1beta1.ManbaTrafficPolicy:
+			manbaObjects = append(manbaObjects, o)
+		default:
+			k8sObjects = append(k8sObjects, o)
+		}
+		assert.Nil(t, err)
+	}
+	s, err := store.NewFakeStore(k8sObjects, manbaObjects)
+	assert.Nil(t, err)
+	return NewValidator(factory, s, annotations.DefaultIngressClass)
+}
+
+func newTestCluster(subsets ...string) *configurationv1beta1.ManbaCluster {
+	cls := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cls",
+			Namespace: "default",
+		},
+	}
+	for _, name := range subsets {
+		cls.Spec.Subsets = append(cls.Spec.Subsets, configurationv1beta1.ManbaClusterSubSet{
+			Name:   name,
+			Labels: map[string]string{"version": name},
+		})
+	}
+	return cls
+}
+
+func TestValidateManbaClusterSpec(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		spec  func(*configurationv1beta1.ManbaClusterSpec)
+		valid bool
+	}{
+		{"valid", func(*configurationv1beta1.ManbaClusterSpec) {}, true},
+		{"empty subset name", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[0].Name = ""
+		}, false},
+		{"duplicate subset name", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[1].Name = s.Subsets[0].Name
+		}, false},
+		{"empty labels", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[0].Labels = nil
+		}, false},
+		{"known load balancer", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.TrafficPolicy = &configurationv1beta1.TrafficPolicy{LoadBalancer: str("IPHash")}
+		}, true},
+		{"unknown load balancer", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[0].TrafficPolicy = &configurationv1beta1.TrafficPolicy{LoadBalancer: str("iphash")}
+		}, false},
+		{"active and preview subsets", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.ActiveSubset = "v1"
+			s.PreviewSubset = "v2"
+		}, true},
+		{"unknown active subset", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.ActiveSubset = "v3"
+		}, false},
+		{"empty preview header", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.PreviewHeader = &configurationv1beta1.ManbaHeaderMatch{Value: "true"}
+		}, false},
+		{"circuit breaker out of range", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.TrafficPolicy = &configurationv1beta1.TrafficPolicy{CircuitBreaker: &metapb.CircuitBreaker{
+				FailureRateToClose: 101,
+			}}
+		}, false},
+		{"subset schedule", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[0].Schedules = []configurationv1beta1.ManbaSchedule{{
+				Name:          "night",
+				Window:        configurationv1beta1.ManbaTimeWindow{Days: []string{"Mon"}, Start: "22:00", End: "06:00", TimeZone: "Asia/Shanghai"},
+				TrafficPolicy: &configurationv1beta1.TrafficPolicy{MaxQPS: 10},
+			}}
+		}, true},
+		{"invalid schedule window", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[0].Schedules = []configurationv1beta1.ManbaSchedule{{
+				Name:   "night",
+				Window: configurationv1beta1.ManbaTimeWindow{Start: "22:00", End: "22:00"},
+			}}
+		}, false},
+		{"split in subset schedule", func(s *configurationv1beta1.ManbaClusterSpec) {
+			s.Subsets[0].Schedules = []configurationv1beta1.ManbaSchedule{{
+				Name:   "night",
+				Window: configurationv1beta1.ManbaTimeWindow{Start: "22:00", End: "06:00"},
+				Split:  []configurationv1beta1.ManbaHTTPRouting{{}},
+			}}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cls := newTestCluster("v1", "v2")
+			tt.spec(&cls.Spec)
+			msg := validateManbaClusterSpec(cls.Spec)
+			assert.Equal(t, tt.valid, msg == "", msg)
+		})
+	}
+}
+
+func TestValidator_ValidateManbaCluster(t *testing.T) {
+	v := newTestValidator(t, &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ing",
+			Namespace: "default",
+		},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Split: []configurationv1beta1.ManbaHTTPRouting{{
+					Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "test-cls", Subset: "v1"},
+				}},
+			}},
+		},
+	})
+
+	valid, _, err := v.ValidateManbaCluster(nil, newTestCluster("v1"))
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	// removing unreferenced subset
+	valid, _, err = v.ValidateManbaCluster(newTestCluster("v1", "v2"), newTestCluster("v1"))
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	// removing referenced subset
+	valid, msg, err := v.ValidateManbaCluster(newTestCluster("v1", "v2"), newTestCluster("v2"))
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Contains(t, msg, "test-ing")
+
+	// deleting referenced cluster
+	valid, _, err = v.ValidateManbaCluster(newTestCluster("v1"), nil)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+
+	// unsetting referenced active subset
+	v = newTestValidator(t, &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ing",
+			Namespace: "default",
+		},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Route: []configurationv1beta1.ManbaHTTPRoute{{
+					Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "test-cls", Subset: configurationv1beta1.ActiveSubsetRef},
+				}},
+			}},
+		},
+	})
+	active := newTestCluster("v1", "v2")
+	active.Spec.ActiveSubset = "v1"
+	promoted := newTestCluster("v1", "v2")
+	promoted.Spec.ActiveSubset = "v2"
+	valid, _, err = v.ValidateManbaCluster(active, promoted)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	valid, msg, err = v.ValidateManbaCluster(active, newTestCluster("v1", "v2"))
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Contains(t, msg, "subset @active")
+
+	// cluster in other namespace is not referenced
+	other := newTestCluster("v1")
+	other.Namespace = "other"
+	valid, _, err = v.ValidateManbaCluster(other, nil)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidator_ValidateManbaClusterRateLimitOption(t *testing.T) {
+	str := func(s string) *string { return &s }
+	v := newTestValidator(t)
+
+	limited := newTestCluster("v1", "v2")
+	limited.Spec.Subsets[1].TrafficPolicy = &configurationv1beta1.TrafficPolicy{RateLimitOption: str("Wait")}
+	valid, msg, err := v.ValidateManbaCluster(nil, limited)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, `subset v2: trafficPolicy.rateLimitOption "Wait" is not supported by manba`, msg)
+
+	// clusters created with the option are still updatable
+	updated := limited.DeepCopy()
+	updated.Spec.ActiveSubset = "v1"
+	valid, msg, err = v.ValidateManbaCluster(limited, updated)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, "subset v2: trafficPolicy.rateLimitOption not supported by manba and ignored", msg)
+
+	updated.Spec.Subsets[1].TrafficPolicy.RateLimitOption = str("Drop")
+	valid, _, err = v.ValidateManbaCluster(limited, updated)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
+
+func TestValidator_ValidateManbaIngressRouteConflict(t *testing.T) {
+	str := func(s string) *string { return &s }
+	newIngress := func(name string, method *string, pattern string) *configurationv1beta1.ManbaIngress {
+		return &configurationv1beta1.ManbaIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP: []configurationv1beta1.ManbaHTTPRule{{
+					Match: []configurationv1beta1.ManbaHTTPMatch{{
+						Host: "example.com",
+						Rules: []configurationv1beta1.ManbaHTTPMatchRule{{
+							URI:    configurationv1beta1.ManbaHTTPURIMatch{Pattern: pattern},
+							Method: method,
+						}},
+					}},
+				}},
+			},
+		}
+	}
+	existing := newIngress("existing", str("GET"), "/api")
+	// ingresses of other classes are served by other controllers
+	otherClass := newIngress("other-class", nil, "/other")
+	otherClass.Annotations = map[string]string{"kubernetes.io/ingress.class": "nginx"}
+	v := newTestValidator(t, existing, otherClass)
+
+	valid, _, err := v.ValidateManbaIngress(newIngress("same-as-other-class", nil, "/other"))
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	otherConflict := newIngress("other-class-conflict", nil, "/api")
+	otherConflict.Annotations = map[string]string{"kubernetes.io/ingress.class": "nginx"}
+	valid, _, err = v.ValidateManbaIngress(otherConflict)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	// updating itself
+	valid, _, err = v.ValidateManbaIngress(existing)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, _, err = v.ValidateManbaIngress(newIngress("post", str("POST"), "/api"))
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, _, err = v.ValidateManbaIngress(newIngress("other-pattern", nil, "/"))
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	conflict := newIngress("any-method", nil, "/api")
+	valid, msg, err := v.ValidateManbaIngress(conflict)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Contains(t, msg, "default/existing")
+
+	conflict.Annotations = map[string]string{annotations.AllowRouteOverrideKey: "true"}
+	valid, _, err = v.ValidateManbaIngress(conflict)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidator_ValidateManbaIngressDryRun(t *testing.T) {
+	rate := func(r int32) *int32 { return &r }
+	cluster := configurationv1beta1.ManbaHTTPRouteCluster{
+		Name:   "test-cls",
+		Subset: "v1",
+		Port:   intstr.FromInt(80),
+	}
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ing",
+			Namespace: "default",
+		},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Match: []configurationv1beta1.ManbaHTTPMatch{{
+					Host:  "example.com",
+					Rules: []configurationv1beta1.ManbaHTTPMatchRule{{}},
+				}},
+				Route: []configurationv1beta1.ManbaHTTPRoute{{Cluster: cluster}},
+				Split: []configurationv1beta1.ManbaHTTPRouting{{Cluster: cluster, Rate: rate(50)}},
+			}},
+		},
+	}
+	v := newTestValidator(t, newTestCluster("v1"))
+
+	valid, msg, err := v.ValidateManbaIngress(ingress)
+	assert.Nil(t, err)
+	assert.True(t, valid, msg)
+	assert.Empty(t, msg)
+
+	ingress.Spec.HTTP[0].Mirror = []configurationv1beta1.ManbaHTTPMirror{{ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{Cluster: cluster}}}
+	valid, msg, err = v.ValidateManbaIngress(ingress)
+	assert.Nil(t, err)
+	assert.True(t, valid, msg)
+	assert.Contains(t, msg, "http[0].mirror[0]: cluster test-cls subset \"v1\" is the route of the rule")
+	ingress.Spec.HTTP[0].Mirror = nil
+
+	ingress.Spec.HTTP[0].Match[0].Rules[0].URI.Pattern = "/api/("
+	valid, msg, err = v.ValidateManbaIngress(ingress)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Contains(t, msg, "is rejected by Manba validation")
+
+	ingress.Spec.HTTP[0].Match[0].Rules[0].URI.Pattern = "/api"
+	ingress.Spec.HTTP[0].Route[0].Match = &configurationv1beta1.ManbaHTTPRouteMatch{
+		Header: map[string]configurationv1beta1.ManbaHTTPValueMatch{"x-user": {Present: true, Absent: true}},
+	}
+	valid, msg, err = v.ValidateManbaIngress(ingress)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Contains(t, msg, "http[0].route[0].match: Header x-user")
+}
+
+func TestValidateRoutingConditions(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		routing configurationv1beta1.ManbaHTTPRouting
+		msg     string
+	}{
+		{"valid", configurationv1beta1.ManbaHTTPRouting{
+			Conditions: []metapb.Condition{{Parameter: metapb.Parameter{Name: "uid"}, Cmp: metapb.CMPGE, Expect: "100"}},
+			Canary: &configurationv1beta1.ManbaHTTPCanary{
+				Header:     map[string]configurationv1beta1.ManbaHTTPValueMatch{"x-user": {Exact: str("internal")}},
+				SourceCIDR: []string{"10.0.0.0/8"},
+			},
+		}, ""},
+		{"empty name", configurationv1beta1.ManbaHTTPRouting{
+			Conditions: []metapb.Condition{{Cmp: metapb.CMPEQ, Expect: "a"}},
+		}, "http[0].split[0].conditions[0]: parameter name must not be empty"},
+		{"empty expect", configurationv1beta1.ManbaHTTPRouting{
+			Conditions: []metapb.Condition{{Parameter: metapb.Parameter{Name: "uid"}, Cmp: metapb.CMPEQ}},
+		}, "must not be empty"},
+		{"non integer", configurationv1beta1.ManbaHTTPRouting{
+			Conditions: []metapb.Condition{{Parameter: metapb.Parameter{Name: "uid"}, Cmp: metapb.CMPLT, Expect: "a"}},
+		}, "must be an integer"},
+		{"invalid regex", configurationv1beta1.ManbaHTTPRouting{
+			Conditions: []metapb.Condition{{Parameter: metapb.Parameter{Name: "uid"}, Cmp: metapb.CMPMatch, Expect: "("}},
+		}, "is not a valid regular expression"},
+		{"unknown cmp", configurationv1beta1.ManbaHTTPRouting{
+			Conditions: []metapb.Condition{{Parameter: metapb.Parameter{Name: "uid"}, Cmp: 10, Expect: "a"}},
+		}, "unknown cmp"},
+		{"invalid canary", configurationv1beta1.ManbaHTTPRouting{
+			Canary: &configurationv1beta1.ManbaHTTPCanary{SourceCIDR: []string{"10.0.0.1"}},
+		}, "http[0].split[0].canary: sourceCIDR 10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := &configurationv1beta1.ManbaIngress{
+				Spec: configurationv1beta1.ManbaIngressSpec{
+					HTTP: []configurationv1beta1.ManbaHTTPRule{{
+						Split: []configurationv1beta1.ManbaHTTPRouting{tt.routing},
+					}},
+				},
+			}
+			msg := validateRoutingConditions(ingress)
+			if tt.msg == "" {
+				assert.Empty(t, msg)
+			} else {
+				assert.Contains(t, msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestValidateRoutingRates(t *testing.T) {
+	rate := func(r int32) *int32 { return &r }
+	newIngress := func(mirror *int32, splits ...*int32) *configurationv1beta1.ManbaIngress {
+		ingress := &configurationv1beta1.ManbaIngress{
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP: []configurationv1beta1.ManbaHTTPRule{{
+					Mirror: []configurationv1beta1.ManbaHTTPMirror{{ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{Rate: mirror}}},
+				}},
+			},
+		}
+		for _, r := range splits {
+			ingress.Spec.HTTP[0].Split = append(ingress.Spec.HTTP[0].Split, configurationv1beta1.ManbaHTTPRouting{Rate: r})
+		}
+		configurationv1beta1.SetManbaIngressDefaults(ingress)
+		return ingress
+	}
+
+	assert.Equal(t, "", validateRoutingRates(newIngress(nil, nil)))
+	assert.Equal(t, "", validateRoutingRates(newIngress(rate(10), rate(30), rate(70))))
+	assert.Contains(t, validateRoutingRates(newIngress(rate(0))), "mirror[0]")
+	assert.Contains(t, validateRoutingRates(newIngress(rate(101))), "mirror[0]")
+	assert.Contains(t, validateRoutingRates(newIngress(nil, rate(-1))), "split[0]")
+	assert.Contains(t, validateRoutingRates(newIngress(nil, rate(60), rate(50))), "add up to 110")
+
+	weighted := newIngress(nil, rate(60), rate(50))
+	weighted.Annotations = map[string]string{annotations.NormalizeSplitWeightsKey: "true"}
+	assert.Equal(t, "", validateRoutingRates(weighted))
+	weighted.Spec.HTTP[0].Split[1].Rate = rate(0)
+	assert.Contains(t, validateRoutingRates(weighted), "weight must be positive")
+}
+
+func TestValidateSchedules(t *testing.T) {
+	window := configurationv1beta1.ManbaTimeWindow{Start: "09:00", End: "18:00"}
+	assert.Equal(t, "", validateSchedules([]configurationv1beta1.ManbaSchedule{
+		{Name: "business", Window: window, Split: []configurationv1beta1.ManbaHTTPRouting{{}}},
+		{Name: "maintenance", Window: window, DefaultValue: &metapb.HTTPResult{Code: 503}},
+	}, false))
+	assert.Contains(t, validateSchedules([]configurationv1beta1.ManbaSchedule{{Window: window}}, false), "name must not be empty")
+	assert.Contains(t, validateSchedules([]configurationv1beta1.ManbaSchedule{
+		{Name: "a", Window: window}, {Name: "a", Window: window},
+	}, false), "duplicate schedule name a")
+	assert.Contains(t, validateSchedules([]configurationv1beta1.ManbaSchedule{
+		{Name: "a", Window: window, TrafficPolicy: &configurationv1beta1.TrafficPolicy{MaxQPS: 10}},
+	}, false), "trafficPolicy is only allowed in schedules of subsets")
+
+	rate := int32(80)
+	ingress := &configurationv1beta1.ManbaIngress{
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Schedules: []configurationv1beta1.ManbaSchedule{{
+					Name:   "business",
+					Window: window,
+					Split:  []configurationv1beta1.ManbaHTTPRouting{{Rate: &rate}, {Rate: &rate}},
+				}},
+			}},
+		},
+	}
+	assert.Contains(t, validateRoutingRates(ingress), "http[0].schedules[0]: rates of splits add up to 160")
+}
+
+func TestValidateMirrors(t *testing.T) {
+	get := "GET"
+	all := configurationv1beta1.DefaultMethod
+	sample := int32(10)
+	newIngress := func(method *string, mirror configurationv1beta1.ManbaHTTPMirror) *configurationv1beta1.ManbaIngress {
+		ingress := &configurationv1beta1.ManbaIngress{
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP: []configurationv1beta1.ManbaHTTPRule{{
+					Match: []configurationv1beta1.ManbaHTTPMatch{{
+						Rules: []configurationv1beta1.ManbaHTTPMatchRule{{Method: method}},
+					}},
+					Mirror: []configurationv1beta1.ManbaHTTPMirror{mirror},
+				}},
+			},
+		}
+		configurationv1beta1.SetManbaIngressDefaults(ingress)
+		return ingress
+	}
+
+	assert.Equal(t, "", validateMirrors(newIngress(nil, configurationv1beta1.ManbaHTTPMirror{})))
+	assert.Equal(t, "", validateMirrors(newIngress(&get, configurationv1beta1.ManbaHTTPMirror{Methods: []string{"get"}})))
+	assert.Contains(t, validateMirrors(newIngress(&get, configurationv1beta1.ManbaHTTPMirror{Methods: []string{"FETCH"}})), "unknown method")
+	assert.Contains(t, validateMirrors(newIngress(&all, configurationv1beta1.ManbaHTTPMirror{Methods: []string{"GET"}})), "match rules of all methods")
+
+	conflict := newIngress(nil, configurationv1beta1.ManbaHTTPMirror{Sample: &sample})
+	assert.Equal(t, "", validateRoutingRates(conflict))
+	conflict.Spec.HTTP[0].Mirror[0].Rate = &sample
+	assert.Equal(t, "", validateRoutingRates(conflict))
+	rate := int32(20)
+	conflict.Spec.HTTP[0].Mirror[0].Rate = &rate
+	assert.Contains(t, validateRoutingRates(conflict), "sample and rate are different")
+}
+
+func TestValidateMaintenance(t *testing.T) {
+	newIngress := func(m *configurationv1beta1.ManbaMaintenance, routes int) *configurationv1beta1.ManbaIngress {
+		return &configurationv1beta1.ManbaIngress{
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP:        []configurationv1beta1.ManbaHTTPRule{{Route: make([]configurationv1beta1.ManbaHTTPRoute, routes)}},
+				Maintenance: m,
+			},
+		}
+	}
+
+	assert.Equal(t, "", validateMaintenance(newIngress(nil, 2)))
+	assert.Equal(t, "", validateMaintenance(newIngress(&configurationv1beta1.ManbaMaintenance{Enabled: true}, 2)))
+	assert.Equal(t, "", validateMaintenance(newIngress(&configurationv1beta1.ManbaMaintenance{Allowlist: []string{"10.0.0.1", "192.168.0.0/16"}}, 1)))
+	assert.Contains(t, validateMaintenance(newIngress(&configurationv1beta1.ManbaMaintenance{
+		Response: &metapb.HTTPResult{Code: 700},
+	}, 1)), "maintenance.response.code")
+	assert.Contains(t, validateMaintenance(newIngress(&configurationv1beta1.ManbaMaintenance{
+		BodyFrom: &corev1.ConfigMapKeySelector{Key: "index.html"},
+	}, 1)), "name and key are required")
+	assert.Contains(t, validateMaintenance(newIngress(&configurationv1beta1.ManbaMaintenance{Allowlist: []string{"10.0.0.300"}}, 1)), "maintenance.allowlist 10.0.0.300/32")
+	assert.Contains(t, validateMaintenance(newIngress(&configurationv1beta1.ManbaMaintenance{Allowlist: []string{"10.0.0.1"}}, 2)), "http[0] has more than one route")
+}
+
+func TestValidateRateLimits(t *testing.T) {
+	byKey := func(qps, burst uint32, shared bool) *configurationv1beta1.ManbaHTTPRateLimit {
+		return &configurationv1beta1.ManbaHTTPRateLimit{
+			Key:    configurationv1beta1.ManbaRateLimitKey{Source: configurationv1beta1.RateLimitKeyHeader, Name: "X-Api-Key"},
+			QPS:    qps,
+			Burst:  burst,
+			Shared: shared,
+		}
+	}
+	newIngress := func(limits ...*configurationv1beta1.ManbaHTTPRateLimit) *configurationv1beta1.ManbaIngress {
+		var ingress configurationv1beta1.ManbaIngress
+		for _, limit := range limits {
+			ingress.Spec.HTTP = append(ingress.Spec.HTTP, configurationv1beta1.ManbaHTTPRule{RateLimit: limit})
+		}
+		return &ingress
+	}
+
+	assert.Equal(t, "", validateRateLimits(newIngress(nil, byKey(10, 0, false), byKey(5, 0, false))))
+	assert.Equal(t, "", validateRateLimits(newIngress(byKey(10, 0, true), byKey(10, 10, true))))
+	assert.Equal(t, "", validateRateLimits(newIngress(&configurationv1beta1.ManbaHTTPRateLimit{
+		Key: configurationv1beta1.ManbaRateLimitKey{Source: configurationv1beta1.RateLimitKeyClientIP},
+		QPS: 1,
+	})))
+	assert.Contains(t, validateRateLimits(newIngress(byKey(10, 0, true), byKey(5, 0, true))), "http[1].rateLimit: shared limit differs from the one of http[0]")
+	assert.Contains(t, validateRateLimits(newIngress(byKey(0, 0, false))), "qps must be greater than 0")
+	assert.Contains(t, validateRateLimits(newIngress(byKey(10, 5, false))), "burst 5 is less than qps 10")
+	assert.Contains(t, validateRateLimits(newIngress(&configurationv1beta1.ManbaHTTPRateLimit{
+		Key: configurationv1beta1.ManbaRateLimitKey{Source: configurationv1beta1.RateLimitKeyHeader},
+		QPS: 1,
+	})), "name is required by source header")
+	assert.Contains(t, validateRateLimits(newIngress(&configurationv1beta1.ManbaHTTPRateLimit{
+		Key: configurationv1beta1.ManbaRateLimitKey{Source: "body"},
+		QPS: 1,
+	})), "unknown source")
+}
+
+func TestValidateAggregates(t *testing.T) {
+	calls := func(names ...string) *configurationv1beta1.ManbaHTTPAggregate {
+		var res configurationv1beta1.ManbaHTTPAggregate
+		for _, name := range names {
+			res.Calls = append(res.Calls, configurationv1beta1.ManbaHTTPAggregateCall{Name: name})
+		}
+		return &res
+	}
+	newIngress := func(rule configurationv1beta1.ManbaHTTPRule) *configurationv1beta1.ManbaIngress {
+		ingress := &configurationv1beta1.ManbaIngress{
+			Spec: configurationv1beta1.ManbaIngressSpec{HTTP: []configurationv1beta1.ManbaHTTPRule{rule}},
+		}
+		configurationv1beta1.SetManbaIngressDefaults(ingress)
+		return ingress
+	}
+
+	assert.Equal(t, "", validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{Aggregate: calls("user", "orders")})))
+	assert.Contains(t, validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{Aggregate: calls("user", "user")})), "calls[1]: duplicate name user")
+	assert.Contains(t, validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{Aggregate: calls("")})), "name is required")
+	assert.Contains(t, validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{Aggregate: calls()})), "calls are required")
+	assert.Contains(t, validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{
+		Aggregate: calls("user"),
+		Route:     []configurationv1beta1.ManbaHTTPRoute{{}},
+	})), "route and aggregate can't be set together")
+	assert.Contains(t, validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{
+		Aggregate: calls("user"),
+		Split:     []configurationv1beta1.ManbaHTTPRouting{{}},
+	})), "split and mirror can't be used with aggregate")
+	assert.Contains(t, validateAggregates(newIngress(configurationv1beta1.ManbaHTTPRule{
+		Route: []configurationv1beta1.ManbaHTTPRoute{{AttrName: "a"}, {AttrName: "a"}},
+	})), "route[1]: duplicate attrName a")
+}
+
+func TestValidator_ValidateManbaCachePolicy(t *testing.T) {
+	policy := &configurationv1beta1.ManbaCachePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "per-user", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaCachePolicySpec{
+			TTL:  metav1.Duration{Duration: time.Minute},
+			Keys: []configurationv1beta1.ManbaCacheKey{{Source: "header", Name: "X-User-ID"}},
+		},
+	}
+	v := newTestValidator(t, policy)
+
+	valid, _, err := v.ValidateManbaCachePolicy(policy)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	invalid := policy.DeepCopy()
+	invalid.Spec.Keys[0].Source = "jsonBody"
+	_, msg, _ := v.ValidateManbaCachePolicy(invalid)
+	assert.Contains(t, msg, `keys[0]: unknown source "jsonBody"`)
+
+	invalid = policy.DeepCopy()
+	invalid.Spec.TTL.Duration = 0
+	_, msg, _ = v.ValidateManbaCachePolicy(invalid)
+	assert.Contains(t, msg, "less than 1s")
+
+	invalid = policy.DeepCopy()
+	invalid.Spec.Conditions = []metapb.Condition{{Parameter: metapb.Parameter{Name: "a"}, Cmp: metapb.CMPMatch, Expect: "("}}
+	_, msg, _ = v.ValidateManbaCachePolicy(invalid)
+	assert.Contains(t, msg, "conditions[0]")
+
+	newIngress := func(route configurationv1beta1.ManbaHTTPRoute) *configurationv1beta1.ManbaIngress {
+		return &configurationv1beta1.ManbaIngress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP: []configurationv1beta1.ManbaHTTPRule{{Route: []configurationv1beta1.ManbaHTTPRoute{route}}},
+			},
+		}
+	}
+	msg, err = v.validateCachePolicyRefs(newIngress(configurationv1beta1.ManbaHTTPRoute{CachePolicy: "per-user"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "", msg)
+	msg, _ = v.validateCachePolicyRefs(newIngress(configurationv1beta1.ManbaHTTPRoute{CachePolicy: "missing"}))
+	assert.Contains(t, msg, "manba cache policy default/missing not found")
+	msg, _ = v.validateCachePolicyRefs(newIngress(configurationv1beta1.ManbaHTTPRoute{CachePolicy: "per-user", Cache: &metapb.Cache{}}))
+	assert.Contains(t, msg, "cache and cachePolicy can't be set together")
+}
+
+func TestValidator_ValidateManbaTrafficPolicy(t *testing.T) {
+	str := func(s string) *string { return &s }
+	policy := &configurationv1beta1.ManbaTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default"},
+		Spec: configurationv1beta1.TrafficPolicy{
+			CircuitBreaker: &metapb.CircuitBreaker{FailureRateToClose: 50},
+		},
+	}
+	v := newTestValidator(t, policy)
+
+	valid, _, err := v.ValidateManbaTrafficPolicy(nil, policy)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	invalid := policy.DeepCopy()
+	invalid.Spec.LoadBalancer = str("iphash")
+	_, msg, _ := v.ValidateManbaTrafficPolicy(nil, invalid)
+	assert.Contains(t, msg, `unknown loadBalancer "iphash"`)
+
+	// rate limit options are only kept unchanged
+	limited := policy.DeepCopy()
+	limited.Spec.RateLimitOption = str("Wait")
+	valid, msg, _ = v.ValidateManbaTrafficPolicy(nil, limited)
+	assert.False(t, valid)
+	assert.Equal(t, `rateLimitOption "Wait" is not supported by manba`, msg)
+	valid, msg, _ = v.ValidateManbaTrafficPolicy(policy, limited)
+	assert.False(t, valid)
+	updated := limited.DeepCopy()
+	updated.Spec.MaxQPS = 10
+	valid, msg, _ = v.ValidateManbaTrafficPolicy(limited, updated)
+	assert.True(t, valid)
+	assert.Equal(t, "rateLimitOption not supported by manba and ignored", msg)
+
+	cluster := newTestCluster("v1")
+	cluster.Spec.TrafficPolicyRef = "shared"
+	valid, _, err = v.ValidateManbaCluster(nil, cluster)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+	cluster.Spec.TrafficPolicyRef = "missing"
+	_, msg, _ = v.ValidateManbaCluster(nil, cluster)
+	assert.Contains(t, msg, "manba traffic policy default/missing not found")
+
+	// referenced policies can't be deleted
+	referencing := newTestCluster("v1")
+	referencing.Spec.TrafficPolicyRef = "shared"
+	other := referencing.DeepCopy()
+	other.Namespace = "other"
+	v = newTestValidator(t, policy, referencing, other)
+	valid, msg, err = v.ValidateManbaTrafficPolicy(policy, nil)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, "manba traffic policy default/shared is in use: referenced by trafficPolicyRef of manba cluster test-cls", msg)
+	unused := &configurationv1beta1.ManbaTrafficPolicy{ObjectMeta: metav1.ObjectMeta{Name: "unused", Namespace: "default"}}
+	valid, _, err = v.ValidateManbaTrafficPolicy(unused, nil)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+}
+
+func TestValidator_ValidateManbaIngressPort(t *testing.T) {
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ing",
+			Namespace: "default",
+		},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Match: []configurationv1beta1.ManbaHTTPMatch{{
+					Host:  "example.com",
+					Rules: []configurationv1beta1.ManbaHTTPMatchRule{{}},
+				}},
+				Route: []configurationv1beta1.ManbaHTTPRoute{{Cluster: configurationv1beta1.ManbaHTTPRouteCluster{
+					Name:   "test-cls",
+					Subset: "v1",
+					Port:   intstr.FromString("http"),
+				}}},
+			}},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-svc",
+			Namespace: "default",
+			Labels:    map[string]string{"version": "v1"},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	}
+
+	// subset without services is not checked
+	v := newTestValidator(t, newTestCluster("v1"))
+	valid, msg, err := v.ValidateManbaIngress(ingress)
+	assert.Nil(t, err)
+	assert.True(t, valid, msg)
+
+	v = newTestValidator(t, newTestCluster("v1"), svc)
+	for _, port := range []intstr.IntOrString{intstr.FromString("http"), intstr.FromInt(80), intstr.FromInt(8080)} {
+		ingress.Spec.HTTP[0].Route[0].Cluster.Port = port
+		valid, msg, err = v.ValidateManbaIngress(ingress)
+		assert.Nil(t, err)
+		assert.True(t, valid, msg)
+	}
+
+	ingress.Spec.HTTP[0].Route[0].Cluster.Port = intstr.FromInt(81)
+	valid, msg, err = v.ValidateManbaIngress(ingress)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+	assert.Contains(t, msg, "port 81")
+	assert.Contains(t, msg, "test-svc")
+}
+
+func TestValidateAPINames(t *testing.T) {
+	newIngress := func(ruleName string, matchNames ...string) *configurationv1beta1.ManbaIngress {
+		rule := configurationv1beta1.ManbaHTTPRule{
+			Name:  ruleName,
+			Match: []configurationv1beta1.ManbaHTTPMatch{{Host: "example.com"}},
+		}
+		for _, name := range matchNames {
+			rule.Match[0].Rules = append(rule.Match[0].Rules, configurationv1beta1.ManbaHTTPMatchRule{Name: name})
+		}
+		ingress := &configurationv1beta1.ManbaIngress{
+			Spec: configurationv1beta1.ManbaIngressSpec{HTTP: []configurationv1beta1.ManbaHTTPRule{rule}},
+		}
+		configurationv1beta1.SetManbaIngressDefaults(ingress)
+		return ingress
+	}
+
+	assert.Equal(t, "", validateAPINames(newIngress("users", "list", "get")))
+	assert.Equal(t, "", validateAPINames(newIngress("", "list")))
+	assert.Contains(t, validateAPINames(newIngress("Users", "list")), "http[0]: name")
+	assert.Contains(t, validateAPINames(newIngress("", "a.b")), "rules[0]: name")
+	assert.Contains(t, validateAPINames(newIngress("", "list", "list")), "same api name")
+	// unnamed rules matching the same requests
+	assert.Contains(t, validateAPINames(newIngress("", "", "")), "same api name")
+}
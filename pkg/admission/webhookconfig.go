@@ -0,0 +1,184 @@
+package admission
+
+import (
+	"github.com/golang/glog"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// webhook names in webhook configurations
+const (
+	validatingWebhookName = "validations.manba.io"
+	mutatingWebhookName   = "defaults.manba.io"
+)
+
+// webhookPort is the port of webhook service, it is also the default of apiserver
+const webhookPort int32 = 443
+
+// validatingRules are the operations sent to validating webhook
+func validatingRules() []admissionregistrationv1beta1.RuleWithOperations {
+	return []admissionregistrationv1beta1.RuleWithOperations{
+		manbaRule([]string{"manbaingresses"}, admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update),
+		manbaRule([]string{"manbaclusters"}, admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update, admissionregistrationv1beta1.Delete),
+		manbaRule([]string{"manbacachepolicies"}, admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update),
+		manbaRule([]string{"manbatrafficpolicies"}, admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update, admissionregistrationv1beta1.Delete),
+	}
+}
+
+// mutatingRules are the operations sent to mutating webhook
+func mutatingRules() []admissionregistrationv1beta1.RuleWithOperations {
+	return []admissionregistrationv1beta1.RuleWithOperations{
+		manbaRule([]string{"manbaingresses"}, admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update),
+	}
+}
+
+// manbaRule sets the fields defaulted by apiserver, so that rules read back compare equal
+func manbaRule(resources []string, ops ...admissionregistrationv1beta1.OperationType) admissionregistrationv1beta1.RuleWithOperations {
+	scope := admissionregistrationv1beta1.AllScopes
+	return admissionregistrationv1beta1.RuleWithOperations{
+		Operations: ops,
+		Rule: admissionregistrationv1beta1.Rule{
+			APIGroups:   []string{manbaIngressResource.Group},
+			APIVersions: []string{"*"},
+			Resources:   resources,
+			Scope:       &scope,
+		},
+	}
+}
+
+func (c *CertManager) clientConfig(path string, caBundle []byte) admissionregistrationv1beta1.WebhookClientConfig {
+	port := webhookPort
+	return admissionregistrationv1beta1.WebhookClientConfig{
+		Service: &admissionregistrationv1beta1.ServiceReference{
+			Namespace: c.cfg.ServiceNamespace,
+			Name:      c.cfg.ServiceName,
+			Path:      &path,
+			Port:      &port,
+		},
+		CABundle: caBundle,
+	}
+}
+
+func (c *CertManager) validatingWebhook(caBundle []byte) admissionregistrationv1beta1.ValidatingWebhook {
+	failurePolicy := admissionregistrationv1beta1.Fail
+	sideEffects := admissionregistrationv1beta1.SideEffectClassNone
+	return admissionregistrationv1beta1.ValidatingWebhook{
+		Name:                    validatingWebhookName,
+		ClientConfig:            c.clientConfig("/", caBundle),
+		Rules:                   validatingRules(),
+		FailurePolicy:           &failurePolicy,
+		SideEffects:             &sideEffects,
+		AdmissionReviewVersions: []string{"v1beta1"},
+	}
+}
+
+func (c *CertManager) mutatingWebhook(caBundle []byte) admissionregistrationv1beta1.MutatingWebhook {
+	failurePolicy := admissionregistrationv1beta1.Fail
+	sideEffects := admissionregistrationv1beta1.SideEffectClassNone
+	return admissionregistrationv1beta1.MutatingWebhook{
+		Name:                    mutatingWebhookName,
+		ClientConfig:            c.clientConfig(MutatePath, caBundle),
+		Rules:                   mutatingRules(),
+		FailurePolicy:           &failurePolicy,
+		SideEffects:             &sideEffects,
+		AdmissionReviewVersions: []string{"v1beta1"},
+	}
+}
+
+// ensureValidatingWebhook creates the validating webhook configuration
+// or overwrites the fields of our webhook which differ from the desired ones,
+// fields we don't set such as timeoutSeconds are left to apiserver
+func (c *CertManager) ensureValidatingWebhook(caBundle []byte) error {
+	if c.cfg.ValidatingWebhook == "" {
+		return nil
+	}
+	desired := c.validatingWebhook(caBundle)
+	configs := c.client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations()
+	config, err := configs.Get(c.cfg.ValidatingWebhook, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		glog.Infof("creating validating webhook configuration %s", c.cfg.ValidatingWebhook)
+		_, err = configs.Create(&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: c.cfg.ValidatingWebhook},
+			Webhooks:   []admissionregistrationv1beta1.ValidatingWebhook{desired},
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config = config.DeepCopy()
+	var webhook *admissionregistrationv1beta1.ValidatingWebhook
+	for i := range config.Webhooks {
+		if config.Webhooks[i].Name == desired.Name {
+			webhook = &config.Webhooks[i]
+			break
+		}
+	}
+	if webhook == nil {
+		config.Webhooks = append(config.Webhooks, desired)
+	} else {
+		current := *webhook
+		webhook.ClientConfig = desired.ClientConfig
+		webhook.Rules = desired.Rules
+		webhook.FailurePolicy = desired.FailurePolicy
+		webhook.SideEffects = desired.SideEffects
+		webhook.AdmissionReviewVersions = desired.AdmissionReviewVersions
+		if equality.Semantic.DeepEqual(current, *webhook) {
+			return nil
+		}
+	}
+	glog.Infof("updating validating webhook configuration %s", c.cfg.ValidatingWebhook)
+	_, err = configs.Update(config)
+	return err
+}
+
+// ensureMutatingWebhook creates the mutating webhook configuration
+// or overwrites the fields of our webhook which differ from the desired ones,
+// fields we don't set such as timeoutSeconds are left to apiserver
+func (c *CertManager) ensureMutatingWebhook(caBundle []byte) error {
+	if c.cfg.MutatingWebhook == "" {
+		return nil
+	}
+	desired := c.mutatingWebhook(caBundle)
+	configs := c.client.AdmissionregistrationV1beta1().MutatingWebhookConfigurations()
+	config, err := configs.Get(c.cfg.MutatingWebhook, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		glog.Infof("creating mutating webhook configuration %s", c.cfg.MutatingWebhook)
+		_, err = configs.Create(&admissionregistrationv1beta1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: c.cfg.MutatingWebhook},
+			Webhooks:   []admissionregistrationv1beta1.MutatingWebhook{desired},
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	config = config.DeepCopy()
+	var webhook *admissionregistrationv1beta1.MutatingWebhook
+	for i := range config.Webhooks {
+		if config.Webhooks[i].Name == desired.Name {
+			webhook = &config.Webhooks[i]
+			break
+		}
+	}
+	if webhook == nil {
+		config.Webhooks = append(config.Webhooks, desired)
+	} else {
+		current := *webhook
+		webhook.ClientConfig = desired.ClientConfig
+		webhook.Rules = desired.Rules
+		webhook.FailurePolicy = desired.FailurePolicy
+		webhook.SideEffects = desired.SideEffects
+		webhook.AdmissionReviewVersions = desired.AdmissionReviewVersions
+		if equality.Semantic.DeepEqual(current, *webhook) {
+			return nil
+		}
+	}
+	glog.Infof("updating mutating webhook configuration %s", c.cfg.MutatingWebhook)
+	_, err = configs.Update(config)
+	return err
+}
@@ -0,0 +1,39 @@
+package v1beta1
+
+import (
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+)
+
+// ManbaHTTPAggregate merges json responses of several calls into one response,
+// the response of each call is set to the attribute named after the call
+type ManbaHTTPAggregate struct {
+	Calls []ManbaHTTPAggregateCall `json:"calls"`
+}
+
+// ManbaHTTPAggregateCall is a request sent to a cluster for an aggregated response
+type ManbaHTTPAggregateCall struct {
+	// Name is the attribute of the response of call, it's unique in the rule
+	Name    string                `json:"name"`
+	Cluster ManbaHTTPRouteCluster `json:"cluster"`
+	// Batch orders calls, calls of a batch are sent together after the previous batch completes,
+	// so they are able to use responses of calls in previous batches
+	Batch int32 `json:"batch,omitempty"`
+	// Rewrite replaces the rewrite of rule for the call
+	Rewrite *ManbaHTTPURIRewrite `json:"rewrite,omitempty"`
+	Match   *ManbaHTTPRouteMatch `json:"match,omitempty"`
+	Cache   *metapb.Cache        `json:"cache,omitempty"`
+	// CachePolicy is the name of ManbaCachePolicy in the namespace which replaces cache
+	CachePolicy string `json:"cachePolicy,omitempty"`
+	// DefaultValue is the response of call when it fails
+	DefaultValue *metapb.HTTPResult `json:"defaultValue,omitempty"`
+	WriteTimeout int64              `json:"writeTimeout,omitempty"`
+	ReadTimeout  int64              `json:"readTimeout,omitempty"`
+}
+
+// GetCalls returns calls of a, it's nil if a is not set
+func (a *ManbaHTTPAggregate) GetCalls() []ManbaHTTPAggregateCall {
+	if a == nil {
+		return nil
+	}
+	return a.Calls
+}
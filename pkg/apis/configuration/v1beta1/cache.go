@@ -0,0 +1,69 @@
+package v1beta1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sources of cache keys
+const (
+	CacheKeyQuery  = "query"
+	CacheKeyHeader = "header"
+	CacheKeyCookie = "cookie"
+)
+
+var cacheKeySources = map[string]metapb.Source{
+	CacheKeyQuery:  metapb.QueryString,
+	CacheKeyHeader: metapb.Header,
+	CacheKeyCookie: metapb.Cookie,
+}
+
+// ManbaCachePolicySpec details of ManbaCachePolicy
+type ManbaCachePolicySpec struct {
+	// Keys are parameters of request which tell cached responses apart
+	Keys []ManbaCacheKey `json:"keys,omitempty"`
+	// TTL is how long a response is cached, in seconds at least
+	TTL metav1.Duration `json:"ttl"`
+	// Conditions limit the cached requests, all requests are cached if they are empty
+	Conditions []metapb.Condition `json:"conditions,omitempty"`
+}
+
+// ManbaCacheKey is a parameter of request in the key of cached response
+type ManbaCacheKey struct {
+	// Source is one of query, header and cookie
+	Source string `json:"source"`
+	Name   string `json:"name"`
+}
+
+// ToManbaCache returns the manba cache of spec
+func (s *ManbaCachePolicySpec) ToManbaCache() (*metapb.Cache, error) {
+	if s.TTL.Duration < time.Second {
+		return nil, errors.Errorf("ttl %v is less than 1s", s.TTL.Duration)
+	}
+	cache := &metapb.Cache{
+		Deadline:   uint64(s.TTL.Duration / time.Second),
+		Conditions: s.Conditions,
+	}
+	for i, key := range s.Keys {
+		source, ok := cacheKeySources[key.Source]
+		if !ok {
+			return nil, fmt.Errorf("keys[%d]: unknown source %q, it must be one of %s, %s and %s",
+				i, key.Source, CacheKeyQuery, CacheKeyHeader, CacheKeyCookie)
+		}
+		if key.Name == "" {
+			return nil, fmt.Errorf("keys[%d]: name is required", i)
+		}
+		cache.Keys = append(cache.Keys, metapb.Parameter{Name: key.Name, Source: source})
+	}
+	return cache, nil
+}
+
+// Validate returns an error if spec can't be converted to manba cache
+func (s *ManbaCachePolicySpec) Validate() error {
+	_, err := s.ToManbaCache()
+	return err
+}
@@ -0,0 +1,81 @@
+package v1beta1
+
+import (
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+)
+
+const (
+	// DefaultMethod matches all http methods
+	DefaultMethod = "*"
+	// DefaultURIPattern matches all paths
+	DefaultURIPattern = "/"
+	// DefaultRoutingRate sends all matched traffic to mirror or split
+	DefaultRoutingRate int32 = 100
+	// DefaultPreviewHeaderName is the header selecting preview requests
+	DefaultPreviewHeaderName = "X-Manba-Preview"
+	// DefaultPreviewHeaderValue is the value of preview header of preview requests
+	DefaultPreviewHeaderValue = "true"
+	// DefaultMaintenanceCode is the status code of maintenance response
+	DefaultMaintenanceCode int32 = 503
+)
+
+var (
+	// DefaultMatchType is the name of the default match rule of manba api
+	DefaultMatchType = metapb.MatchDefault.String()
+	// DefaultLoadBalancer is the name of the default load balancer of manba cluster
+	DefaultLoadBalancer = metapb.RoundRobin.String()
+)
+
+// SetManbaIngressDefaults fills the fields of ingress which are left empty
+func SetManbaIngressDefaults(ingress *ManbaIngress) {
+	for i := range ingress.Spec.HTTP {
+		rule := &ingress.Spec.HTTP[i]
+		for j := range rule.Match {
+			for k := range rule.Match[j].Rules {
+				r := &rule.Match[j].Rules[k]
+				if r.URI.Pattern == "" {
+					r.URI.Pattern = DefaultURIPattern
+				}
+				if r.Method == nil {
+					method := DefaultMethod
+					r.Method = &method
+				}
+				if r.MatchType == "" {
+					r.MatchType = DefaultMatchType
+				}
+			}
+		}
+
+		for j := range rule.Mirror {
+			// sample replaces the rate of mirror
+			if rule.Mirror[j].Sample == nil {
+				setManbaHTTPRoutingDefaults(&rule.Mirror[j].ManbaHTTPRouting)
+			}
+		}
+		for j := range rule.Split {
+			setManbaHTTPRoutingDefaults(&rule.Split[j])
+		}
+		for j := range rule.Schedules {
+			schedule := &rule.Schedules[j]
+			for k := range schedule.Split {
+				setManbaHTTPRoutingDefaults(&schedule.Split[k])
+			}
+		}
+	}
+}
+
+func setManbaHTTPRoutingDefaults(routing *ManbaHTTPRouting) {
+	if routing.Rate == nil {
+		rate := DefaultRoutingRate
+		routing.Rate = &rate
+	}
+}
+
+// SetTrafficPolicyDefaults fills the fields of policy which are left empty,
+// it's applied to effective policies since the ones of clusters and subsets inherit fields
+func SetTrafficPolicyDefaults(policy *TrafficPolicy) {
+	if policy.LoadBalancer == nil {
+		lb := DefaultLoadBalancer
+		policy.LoadBalancer = &lb
+	}
+}
@@ -0,0 +1,57 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ManbaMaintenance returns a maintenance response from all apis of an ingress
+type ManbaMaintenance struct {
+	Enabled bool `json:"enabled"`
+	// Response is returned instead of calling clusters, default is 503 with an empty body
+	Response *metapb.HTTPResult `json:"response,omitempty"`
+	// BodyFrom replaces the body of response with a key of a ConfigMap in the namespace of ingress
+	BodyFrom *corev1.ConfigMapKeySelector `json:"bodyFrom,omitempty"`
+	// Allowlist are IPv4 addresses or CIDRs of clients which still reach clusters,
+	// they are matched with the first address in X-Forwarded-For like sourceCIDR of canary
+	Allowlist []string `json:"allowlist,omitempty"`
+}
+
+// IsEnabled returns true if m is set and enabled
+func (m *ManbaMaintenance) IsEnabled() bool {
+	return m != nil && m.Enabled
+}
+
+// GetResponse returns a copy of response, the default response is used if it's not set
+func (m *ManbaMaintenance) GetResponse() *metapb.HTTPResult {
+	var res metapb.HTTPResult
+	if m.Response != nil {
+		res = *m.Response
+	}
+	if res.Code == 0 {
+		res.Code = DefaultMaintenanceCode
+	}
+	return &res
+}
+
+// AllowlistConditions returns the routing conditions matching clients in allowlist, nil if it's empty
+func (m *ManbaMaintenance) AllowlistConditions() ([]metapb.Condition, error) {
+	if len(m.Allowlist) == 0 {
+		return nil, nil
+	}
+	var cidrs []string
+	for _, addr := range m.Allowlist {
+		if !strings.Contains(addr, "/") {
+			addr += "/32"
+		}
+		cidrs = append(cidrs, addr)
+	}
+	cond, err := forwardedForCondition(cidrs)
+	if err != nil {
+		return nil, fmt.Errorf("allowlist %v", err)
+	}
+	return []metapb.Condition{cond}, nil
+}
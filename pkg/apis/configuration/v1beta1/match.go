@@ -0,0 +1,106 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+)
+
+// neverMatch is a regular expression matching nothing,
+// it makes a parameter absent
+const neverMatch = `[^\s\S]`
+
+// ManbaHTTPValueMatch matches a parameter of request, exactly one of
+// exact, prefix, regex, present and absent should be set.
+// A string is accepted in place of the object, it's a regular expression
+type ManbaHTTPValueMatch struct {
+	Exact   *string `json:"exact,omitempty"`
+	Prefix  *string `json:"prefix,omitempty"`
+	Regex   *string `json:"regex,omitempty"`
+	Present bool    `json:"present,omitempty"`
+	Absent  bool    `json:"absent,omitempty"`
+	// Required makes requests without the parameter unmatched, it defaults to true.
+	// If it's false, the parameter is only checked when it's sent
+	Required *bool `json:"required,omitempty"`
+}
+
+// UnmarshalJSON accepts a regular expression or a matcher object
+func (m *ManbaHTTPValueMatch) UnmarshalJSON(data []byte) error {
+	var regex string
+	if err := json.Unmarshal(data, &regex); err == nil {
+		*m = ManbaHTTPValueMatch{Regex: &regex}
+		return nil
+	}
+	type plain ManbaHTTPValueMatch
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// MarshalJSON writes a required regular expression as a string,
+// so objects in the map form keep their form
+func (m ManbaHTTPValueMatch) MarshalJSON() ([]byte, error) {
+	if m.Regex != nil && m.Exact == nil && m.Prefix == nil &&
+		!m.Present && !m.Absent && m.Required == nil {
+		return json.Marshal(*m.Regex)
+	}
+	type plain ManbaHTTPValueMatch
+	return json.Marshal(plain(m))
+}
+
+// Validate returns an error if the matcher is ambiguous or invalid
+func (m *ManbaHTTPValueMatch) Validate() error {
+	set := 0
+	for _, ok := range []bool{m.Exact != nil, m.Prefix != nil, m.Regex != nil, m.Present, m.Absent} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of exact, prefix, regex, present and absent must be set")
+	}
+	if m.Regex != nil {
+		if _, err := regexp.Compile(*m.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %v", err)
+		}
+	}
+	if m.Required != nil && !*m.Required && m.Present {
+		return errors.New("present can't be optional")
+	}
+	if m.Required != nil && *m.Required && m.Absent {
+		return errors.New("absent can't be required")
+	}
+	return nil
+}
+
+// ToManbaValidation returns the manba validation of parameter
+func (m *ManbaHTTPValueMatch) ToManbaValidation(param metapb.Parameter) *metapb.Validation {
+	v := &metapb.Validation{
+		Parameter: param,
+		Required:  m.Required == nil || *m.Required,
+	}
+
+	var expr string
+	switch {
+	case m.Exact != nil:
+		expr = "^" + regexp.QuoteMeta(*m.Exact) + "$"
+	case m.Prefix != nil:
+		expr = "^" + regexp.QuoteMeta(*m.Prefix)
+	case m.Regex != nil:
+		expr = *m.Regex
+	case m.Present:
+		// a required parameter without rules only needs to be sent
+		v.Required = true
+		return v
+	case m.Absent:
+		// an optional parameter is only checked when it's sent
+		v.Required = false
+		expr = neverMatch
+	}
+	v.Rules = []metapb.ValidationRule{{
+		RuleType:   metapb.RuleRegexp,
+		Expression: expr,
+	}}
+	return v
+}
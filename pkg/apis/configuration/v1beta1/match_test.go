@@ -0,0 +1,64 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManbaHTTPRouteMatch_JSON(t *testing.T) {
+	raw := `{"header":{"x-version":"^v[12]$","x-user":{"exact":"admin"}},"query":{"debug":{"absent":true}}}`
+	var match ManbaHTTPRouteMatch
+	assert.Nil(t, json.Unmarshal([]byte(raw), &match))
+	assert.Equal(t, "^v[12]$", *match.Header["x-version"].Regex)
+	assert.Equal(t, "admin", *match.Header["x-user"].Exact)
+	assert.True(t, match.Query["debug"].Absent)
+
+	// regular expressions keep the map form
+	out, err := json.Marshal(match.Header)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"x-version":"^v[12]$","x-user":{"exact":"admin"}}`, string(out))
+}
+
+func TestManbaHTTPRouteMatch_ToManbaValidations(t *testing.T) {
+	str := func(s string) *string { return &s }
+	optional := false
+	match := &ManbaHTTPRouteMatch{
+		Header: map[string]ManbaHTTPValueMatch{
+			"exact":   {Exact: str("a.b")},
+			"prefix":  {Prefix: str("/api")},
+			"present": {Present: true},
+		},
+		Query: map[string]ManbaHTTPValueMatch{
+			"absent":   {Absent: true},
+			"optional": {Regex: str("^[0-9]+$"), Required: &optional},
+		},
+	}
+	assert.Nil(t, match.Validate())
+
+	rule := func(expr string) []metapb.ValidationRule {
+		return []metapb.ValidationRule{{RuleType: metapb.RuleRegexp, Expression: expr}}
+	}
+	assert.Equal(t, []*metapb.Validation{
+		{Parameter: metapb.Parameter{Name: "absent", Source: metapb.QueryString}, Required: false, Rules: rule(neverMatch)},
+		{Parameter: metapb.Parameter{Name: "optional", Source: metapb.QueryString}, Required: false, Rules: rule("^[0-9]+$")},
+		{Parameter: metapb.Parameter{Name: "exact", Source: metapb.Header}, Required: true, Rules: rule(`^a\.b$`)},
+		{Parameter: metapb.Parameter{Name: "prefix", Source: metapb.Header}, Required: true, Rules: rule("^/api")},
+		{Parameter: metapb.Parameter{Name: "present", Source: metapb.Header}, Required: true},
+	}, match.ToManbaValidations())
+}
+
+func TestManbaHTTPValueMatch_Validate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	yes, no := true, false
+
+	assert.Nil(t, (&ManbaHTTPValueMatch{Regex: str("^a")}).Validate())
+	assert.Nil(t, (&ManbaHTTPValueMatch{Absent: true, Required: &no}).Validate())
+	assert.NotNil(t, (&ManbaHTTPValueMatch{}).Validate())
+	assert.NotNil(t, (&ManbaHTTPValueMatch{Exact: str("a"), Prefix: str("a")}).Validate())
+	assert.NotNil(t, (&ManbaHTTPValueMatch{Regex: str("(")}).Validate())
+	assert.NotNil(t, (&ManbaHTTPValueMatch{Present: true, Required: &no}).Validate())
+	assert.NotNil(t, (&ManbaHTTPValueMatch{Absent: true, Required: &yes}).Validate())
+}
@@ -0,0 +1,67 @@
+package v1beta1
+
+import (
+	"github.com/pkg/errors"
+)
+
+// sources of rate limit keys
+const (
+	RateLimitKeyHeader   = "header"
+	RateLimitKeyQuery    = "query"
+	RateLimitKeyCookie   = "cookie"
+	RateLimitKeyClientIP = "clientIP"
+)
+
+// ManbaHTTPRateLimit limits requests each client sends to apis of a rule, clients are told apart by key,
+// it's enforced by the rate limit filter of manba proxy which reads the limit from tags of apis
+type ManbaHTTPRateLimit struct {
+	Key ManbaRateLimitKey `json:"key"`
+	// QPS is the number of requests a client may send per second
+	QPS uint32 `json:"qps"`
+	// Burst is the number of requests a client may send at once, default is qps
+	Burst uint32 `json:"burst,omitempty"`
+	// Shared makes apis of the rules of ingress with shared limits use one quota of each client,
+	// otherwise each api has its own quota
+	Shared bool `json:"shared,omitempty"`
+}
+
+// ManbaRateLimitKey is the parameter of request which identifies the client
+type ManbaRateLimitKey struct {
+	// Source is one of header, query, cookie and clientIP
+	Source string `json:"source"`
+	// Name of the parameter, it's not set for clientIP
+	Name string `json:"name,omitempty"`
+}
+
+// GetBurst returns burst of l, it's qps if burst is not set
+func (l *ManbaHTTPRateLimit) GetBurst() uint32 {
+	if l.Burst == 0 {
+		return l.QPS
+	}
+	return l.Burst
+}
+
+// Validate returns an error if l can't be enforced
+func (l *ManbaHTTPRateLimit) Validate() error {
+	switch l.Key.Source {
+	case RateLimitKeyHeader, RateLimitKeyQuery, RateLimitKeyCookie:
+		if l.Key.Name == "" {
+			return errors.Errorf("key: name is required by source %s", l.Key.Source)
+		}
+	case RateLimitKeyClientIP:
+		if l.Key.Name != "" {
+			return errors.Errorf("key: name is not allowed by source %s", l.Key.Source)
+		}
+	default:
+		return errors.Errorf("key: unknown source %q, it must be one of %s, %s, %s and %s", l.Key.Source,
+			RateLimitKeyHeader, RateLimitKeyQuery, RateLimitKeyCookie, RateLimitKeyClientIP)
+	}
+	if l.QPS == 0 {
+		return errors.New("qps must be greater than 0")
+	}
+	if l.Burst != 0 && l.Burst < l.QPS {
+		// a bucket smaller than qps can't let qps requests in
+		return errors.Errorf("burst %d is less than qps %d", l.Burst, l.QPS)
+	}
+	return nil
+}
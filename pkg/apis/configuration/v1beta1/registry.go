@@ -31,6 +31,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ManbaIngressList{},
 		&ManbaCluster{},
 		&ManbaClusterList{},
+		&ManbaCanary{},
+		&ManbaCanaryList{},
+		&ManbaCachePolicy{},
+		&ManbaCachePolicyList{},
+		&ManbaTrafficPolicy{},
+		&ManbaTrafficPolicyList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -0,0 +1,140 @@
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+)
+
+// ForwardedForHeader carries the address of client, sourceCIDR matches the first address in it
+const ForwardedForHeader = "X-Forwarded-For"
+
+// ManbaHTTPCanary selects requests sent to a split or mirror,
+// a request must match all of the fields
+type ManbaHTTPCanary struct {
+	Header map[string]ManbaHTTPValueMatch `json:"header,omitempty"`
+	Cookie map[string]ManbaHTTPValueMatch `json:"cookie,omitempty"`
+	// SourceCIDR matches the address of client in header X-Forwarded-For,
+	// which is set by the load balancer in front of manba, only IPv4 is supported
+	SourceCIDR []string `json:"sourceCIDR,omitempty"`
+}
+
+// ToConditions returns the routing conditions of canary
+func (c *ManbaHTTPCanary) ToConditions() ([]metapb.Condition, error) {
+	var conds []metapb.Condition
+	add := func(source metapb.Source, values map[string]ManbaHTTPValueMatch) error {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			m := values[name]
+			cond, err := m.toCondition(metapb.Parameter{Name: name, Source: source})
+			if err != nil {
+				return fmt.Errorf("%s %s: %v", strings.ToLower(source.String()), name, err)
+			}
+			conds = append(conds, cond)
+		}
+		return nil
+	}
+	if err := add(metapb.Header, c.Header); err != nil {
+		return nil, err
+	}
+	if err := add(metapb.Cookie, c.Cookie); err != nil {
+		return nil, err
+	}
+
+	if len(c.SourceCIDR) != 0 {
+		cond, err := forwardedForCondition(c.SourceCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("sourceCIDR %v", err)
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+// forwardedForCondition returns the condition matching requests whose first address in X-Forwarded-For is in cidrs
+func forwardedForCondition(cidrs []string) (metapb.Condition, error) {
+	var exprs []string
+	for _, cidr := range cidrs {
+		expr, err := cidrRegexp(cidr)
+		if err != nil {
+			return metapb.Condition{}, fmt.Errorf("%s: %v", cidr, err)
+		}
+		exprs = append(exprs, expr)
+	}
+	return metapb.Condition{
+		Parameter: metapb.Parameter{Name: ForwardedForHeader, Source: metapb.Header},
+		Cmp:       metapb.CMPMatch,
+		Expect:    `^\s*(?:` + strings.Join(exprs, "|") + `)\s*(?:,|$)`,
+	}, nil
+}
+
+// toCondition returns the routing condition of parameter, conditions never match
+// requests without the parameter, so the matcher must not be absent or optional
+func (m *ManbaHTTPValueMatch) toCondition(param metapb.Parameter) (metapb.Condition, error) {
+	if err := m.Validate(); err != nil {
+		return metapb.Condition{}, err
+	}
+	if m.Absent {
+		return metapb.Condition{}, errors.New("absent is not supported by routing conditions")
+	}
+	if m.Required != nil && !*m.Required {
+		return metapb.Condition{}, errors.New("optional parameters are not supported by routing conditions")
+	}
+
+	cond := metapb.Condition{Parameter: param, Cmp: metapb.CMPMatch}
+	switch {
+	case m.Exact != nil:
+		cond.Cmp = metapb.CMPEQ
+		cond.Expect = *m.Exact
+	case m.Prefix != nil:
+		cond.Expect = "^" + regexp.QuoteMeta(*m.Prefix)
+	case m.Regex != nil:
+		cond.Expect = *m.Regex
+	case m.Present:
+		// empty values never match, so any value matches
+		cond.Expect = ""
+	}
+	return cond, nil
+}
+
+// cidrRegexp returns a regular expression matching the IPv4 addresses in cidr
+func cidrRegexp(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return "", errors.New("only IPv4 is supported")
+	}
+	ones, _ := ipNet.Mask.Size()
+
+	octets := make([]string, 4)
+	for i := range octets {
+		bits := ones - i*8
+		switch {
+		case bits >= 8:
+			octets[i] = strconv.Itoa(int(ip[i]))
+		case bits <= 0:
+			octets[i] = `\d{1,3}`
+		default:
+			n := 1 << uint(8-bits)
+			values := make([]string, 0, n)
+			for v := int(ip[i]); v < int(ip[i])+n; v++ {
+				values = append(values, strconv.Itoa(v))
+			}
+			octets[i] = "(?:" + strings.Join(values, "|") + ")"
+		}
+	}
+	return strings.Join(octets, `\.`), nil
+}
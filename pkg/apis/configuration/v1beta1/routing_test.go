@@ -0,0 +1,63 @@
+package v1beta1
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManbaHTTPCanary_ToConditions(t *testing.T) {
+	str := func(s string) *string { return &s }
+	canary := &ManbaHTTPCanary{
+		Header: map[string]ManbaHTTPValueMatch{
+			"x-user":    {Exact: str("internal")},
+			"x-version": {Prefix: str("v2.")},
+		},
+		Cookie: map[string]ManbaHTTPValueMatch{
+			"beta": {Present: true},
+		},
+		SourceCIDR: []string{"10.0.0.0/8", "192.168.1.16/30"},
+	}
+	conds, err := canary.ToConditions()
+	assert.Nil(t, err)
+	assert.Len(t, conds, 4)
+	assert.Equal(t, metapb.Condition{
+		Parameter: metapb.Parameter{Name: "x-user", Source: metapb.Header},
+		Cmp:       metapb.CMPEQ,
+		Expect:    "internal",
+	}, conds[0])
+	assert.Equal(t, `^v2\.`, conds[1].Expect)
+	assert.Equal(t, metapb.Condition{
+		Parameter: metapb.Parameter{Name: "beta", Source: metapb.Cookie},
+		Cmp:       metapb.CMPMatch,
+	}, conds[2])
+
+	cidr := conds[3]
+	assert.Equal(t, metapb.Parameter{Name: "X-Forwarded-For", Source: metapb.Header}, cidr.Parameter)
+	re := regexp.MustCompile(cidr.Expect)
+	for value, matched := range map[string]bool{
+		"10.1.2.3":                true,
+		"10.1.2.3, 172.16.0.1":    true,
+		"192.168.1.19":            true,
+		"192.168.1.20":            false,
+		"192.168.1.1":             false,
+		"192.168.1.160":           false,
+		"110.1.2.3":               false,
+		"172.16.0.1, 10.1.2.3":    false,
+		" 192.168.1.17 ,10.0.0.1": true,
+	} {
+		assert.Equal(t, matched, re.MatchString(value), value)
+	}
+
+	for _, invalid := range []*ManbaHTTPCanary{
+		{Header: map[string]ManbaHTTPValueMatch{"x-user": {Absent: true}}},
+		{Cookie: map[string]ManbaHTTPValueMatch{"beta": {Regex: str("(")}}},
+		{SourceCIDR: []string{"10.0.0.1"}},
+		{SourceCIDR: []string{"fd00::/8"}},
+	} {
+		_, err := invalid.ToConditions()
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,168 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManbaSchedule replaces policies of a rule or subset during its window
+type ManbaSchedule struct {
+	// Name identifies the schedule in status
+	Name   string          `json:"name"`
+	Window ManbaTimeWindow `json:"window"`
+	// TrafficPolicy overrides the fields it sets in the traffic policy of subset, it's not allowed in rules since manba apis have none
+	TrafficPolicy *TrafficPolicy `json:"trafficPolicy,omitempty"`
+	// DefaultValue replaces the default value of rule, it's not allowed in subsets
+	DefaultValue *metapb.HTTPResult `json:"defaultValue,omitempty"`
+	// Split replaces the splits of rule, it's not allowed in subsets
+	Split []ManbaHTTPRouting `json:"split,omitempty"`
+}
+
+// ManbaTimeWindow is a daily time range, like 22:00 to 06:00
+type ManbaTimeWindow struct {
+	// Days are the days the window starts on, like Mon, every day if it's empty
+	Days []string `json:"days,omitempty"`
+	// Start is the time the window starts, in format HH:MM
+	Start string `json:"start"`
+	// End is the time the window ends, in format HH:MM, the window ends the next day if it's not after start
+	End string `json:"end"`
+	// TimeZone is the IANA name of the zone of start and end, default is UTC
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ManbaActiveSchedule is a schedule in its window
+type ManbaActiveSchedule struct {
+	// Target is the rule or subset the schedule belongs to
+	Target   string      `json:"target"`
+	Schedule string      `json:"schedule"`
+	Until    metav1.Time `json:"until"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type window struct {
+	// days are nil if the window is on every day
+	days       map[time.Weekday]bool
+	start, end time.Duration
+	loc        *time.Location
+}
+
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, errors.Errorf("%q is not in format HH:MM", s)
+	}
+	if h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, errors.Errorf("%q is not a time of day", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+func (w *ManbaTimeWindow) parse() (*window, error) {
+	res := &window{loc: time.UTC}
+	var err error
+	if res.start, err = parseClock(w.Start); err != nil {
+		return nil, errors.Wrap(err, "start")
+	}
+	if res.end, err = parseClock(w.End); err != nil {
+		return nil, errors.Wrap(err, "end")
+	}
+	if res.start == res.end {
+		return nil, errors.New("start and end are the same")
+	}
+	if w.TimeZone != "" {
+		if res.loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return nil, errors.Wrap(err, "timeZone")
+		}
+	}
+	for _, day := range w.Days {
+		d, ok := weekdays[strings.ToLower(day)]
+		if !ok && len(day) > 3 {
+			// full names like Monday
+			d, ok = weekdays[strings.ToLower(day[:3])]
+			ok = ok && strings.EqualFold(day, d.String())
+		}
+		if !ok {
+			return nil, errors.Errorf("unknown day %q", day)
+		}
+		if res.days == nil {
+			res.days = make(map[time.Weekday]bool)
+		}
+		res.days[d] = true
+	}
+	return res, nil
+}
+
+// Validate returns an error if the window can't be evaluated
+func (w *ManbaTimeWindow) Validate() error {
+	_, err := w.parse()
+	return err
+}
+
+// Evaluate returns whether now is in the window, and the time the result changes
+func (w *ManbaTimeWindow) Evaluate(now time.Time) (bool, time.Time, error) {
+	win, err := w.parse()
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	local := now.In(win.loc)
+	var next time.Time
+	// windows started yesterday may not end yet, and the next one starts in a week at most
+	for offset := -1; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, win.loc)
+		if win.days != nil && !win.days[day.Weekday()] {
+			continue
+		}
+		start := clockOf(day, win.start)
+		end := clockOf(day, win.end)
+		if !end.After(start) {
+			end = clockOf(day.AddDate(0, 0, 1), win.end)
+		}
+		if !now.Before(start) && now.Before(end) {
+			return true, end, nil
+		}
+		if start.After(now) && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return false, next, nil
+}
+
+// clockOf returns the time of clock on day, it's computed with wall clock, so the days of DST changes are right
+func clockOf(day time.Time, clock time.Duration) time.Time {
+	h, m := int(clock/time.Hour), int(clock%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+// ActiveSchedule returns the first schedule whose window contains now,
+// and the earliest time the result may change, the time is zero if there's no schedule
+func ActiveSchedule(schedules []ManbaSchedule, now time.Time) (*ManbaSchedule, time.Time, error) {
+	var active *ManbaSchedule
+	var next time.Time
+	for i := range schedules {
+		in, change, err := schedules[i].Window.Evaluate(now)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrapf(err, "schedule %s", schedules[i].Name)
+		}
+		if in && active == nil {
+			active = &schedules[i]
+		}
+		if !change.IsZero() && (next.IsZero() || change.Before(next)) {
+			next = change
+		}
+	}
+	return active, next, nil
+}
@@ -0,0 +1,70 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManbaTimeWindow_Evaluate(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		// 2020-01-06 is a Monday
+		return time.Date(2020, 1, 6+day, hour, min, 0, 0, time.UTC)
+	}
+	night := &ManbaTimeWindow{Start: "22:00", End: "06:00"}
+	tests := []struct {
+		name   string
+		window *ManbaTimeWindow
+		now    time.Time
+		active bool
+		next   time.Time
+	}{
+		{"before overnight window", night, at(0, 12, 0), false, at(0, 22, 0)},
+		{"in overnight window", night, at(0, 23, 0), true, at(1, 6, 0)},
+		{"in overnight window started yesterday", night, at(1, 5, 59), true, at(1, 6, 0)},
+		{"at end of window", night, at(1, 6, 0), false, at(1, 22, 0)},
+		{"weekdays", &ManbaTimeWindow{Days: []string{"Mon", "friday"}, Start: "09:00", End: "18:00"}, at(1, 10, 0), false, at(4, 9, 0)},
+		{"whole day", &ManbaTimeWindow{Days: []string{"sun"}, Start: "00:00", End: "24:00"}, at(6, 23, 0), true, at(7, 0, 0)},
+		{"time zone", &ManbaTimeWindow{Start: "09:00", End: "18:00", TimeZone: "Asia/Shanghai"}, at(0, 2, 0), true, at(0, 10, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, next, err := tt.window.Evaluate(tt.now)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.active, active)
+			assert.True(t, tt.next.Equal(next), "next is %v", next)
+		})
+	}
+
+	for _, window := range []ManbaTimeWindow{
+		{Start: "9:00", End: "18:00"},
+		{Start: "09:00", End: "25:00"},
+		{Start: "09:00", End: "09:00"},
+		{Start: "09:00", End: "18:00", Days: []string{"someday"}},
+		{Start: "09:00", End: "18:00", TimeZone: "Mars/Olympus"},
+	} {
+		assert.NotNil(t, window.Validate(), "%+v", window)
+	}
+}
+
+func TestActiveSchedule(t *testing.T) {
+	now := time.Date(2020, 1, 6, 23, 0, 0, 0, time.UTC)
+	schedules := []ManbaSchedule{
+		{Name: "business", Window: ManbaTimeWindow{Start: "09:00", End: "18:00"}},
+		{Name: "night", Window: ManbaTimeWindow{Start: "22:00", End: "06:00"}},
+		{Name: "late", Window: ManbaTimeWindow{Start: "23:00", End: "23:30"}},
+	}
+	active, next, err := ActiveSchedule(schedules, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "night", active.Name)
+	assert.True(t, next.Equal(now.Add(30*time.Minute)))
+
+	active, next, err = ActiveSchedule(nil, now)
+	assert.Nil(t, err)
+	assert.Nil(t, active)
+	assert.True(t, next.IsZero())
+
+	_, _, err = ActiveSchedule([]ManbaSchedule{{Name: "bad", Window: ManbaTimeWindow{Start: "x"}}}, now)
+	assert.Contains(t, err.Error(), "schedule bad: start")
+}
@@ -0,0 +1,37 @@
+package v1beta1
+
+// DefaultTrafficPolicyName is the name of ManbaTrafficPolicy inherited by all manba clusters in its namespace
+const DefaultTrafficPolicyName = "default"
+
+// ManbaSubsetTrafficPolicy is the effective traffic policy of a subset
+type ManbaSubsetTrafficPolicy struct {
+	Subset string        `json:"subset"`
+	Policy TrafficPolicy `json:"policy"`
+}
+
+// MergeTrafficPolicies returns a policy whose fields are the first ones set in policies,
+// so policies are ordered from the nearest one, nil policies are skipped
+func MergeTrafficPolicies(policies ...*TrafficPolicy) *TrafficPolicy {
+	res := &TrafficPolicy{}
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		if res.LoadBalancer == nil && policy.LoadBalancer != nil {
+			lb := *policy.LoadBalancer
+			res.LoadBalancer = &lb
+		}
+		if res.MaxQPS == 0 {
+			res.MaxQPS = policy.MaxQPS
+		}
+		if res.CircuitBreaker == nil && policy.CircuitBreaker != nil {
+			cb := *policy.CircuitBreaker
+			res.CircuitBreaker = &cb
+		}
+		if res.RateLimitOption == nil && policy.RateLimitOption != nil {
+			option := *policy.RateLimitOption
+			res.RateLimitOption = &option
+		}
+	}
+	return res
+}
@@ -1,7 +1,9 @@
 package v1beta1
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/fagongzi/gateway/pkg/pb/metapb"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
@@ -10,7 +12,6 @@ import (
 )
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // ManbaIngress is a top-level type. A client is created for it.
@@ -22,8 +23,14 @@ type ManbaIngress struct {
 
 	Spec ManbaIngressSpec `json:"spec,omitempty"`
 
-	// Status *ManbaIngressStatus `json:"status,omitempty"`
-	Status networkingv1beta1.IngressStatus `json:"status,omitempty"`
+	Status ManbaIngressStatus `json:"status,omitempty"`
+}
+
+// ManbaIngressStatus is the observed state of ManbaIngress
+type ManbaIngressStatus struct {
+	networkingv1beta1.IngressStatus `json:",inline"`
+	// ActiveSchedules are the schedules of rules in their windows
+	ActiveSchedules []ManbaActiveSchedule `json:"activeSchedules,omitempty"`
 }
 
 // ManbaIngressList is a list of ManbaIngress
@@ -39,10 +46,16 @@ type ManbaIngressList struct {
 type ManbaIngressSpec struct {
 	HTTP []ManbaHTTPRule              `json:"http,omitempty"`
 	TLS  networkingv1beta1.IngressTLS `json:"tls,omitempty"`
+	// Maintenance switches all apis of the ingress to a maintenance response when it's enabled
+	Maintenance *ManbaMaintenance `json:"maintenance,omitempty"`
 }
 
 // ManbaHTTPRule implements manba api
 type ManbaHTTPRule struct {
+	// Name identifies apis of the rule, it's optional
+	Name string `json:"name,omitempty"`
+	// Priority orders apis across ingresses, apis with higher priority are matched first
+	Priority        int32                   `json:"priority,omitempty"`
 	Match           []ManbaHTTPMatch        `json:"match,omitempty"`
 	Rewrite         *ManbaHTTPURIRewrite    `json:"rewrite,omtiempty"`
 	IPAccessControl *metapb.IPAccessControl `json:"accessControl,omitempty"`
@@ -52,8 +65,14 @@ type ManbaHTTPRule struct {
 	AuthFilter      *string                 `json:"authFilter,omitempty"`
 	TrafficPolicy   *TrafficPolicy          `json:"trafficPolicy,omitempty"`
 	Route           []ManbaHTTPRoute        `json:"route,omitempty"`
-	Mirror          []ManbaHTTPRouting      `json:"mirror,omitempty"`
-	Split           []ManbaHTTPRouting      `json:"split,omitempty"`
+	// Aggregate replaces route with several calls whose responses are merged
+	Aggregate *ManbaHTTPAggregate `json:"aggregate,omitempty"`
+	Mirror    []ManbaHTTPMirror   `json:"mirror,omitempty"`
+	Split     []ManbaHTTPRouting  `json:"split,omitempty"`
+	// Schedules replace policies of the rule during their windows, the first one in its window is used
+	Schedules []ManbaSchedule `json:"schedules,omitempty"`
+	// RateLimit limits requests of each client to apis of the rule
+	RateLimit *ManbaHTTPRateLimit `json:"rateLimit,omitempty"`
 }
 
 type ManbaHTTPMatch struct {
@@ -62,6 +81,8 @@ type ManbaHTTPMatch struct {
 }
 
 type ManbaHTTPMatchRule struct {
+	// Name identifies the api of the match rule, a hash of what it matches is used if it's empty
+	Name      string            `json:"name,omitempty"`
 	URI       ManbaHTTPURIMatch `json:"uri,omitempty"`
 	Method    *string           `json:"method,omitempty"`
 	MatchType string            `json:"match_type,omitempty"`
@@ -86,18 +107,57 @@ type ManbaHTTPRouting struct {
 	Cluster    ManbaHTTPRouteCluster `json:"cluster,omitempty"`
 	Rate       *int32                `json:"rate,omitempty"`
 	Conditions []metapb.Condition    `json:"conditions,omitempty"`
+	// Canary is a shorthand of conditions, it's compiled to conditions added to them
+	Canary *ManbaHTTPCanary `json:"canary,omitempty"`
+}
+
+// ManbaHTTPMirror copies requests to a cluster, responses of the cluster are dropped.
+// Manba copies requests unchanged, credentials in headers included
+type ManbaHTTPMirror struct {
+	ManbaHTTPRouting `json:",inline"`
+	// Sample is the percentage of requests copied, rate is its former name
+	Sample *int32 `json:"sample,omitempty"`
+	// Methods are the http methods of copied requests, requests of all methods are copied if it's empty
+	Methods []string `json:"methods,omitempty"`
+}
+
+// GetSample returns the percentage of requests copied
+func (m *ManbaHTTPMirror) GetSample() int32 {
+	switch {
+	case m.Sample != nil:
+		return *m.Sample
+	case m.Rate != nil:
+		return *m.Rate
+	}
+	return DefaultRoutingRate
+}
+
+// CopiesMethod returns true if requests of method are copied,
+// method is the method of manba api, "*" matches all methods
+func (m *ManbaHTTPMirror) CopiesMethod(method string) bool {
+	if len(m.Methods) == 0 {
+		return true
+	}
+	for _, allowed := range m.Methods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
 }
 
 type ManbaHTTPRoute struct {
-	Cluster      ManbaHTTPRouteCluster `json:"cluster,omitempty"`
-	Rewrite      *ManbaHTTPURIRewrite  `json:"rewrite,omitempty"`
-	AttrName     string                `json:"attrName,omitempty"`
-	Match        *ManbaHTTPRouteMatch  `json:"match,omitempty"`
-	Cache        *metapb.Cache         `json:"cache,omitempty"`
-	BatchIndex   int32                 `json:"batchIndex,omitempty"`
-	DefaultValue *metapb.HTTPResult    `json:"default_value,omitempty"`
-	WriteTimeout int64                 `json:"writeTimeout,omitempty"`
-	ReadTimeout  int64                 `json:"readTimeout,omitempty"`
+	Cluster  ManbaHTTPRouteCluster `json:"cluster,omitempty"`
+	Rewrite  *ManbaHTTPURIRewrite  `json:"rewrite,omitempty"`
+	AttrName string                `json:"attrName,omitempty"`
+	Match    *ManbaHTTPRouteMatch  `json:"match,omitempty"`
+	Cache    *metapb.Cache         `json:"cache,omitempty"`
+	// CachePolicy is the name of ManbaCachePolicy in the namespace which replaces cache
+	CachePolicy  string             `json:"cachePolicy,omitempty"`
+	BatchIndex   int32              `json:"batchIndex,omitempty"`
+	DefaultValue *metapb.HTTPResult `json:"default_value,omitempty"`
+	WriteTimeout int64              `json:"writeTimeout,omitempty"`
+	ReadTimeout  int64              `json:"readTimeout,omitempty"`
 }
 
 type ManbaHTTPRouteCluster struct {
@@ -106,57 +166,71 @@ type ManbaHTTPRouteCluster struct {
 	Port   intstr.IntOrString `json:"port,omitempty"`
 }
 
+// ManbaHTTPRouteMatch matches parameters of request by their names
 type ManbaHTTPRouteMatch struct {
-	Cookie    map[string]string `json:"cookie"`
-	Query     map[string]string `json:"query"`
-	JSONBody  map[string]string `json:"jsonBody"`
-	Header    map[string]string `json:"header"`
-	PathValue map[string]string `json:"pathValue"`
-	FormData  map[string]string `json:"formData"`
+	Cookie    map[string]ManbaHTTPValueMatch `json:"cookie"`
+	Query     map[string]ManbaHTTPValueMatch `json:"query"`
+	JSONBody  map[string]ManbaHTTPValueMatch `json:"jsonBody"`
+	Header    map[string]ManbaHTTPValueMatch `json:"header"`
+	PathValue map[string]ManbaHTTPValueMatch `json:"pathValue"`
+	FormData  map[string]ManbaHTTPValueMatch `json:"formData"`
+}
+
+// Validate returns an error if a matcher is invalid
+func (v *ManbaHTTPRouteMatch) Validate() error {
+	if v == nil {
+		return nil
+	}
+	for _, validation := range v.ToManbaValidations() {
+		param := validation.Parameter
+		m := v.sources()[param.Source][param.Name]
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("%s %s: %v", param.Source, param.Name, err)
+		}
+	}
+	return nil
+}
+
+// sources returns matchers by the source of parameters
+func (v *ManbaHTTPRouteMatch) sources() map[metapb.Source]map[string]ManbaHTTPValueMatch {
+	return map[metapb.Source]map[string]ManbaHTTPValueMatch{
+		metapb.Cookie:      v.Cookie,
+		metapb.FormData:    v.FormData,
+		metapb.Header:      v.Header,
+		metapb.JSONBody:    v.JSONBody,
+		metapb.QueryString: v.Query,
+		metapb.PathValue:   v.PathValue,
+	}
 }
 
+// ToManbaValidations returns manba validations of matchers ordered by source and name
 func (v *ManbaHTTPRouteMatch) ToManbaValidations() []*metapb.Validation {
 	if v == nil {
 		return nil
 	}
 	var res []*metapb.Validation
-
-	parse := func(source metapb.Source, data map[string]string) []*metapb.Validation {
-		var res []*metapb.Validation
-		for k, v := range data {
-			res = append(res, &metapb.Validation{
-				Parameter: metapb.Parameter{
-					Name:   k,
-					Source: source,
-				},
-				Required: true,
-				Rules: []metapb.ValidationRule{{
-					RuleType:   metapb.RuleRegexp,
-					Expression: v,
-				}},
-			})
+	for source, data := range v.sources() {
+		for name, m := range data {
+			res = append(res, m.ToManbaValidation(metapb.Parameter{
+				Name:   name,
+				Source: source,
+			}))
 		}
-		return res
 	}
 
-	res = append(res, parse(metapb.Cookie, v.Cookie)...)
-	res = append(res, parse(metapb.FormData, v.FormData)...)
-	res = append(res, parse(metapb.Header, v.Header)...)
-	res = append(res, parse(metapb.JSONBody, v.JSONBody)...)
-	res = append(res, parse(metapb.QueryString, v.Query)...)
-	res = append(res, parse(metapb.PathValue, v.PathValue)...)
-
 	sort.Slice(res, func(i, j int) bool {
 		param1 := res[i].Parameter
 		param2 := res[j].Parameter
-		return param1.Source <= param2.Source && param1.Name < param2.Name
+		if param1.Source != param2.Source {
+			return param1.Source < param2.Source
+		}
+		return param1.Name < param2.Name
 	})
 
 	return res
 }
 
 // +genclient
-// +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // ManbaCluster is top level of manba cluster
@@ -166,7 +240,8 @@ type ManbaCluster struct {
 	// +optional
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ManbaClusterSpec `json:"spec,omitempty"`
+	Spec   ManbaClusterSpec   `json:"spec,omitempty"`
+	Status ManbaClusterStatus `json:"status,omitempty"`
 }
 
 // ManbaClusterList is a list of ManbaCluster
@@ -180,8 +255,85 @@ type ManbaClusterList struct {
 
 // ManbaClusterSpec details of ManbaCluster
 type ManbaClusterSpec struct {
-	TrafficPolicy *TrafficPolicy       `json:"trafficPolicy,omitempty"`
-	Subsets       []ManbaClusterSubSet `json:"subsets"`
+	TrafficPolicy *TrafficPolicy `json:"trafficPolicy,omitempty"`
+	// TrafficPolicyRef is the name of ManbaTrafficPolicy in the namespace, fields not set
+	// in traffic policy of cluster are inherited from it
+	TrafficPolicyRef string               `json:"trafficPolicyRef,omitempty"`
+	Subsets          []ManbaClusterSubSet `json:"subsets"`
+	// ActiveSubset is the subset which routes referencing "@active" send traffic to
+	ActiveSubset string `json:"activeSubset,omitempty"`
+	// PreviewSubset is the subset which routes referencing "@preview" send traffic to,
+	// requests of routes referencing "@active" carrying the preview header are sent to it as well
+	PreviewSubset string `json:"previewSubset,omitempty"`
+	// PreviewHeader selects preview requests, default is X-Manba-Preview: true
+	PreviewHeader *ManbaHeaderMatch `json:"previewHeader,omitempty"`
+}
+
+// ManbaHeaderMatch matches requests whose header equals value
+type ManbaHeaderMatch struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+const (
+	// ActiveSubsetRef references the active subset of a cluster
+	ActiveSubsetRef = "@active"
+	// PreviewSubsetRef references the preview subset of a cluster
+	PreviewSubsetRef = "@preview"
+)
+
+// ResolveSubset returns the name of subset referenced by ref,
+// false is returned if ref references an active or preview subset which is not set
+func (c *ManbaCluster) ResolveSubset(ref string) (string, bool) {
+	switch ref {
+	case ActiveSubsetRef:
+		return c.Spec.ActiveSubset, c.Spec.ActiveSubset != ""
+	case PreviewSubsetRef:
+		return c.Spec.PreviewSubset, c.Spec.PreviewSubset != ""
+	}
+	return ref, true
+}
+
+// GetPreviewHeader returns the preview header, defaults are used if it's not set
+func (s *ManbaClusterSpec) GetPreviewHeader() ManbaHeaderMatch {
+	header := ManbaHeaderMatch{
+		Name:  DefaultPreviewHeaderName,
+		Value: DefaultPreviewHeaderValue,
+	}
+	if s.PreviewHeader != nil {
+		header = *s.PreviewHeader
+	}
+	return header
+}
+
+// PromotionType is the type of a change of active subset
+type PromotionType string
+
+const (
+	// Promote makes a new subset active
+	Promote PromotionType = "Promote"
+	// Rollback makes the previous active subset active again
+	Rollback PromotionType = "Rollback"
+)
+
+// ManbaClusterPromotion records a change of active subset
+type ManbaClusterPromotion struct {
+	Type PromotionType `json:"type"`
+	From string        `json:"from,omitempty"`
+	To   string        `json:"to"`
+	Time metav1.Time   `json:"time"`
+}
+
+// ManbaClusterStatus is the observed state of ManbaCluster
+type ManbaClusterStatus struct {
+	ActiveSubset  string `json:"activeSubset,omitempty"`
+	PreviewSubset string `json:"previewSubset,omitempty"`
+	// History of active subset, the latest one is the last
+	History []ManbaClusterPromotion `json:"history,omitempty"`
+	// ActiveSchedules are the schedules of subsets in their windows
+	ActiveSchedules []ManbaActiveSchedule `json:"activeSchedules,omitempty"`
+	// TrafficPolicies are the effective traffic policies of subsets, schedules are not applied
+	TrafficPolicies []ManbaSubsetTrafficPolicy `json:"trafficPolicies,omitempty"`
 }
 
 // ManbaClusterSubSet represents service in k8s
@@ -191,13 +343,162 @@ type ManbaClusterSubSet struct {
 	// Labels used to list service by labels
 	Labels map[string]string `json:"labels,omitempty"`
 	// TrafficPolicy for cluster, if cluster has 5 servers,
-	// single server's maxQPS is trafficPolicy.MaxQPS/5
+	// single server's maxQPS is trafficPolicy.MaxQPS/5,
+	// fields not set are inherited from traffic policy of cluster
 	TrafficPolicy *TrafficPolicy `json:"trafficPolicy,omitempty"`
+	// Schedules override the traffic policy of the subset during their windows, the first one in its window is used
+	Schedules []ManbaSchedule `json:"schedules,omitempty"`
 }
 
+// TrafficPolicy limits traffic to servers, a zero maxQPS means it's not set
 type TrafficPolicy struct {
 	LoadBalancer    *string                `json:"loadBalancer,omitempty"`
 	MaxQPS          uint64                 `json:"maxQPS"`
 	CircuitBreaker  *metapb.CircuitBreaker `json:"circuitBreaker,omitempty"`
 	RateLimitOption *string                `json:"rateLimitOption,omitempty"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ManbaCanary shifts traffic of a ManbaIngress to a subset step by step,
+// and rolls back when the subset is unhealthy
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManbaCanary struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManbaCanarySpec   `json:"spec,omitempty"`
+	Status ManbaCanaryStatus `json:"status,omitempty"`
+}
+
+// ManbaCanaryList is a list of ManbaCanary
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManbaCanaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManbaCanary `json:"items,omitempty"`
+}
+
+// ManbaCanarySpec details of ManbaCanary
+type ManbaCanarySpec struct {
+	// Ingress is the name of ManbaIngress in the same namespace
+	Ingress string `json:"ingress"`
+	// Target is the split whose rate is stepped, it must be a route of the ingress
+	Target ManbaHTTPRouteCluster `json:"target"`
+	// Steps are the rates of target, like [5, 25, 50, 100]
+	Steps []int32 `json:"steps"`
+	// Interval is how long a step lasts before it's checked and promoted, default is 1m
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Analysis gates every step
+	Analysis ManbaCanaryAnalysis `json:"analysis,omitempty"`
+}
+
+// ManbaCanaryAnalysis checks metrics of target before promoting a step
+type ManbaCanaryAnalysis struct {
+	// MaxErrorRate is the max ratio of failed requests, like 0.01
+	MaxErrorRate *float64 `json:"maxErrorRate,omitempty"`
+	// MaxLatency is the max latency of requests
+	MaxLatency *metav1.Duration `json:"maxLatency,omitempty"`
+	// Provider gives metrics of target, only one of them can be set
+	Provider ManbaCanaryProvider `json:"provider,omitempty"`
+}
+
+// ManbaCanaryProvider is the source of metrics
+type ManbaCanaryProvider struct {
+	Prometheus *PrometheusProvider `json:"prometheus,omitempty"`
+	Static     *StaticProvider     `json:"static,omitempty"`
+}
+
+// PrometheusProvider queries metrics from prometheus,
+// queries are go templates with .Namespace, .Name, .Ingress, .Cluster and .Subset
+type PrometheusProvider struct {
+	// Address of prometheus, flag --canary-prometheus-address is used if it's empty
+	Address string `json:"address,omitempty"`
+	// ErrorRateQuery returns the ratio of failed requests
+	ErrorRateQuery string `json:"errorRateQuery,omitempty"`
+	// LatencyQuery returns the latency in seconds
+	LatencyQuery string `json:"latencyQuery,omitempty"`
+}
+
+// StaticProvider always returns the given metrics
+type StaticProvider struct {
+	ErrorRate float64         `json:"errorRate"`
+	Latency   metav1.Duration `json:"latency"`
+}
+
+// ManbaCanaryPhase is the phase of a canary
+type ManbaCanaryPhase string
+
+const (
+	// CanaryProgressing the rate of target is being stepped
+	CanaryProgressing ManbaCanaryPhase = "Progressing"
+	// CanarySucceeded all steps are passed
+	CanarySucceeded ManbaCanaryPhase = "Succeeded"
+	// CanaryRolledBack a check failed and the rate of target is 0
+	CanaryRolledBack ManbaCanaryPhase = "RolledBack"
+)
+
+// ManbaCanaryStatus is the state of rollout
+type ManbaCanaryStatus struct {
+	Phase ManbaCanaryPhase `json:"phase,omitempty"`
+	// Step is the index of current step
+	Step int32 `json:"step"`
+	// Rate is the current rate of target
+	Rate int32 `json:"rate"`
+	// LastStepTime is when current step starts
+	LastStepTime metav1.Time `json:"lastStepTime,omitempty"`
+	// ObservedGeneration is the generation of spec the rollout runs for,
+	// rollout restarts when spec changes
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	Message            string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ManbaCachePolicy caches responses of routes and aggregate calls which reference it by name
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManbaCachePolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ManbaCachePolicySpec `json:"spec,omitempty"`
+}
+
+// ManbaCachePolicyList is a list of ManbaCachePolicy
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManbaCachePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManbaCachePolicy `json:"items,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ManbaTrafficPolicy is a traffic policy shared by manba clusters which reference it by name,
+// the one named default is inherited by all manba clusters in its namespace
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManbaTrafficPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TrafficPolicy `json:"spec,omitempty"`
+}
+
+// ManbaTrafficPolicyList is a list of ManbaTrafficPolicy
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ManbaTrafficPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManbaTrafficPolicy `json:"items,omitempty"`
+}
@@ -2,7 +2,9 @@ package v1beta1
 
 import (
 	"encoding/json"
+
 	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto ...
@@ -35,8 +37,21 @@ func (in *ManbaIngress) DeepCopyInto(out *ManbaIngress) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	inSpec, outSpec := in.Spec, out.Spec
-	deepcopy(&inSpec, &outSpec)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = ManbaIngressStatus{}
+	deepcopy(&in.Status, &out.Status)
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaSchedule) DeepCopyInto(out *ManbaSchedule) {
+	*out = ManbaSchedule{}
+	deepcopy(in, out)
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaMaintenance) DeepCopyInto(out *ManbaMaintenance) {
+	*out = ManbaMaintenance{}
+	deepcopy(in, out)
 }
 
 func deepcopy(in, out interface{}) {
@@ -49,3 +64,178 @@ func deepcopy(in, out interface{}) {
 		glog.Errorf("unexpected error copying configuration: %v", err)
 	}
 }
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaCanary) DeepCopyInto(out *ManbaCanary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = ManbaCanarySpec{}
+	deepcopy(&in.Spec, &out.Spec)
+	in.Status.LastStepTime.DeepCopyInto(&out.Status.LastStepTime)
+}
+
+// DeepCopy deepcopy function, copying the receiver, creating a new ManbaCanary.
+func (in *ManbaCanary) DeepCopy() *ManbaCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManbaCanary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaCanaryList) DeepCopyInto(out *ManbaCanaryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ManbaCanary, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy deepcopy function, copying the receiver, creating a new ManbaCanaryList.
+func (in *ManbaCanaryList) DeepCopy() *ManbaCanaryList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaCanaryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManbaCanaryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaCachePolicy) DeepCopyInto(out *ManbaCachePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = ManbaCachePolicySpec{}
+	deepcopy(&in.Spec, &out.Spec)
+}
+
+// DeepCopy deepcopy function, copying the receiver, creating a new ManbaCachePolicy.
+func (in *ManbaCachePolicy) DeepCopy() *ManbaCachePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaCachePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManbaCachePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaCachePolicyList) DeepCopyInto(out *ManbaCachePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ManbaCachePolicy, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy deepcopy function, copying the receiver, creating a new ManbaCachePolicyList.
+func (in *ManbaCachePolicyList) DeepCopy() *ManbaCachePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaCachePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManbaCachePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaTrafficPolicy) DeepCopyInto(out *ManbaTrafficPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = TrafficPolicy{}
+	deepcopy(&in.Spec, &out.Spec)
+}
+
+// DeepCopy deepcopy function, copying the receiver, creating a new ManbaTrafficPolicy.
+func (in *ManbaTrafficPolicy) DeepCopy() *ManbaTrafficPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaTrafficPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManbaTrafficPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaTrafficPolicyList) DeepCopyInto(out *ManbaTrafficPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ManbaTrafficPolicy, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy deepcopy function, copying the receiver, creating a new ManbaTrafficPolicyList.
+func (in *ManbaTrafficPolicyList) DeepCopy() *ManbaTrafficPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaTrafficPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ManbaTrafficPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
@@ -30,6 +30,7 @@ func (in *ManbaCluster) DeepCopyInto(out *ManbaCluster) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -98,6 +99,11 @@ func (in *ManbaClusterSpec) DeepCopyInto(out *ManbaClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PreviewHeader != nil {
+		in, out := &in.PreviewHeader, &out.PreviewHeader
+		*out = new(ManbaHeaderMatch)
+		**out = **in
+	}
 	return
 }
 
@@ -111,6 +117,94 @@ func (in *ManbaClusterSpec) DeepCopy() *ManbaClusterSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaActiveSchedule) DeepCopyInto(out *ManbaActiveSchedule) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManbaActiveSchedule.
+func (in *ManbaActiveSchedule) DeepCopy() *ManbaActiveSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaActiveSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaClusterPromotion) DeepCopyInto(out *ManbaClusterPromotion) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManbaClusterPromotion.
+func (in *ManbaClusterPromotion) DeepCopy() *ManbaClusterPromotion {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaClusterPromotion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaClusterStatus) DeepCopyInto(out *ManbaClusterStatus) {
+	*out = *in
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]ManbaClusterPromotion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ActiveSchedules != nil {
+		in, out := &in.ActiveSchedules, &out.ActiveSchedules
+		*out = make([]ManbaActiveSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TrafficPolicies != nil {
+		in, out := &in.TrafficPolicies, &out.TrafficPolicies
+		*out = make([]ManbaSubsetTrafficPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManbaClusterStatus.
+func (in *ManbaClusterStatus) DeepCopy() *ManbaClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManbaSubsetTrafficPolicy) DeepCopyInto(out *ManbaSubsetTrafficPolicy) {
+	*out = *in
+	in.Policy.DeepCopyInto(&out.Policy)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManbaSubsetTrafficPolicy.
+func (in *ManbaSubsetTrafficPolicy) DeepCopy() *ManbaSubsetTrafficPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ManbaSubsetTrafficPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManbaClusterSubSet) DeepCopyInto(out *ManbaClusterSubSet) {
 	*out = *in
@@ -125,6 +219,13 @@ func (in *ManbaClusterSubSet) DeepCopyInto(out *ManbaClusterSubSet) {
 		in, out := &in.TrafficPolicy, &out.TrafficPolicy
 		*out = (*in).DeepCopy()
 	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]ManbaSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -312,6 +413,11 @@ func (in *ManbaIngressSpec) DeepCopyInto(out *ManbaIngressSpec) {
 		}
 	}
 	in.TLS.DeepCopyInto(&out.TLS)
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(ManbaMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -49,6 +49,12 @@ func CreateInformers(k8sCli kubernetes.Interface, cfg *rest.Config, syncPeriod t
 	secretInformer.AddEventHandler(reh)
 	informers = append(informers, secretInformer)
 
+	configMapInformer := factory.Core().V1().ConfigMaps().Informer()
+	configMapInformer.AddEventHandler(controller.ConfigMapEventHandler{
+		UpdateCh: updateChannel,
+	})
+	informers = append(informers, configMapInformer)
+
 	manbaIngInformer := manbaFactory.Configuration().V1beta1().ManbaIngresses().Informer()
 	manbaIngInformer.AddEventHandler(reh)
 	informers = append(informers, manbaIngInformer)
@@ -57,5 +63,17 @@ func CreateInformers(k8sCli kubernetes.Interface, cfg *rest.Config, syncPeriod t
 	manbaClusterInformer.AddEventHandler(reh)
 	informers = append(informers, manbaClusterInformer)
 
+	manbaCanaryInformer := manbaFactory.Configuration().V1beta1().ManbaCanaries().Informer()
+	manbaCanaryInformer.AddEventHandler(reh)
+	informers = append(informers, manbaCanaryInformer)
+
+	manbaCachePolicyInformer := manbaFactory.Configuration().V1beta1().ManbaCachePolicies().Informer()
+	manbaCachePolicyInformer.AddEventHandler(reh)
+	informers = append(informers, manbaCachePolicyInformer)
+
+	manbaTrafficPolicyInformer := manbaFactory.Configuration().V1beta1().ManbaTrafficPolicies().Informer()
+	manbaTrafficPolicyInformer.AddEventHandler(reh)
+	informers = append(informers, manbaTrafficPolicyInformer)
+
 	return informers, factory, manbaFactory
 }
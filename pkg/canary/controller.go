@@ -0,0 +1,201 @@
+package canary
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultInterval is the duration of a step if interval of canary is not set
+const DefaultInterval = time.Minute
+
+// Controller steps rates of canaries and writes them to status,
+// the parser applies the rates to splits of ingresses
+type Controller struct {
+	client   versioned.Interface
+	store    store.Store
+	isLeader func() bool
+	// prometheusAddress is used by prometheus providers without address
+	prometheusAddress string
+
+	now         func() time.Time
+	newProvider func(canary *configurationv1beta1.ManbaCanary, defaultAddress string) (Provider, error)
+}
+
+// New returns a canary controller, only the leader updates canaries
+func New(client versioned.Interface, s store.Store, isLeader func() bool, prometheusAddress string) *Controller {
+	return &Controller{
+		client:            client,
+		store:             s,
+		isLeader:          isLeader,
+		prometheusAddress: prometheusAddress,
+		now:               time.Now,
+		newProvider:       NewProvider,
+	}
+}
+
+// Run checks canaries every period until stopCh is closed
+func (c *Controller) Run(period time.Duration, stopCh <-chan struct{}) {
+	wait.Until(c.syncAll, period, stopCh)
+}
+
+func (c *Controller) syncAll() {
+	if !c.isLeader() {
+		return
+	}
+	for _, canary := range c.store.ListManbaCanaries() {
+		if err := c.sync(canary); err != nil {
+			glog.Errorf("syncing canary %s/%s: %v", canary.Namespace, canary.Name, err)
+		}
+	}
+}
+
+func (c *Controller) sync(canary *configurationv1beta1.ManbaCanary) error {
+	status := c.reconcile(canary)
+	if reflect.DeepEqual(status, canary.Status) {
+		return nil
+	}
+	if status.Phase == configurationv1beta1.CanaryRolledBack && canary.Status.Phase != status.Phase {
+		glog.Warningf("canary %s/%s is rolled back: %s", canary.Namespace, canary.Name, status.Message)
+	}
+
+	updated := canary.DeepCopy()
+	updated.Status = status
+	_, err := c.client.ConfigurationV1beta1().ManbaCanaries(canary.Namespace).UpdateStatus(updated)
+	return errors.Wrap(err, "updating status")
+}
+
+// reconcile returns the next status of canary
+func (c *Controller) reconcile(canary *configurationv1beta1.ManbaCanary) configurationv1beta1.ManbaCanaryStatus {
+	spec := canary.Spec
+	status := canary.Status
+	now := metav1.NewTime(c.now())
+
+	if err := Validate(&spec); err != nil {
+		status.Message = fmt.Sprintf("invalid spec: %v", err)
+		return status
+	}
+
+	// rollout restarts from the first step when spec changes
+	if status.Phase == "" || status.ObservedGeneration != canary.Generation {
+		return configurationv1beta1.ManbaCanaryStatus{
+			Phase:              configurationv1beta1.CanaryProgressing,
+			Step:               0,
+			Rate:               spec.Steps[0],
+			LastStepTime:       now,
+			ObservedGeneration: canary.Generation,
+			Message:            fmt.Sprintf("step 0: rate is %d", spec.Steps[0]),
+		}
+	}
+	if status.Phase != configurationv1beta1.CanaryProgressing {
+		return status
+	}
+	if now.Sub(status.LastStepTime.Time) < interval(&spec) {
+		return status
+	}
+
+	if reason, err := c.analyze(canary); err != nil {
+		// metrics are unknown, the step is held until they are available
+		status.Message = fmt.Sprintf("step %d is held: %v", status.Step, err)
+		return status
+	} else if reason != "" {
+		status.Phase = configurationv1beta1.CanaryRolledBack
+		status.Rate = 0
+		status.LastStepTime = now
+		status.Message = fmt.Sprintf("rolled back at step %d: %s", status.Step, reason)
+		return status
+	}
+
+	status.LastStepTime = now
+	if int(status.Step) == len(spec.Steps)-1 {
+		status.Phase = configurationv1beta1.CanarySucceeded
+		status.Message = fmt.Sprintf("all steps are passed, rate is %d", status.Rate)
+		return status
+	}
+	status.Step++
+	status.Rate = spec.Steps[status.Step]
+	status.Message = fmt.Sprintf("step %d: rate is %d", status.Step, status.Rate)
+	return status
+}
+
+// analyze returns why the target of canary fails the checks,
+// it's empty if the target passes them
+func (c *Controller) analyze(canary *configurationv1beta1.ManbaCanary) (string, error) {
+	analysis := canary.Spec.Analysis
+	if analysis.MaxErrorRate == nil && analysis.MaxLatency == nil {
+		return "", nil
+	}
+	provider, err := c.newProvider(canary, c.prometheusAddress)
+	if err != nil {
+		return "", err
+	}
+	metrics, err := provider.Query(canary)
+	if err != nil {
+		return "", err
+	}
+
+	if analysis.MaxErrorRate != nil && metrics.ErrorRate > *analysis.MaxErrorRate {
+		return fmt.Sprintf("error rate %g exceeds %g", metrics.ErrorRate, *analysis.MaxErrorRate), nil
+	}
+	if analysis.MaxLatency != nil && metrics.Latency > analysis.MaxLatency.Duration {
+		return fmt.Sprintf("latency %v exceeds %v", metrics.Latency, analysis.MaxLatency.Duration), nil
+	}
+	return "", nil
+}
+
+func interval(spec *configurationv1beta1.ManbaCanarySpec) time.Duration {
+	if spec.Interval == nil {
+		return DefaultInterval
+	}
+	return spec.Interval.Duration
+}
+
+// Validate checks spec of canary
+func Validate(spec *configurationv1beta1.ManbaCanarySpec) error {
+	if spec.Ingress == "" {
+		return errors.New("ingress is required")
+	}
+	if spec.Target.Name == "" {
+		return errors.New("target.name is required")
+	}
+	if len(spec.Steps) == 0 {
+		return errors.New("steps are required")
+	}
+	for i, step := range spec.Steps {
+		if step < 1 || step > 100 {
+			return errors.Errorf("steps[%d]: rate %d is out of range [1, 100]", i, step)
+		}
+		if i > 0 && step < spec.Steps[i-1] {
+			return errors.Errorf("steps[%d]: rate %d is less than the previous step", i, step)
+		}
+	}
+	if spec.Interval != nil && spec.Interval.Duration <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	analysis := spec.Analysis
+	if analysis.MaxErrorRate != nil && (*analysis.MaxErrorRate < 0 || *analysis.MaxErrorRate > 1) {
+		return errors.Errorf("analysis.maxErrorRate %g is out of range [0, 1]", *analysis.MaxErrorRate)
+	}
+	if (analysis.MaxErrorRate != nil || analysis.MaxLatency != nil) &&
+		analysis.Provider.Prometheus == nil && analysis.Provider.Static == nil {
+		return errors.New("analysis.provider is required by checks")
+	}
+	if prom := analysis.Provider.Prometheus; prom != nil {
+		if analysis.MaxErrorRate != nil && prom.ErrorRateQuery == "" {
+			return errors.New("analysis.provider.prometheus.errorRateQuery is required by maxErrorRate")
+		}
+		if analysis.MaxLatency != nil && prom.LatencyQuery == "" {
+			return errors.New("analysis.provider.prometheus.latencyQuery is required by maxLatency")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package canary
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/fake"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newCanary(errorRate float64) *configurationv1beta1.ManbaCanary {
+	maxErrorRate := 0.01
+	return &configurationv1beta1.ManbaCanary{
+		ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default", Generation: 1},
+		Spec: configurationv1beta1.ManbaCanarySpec{
+			Ingress:  "ing",
+			Target:   configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v2", Port: intstr.FromInt(80)},
+			Steps:    []int32{5, 50, 100},
+			Interval: &metav1.Duration{Duration: time.Minute},
+			Analysis: configurationv1beta1.ManbaCanaryAnalysis{
+				MaxErrorRate: &maxErrorRate,
+				MaxLatency:   &metav1.Duration{Duration: time.Second},
+				Provider: configurationv1beta1.ManbaCanaryProvider{
+					Static: &configurationv1beta1.StaticProvider{ErrorRate: errorRate},
+				},
+			},
+		},
+	}
+}
+
+func TestController_Reconcile(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &Controller{
+		now:         func() time.Time { return now },
+		newProvider: NewProvider,
+	}
+
+	canary := newCanary(0)
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, configurationv1beta1.CanaryProgressing, canary.Status.Phase)
+	assert.Equal(t, int32(5), canary.Status.Rate)
+	assert.Equal(t, int64(1), canary.Status.ObservedGeneration)
+
+	// interval is not passed
+	now = now.Add(30 * time.Second)
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, int32(0), canary.Status.Step)
+
+	for _, rate := range []int32{50, 100} {
+		now = now.Add(time.Minute)
+		canary.Status = c.reconcile(canary)
+		assert.Equal(t, configurationv1beta1.CanaryProgressing, canary.Status.Phase)
+		assert.Equal(t, rate, canary.Status.Rate)
+	}
+	now = now.Add(time.Minute)
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, configurationv1beta1.CanarySucceeded, canary.Status.Phase)
+	assert.Equal(t, int32(100), canary.Status.Rate)
+
+	// spec changes restart the rollout
+	canary.Generation = 2
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, configurationv1beta1.CanaryProgressing, canary.Status.Phase)
+	assert.Equal(t, int32(5), canary.Status.Rate)
+}
+
+func TestController_ReconcileRollback(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &Controller{
+		now:         func() time.Time { return now },
+		newProvider: NewProvider,
+	}
+
+	canary := newCanary(0.5)
+	canary.Status = c.reconcile(canary)
+	now = now.Add(time.Minute)
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, configurationv1beta1.CanaryRolledBack, canary.Status.Phase)
+	assert.Equal(t, int32(0), canary.Status.Rate)
+	assert.Contains(t, canary.Status.Message, "error rate 0.5 exceeds 0.01")
+
+	// rolled back canaries stay rolled back
+	now = now.Add(time.Minute)
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, configurationv1beta1.CanaryRolledBack, canary.Status.Phase)
+
+	// latency
+	canary = newCanary(0)
+	canary.Spec.Analysis.Provider.Static.Latency = metav1.Duration{Duration: 2 * time.Second}
+	canary.Status = c.reconcile(canary)
+	now = now.Add(time.Minute)
+	canary.Status = c.reconcile(canary)
+	assert.Equal(t, configurationv1beta1.CanaryRolledBack, canary.Status.Phase)
+	assert.Contains(t, canary.Status.Message, "latency 2s exceeds 1s")
+}
+
+func TestController_Sync(t *testing.T) {
+	canary := newCanary(0)
+	client := fake.NewSimpleClientset(canary)
+	s, err := store.NewFakeStore(nil, []runtime.Object{canary})
+	assert.Nil(t, err)
+	c := New(client, s, func() bool { return true }, "")
+
+	c.syncAll()
+	got, err := client.ConfigurationV1beta1().ManbaCanaries("default").Get("canary", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, configurationv1beta1.CanaryProgressing, got.Status.Phase)
+	assert.Equal(t, int32(5), got.Status.Rate)
+}
+
+func TestPrometheusProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		switch r.URL.Query().Get("query") {
+		case `errors{cluster="default.cls.v2.80.svc"}`:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"0.02"]}]}}`)
+		case `latency{subset="v2"}`:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1,"0.25"]}}`)
+		default:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		}
+	}))
+	defer server.Close()
+
+	canary := newCanary(0)
+	canary.Spec.Analysis.Provider = configurationv1beta1.ManbaCanaryProvider{
+		Prometheus: &configurationv1beta1.PrometheusProvider{
+			ErrorRateQuery: `errors{cluster="{{.Cluster}}"}`,
+			LatencyQuery:   `latency{subset="{{.Subset}}"}`,
+		},
+	}
+	provider, err := NewProvider(canary, server.URL)
+	assert.Nil(t, err)
+	metrics, err := provider.Query(canary)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.02, metrics.ErrorRate)
+	assert.Equal(t, 250*time.Millisecond, metrics.Latency)
+
+	// no data
+	canary.Spec.Analysis.Provider.Prometheus.LatencyQuery = "unknown"
+	provider, err = NewProvider(canary, server.URL)
+	assert.Nil(t, err)
+	_, err = provider.Query(canary)
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	canary := newCanary(0)
+	assert.Nil(t, Validate(&canary.Spec))
+
+	canary.Spec.Steps = []int32{50, 5}
+	assert.NotNil(t, Validate(&canary.Spec))
+
+	canary = newCanary(0)
+	canary.Spec.Steps = []int32{0}
+	assert.NotNil(t, Validate(&canary.Spec))
+
+	canary = newCanary(0)
+	canary.Spec.Analysis.Provider = configurationv1beta1.ManbaCanaryProvider{}
+	assert.NotNil(t, Validate(&canary.Spec))
+}
@@ -0,0 +1,190 @@
+package canary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// Metrics of the target of a canary
+type Metrics struct {
+	// ErrorRate is the ratio of failed requests
+	ErrorRate float64
+	Latency   time.Duration
+}
+
+// Provider queries metrics of the target of a canary
+type Provider interface {
+	Query(canary *configurationv1beta1.ManbaCanary) (Metrics, error)
+}
+
+// staticProvider always returns the metrics in spec, it's used in tests
+type staticProvider struct {
+	metrics Metrics
+}
+
+func (s *staticProvider) Query(*configurationv1beta1.ManbaCanary) (Metrics, error) {
+	return s.metrics, nil
+}
+
+// prometheusProvider runs instant queries against prometheus http api
+type prometheusProvider struct {
+	address string
+	spec    configurationv1beta1.PrometheusProvider
+	client  *http.Client
+}
+
+// queryData is passed to templates of prometheus queries
+type queryData struct {
+	Namespace string
+	Name      string
+	Ingress   string
+	// Cluster is the name of manba cluster generated for target, like default.cls.v2.80.svc
+	Cluster string
+	Subset  string
+}
+
+func (p *prometheusProvider) Query(canary *configurationv1beta1.ManbaCanary) (Metrics, error) {
+	var metrics Metrics
+	target := canary.Spec.Target
+	data := queryData{
+		Namespace: canary.Namespace,
+		Name:      canary.Name,
+		Ingress:   canary.Spec.Ingress,
+		Cluster:   fmt.Sprintf("%s.%s.%s.%s.svc", canary.Namespace, target.Name, target.Subset, target.Port.String()),
+		Subset:    target.Subset,
+	}
+
+	if p.spec.ErrorRateQuery != "" {
+		v, err := p.query(p.spec.ErrorRateQuery, data)
+		if err != nil {
+			return metrics, errors.Wrap(err, "querying error rate")
+		}
+		metrics.ErrorRate = v
+	}
+	if p.spec.LatencyQuery != "" {
+		v, err := p.query(p.spec.LatencyQuery, data)
+		if err != nil {
+			return metrics, errors.Wrap(err, "querying latency")
+		}
+		metrics.Latency = time.Duration(v * float64(time.Second))
+	}
+	return metrics, nil
+}
+
+// prometheusResponse is the response of /api/v1/query
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// query returns the value of a query returning a scalar or a single element vector
+func (p *prometheusProvider) query(text string, data queryData) (float64, error) {
+	tmpl, err := template.New("query").Parse(text)
+	if err != nil {
+		return 0, errors.Wrap(err, "parsing query")
+	}
+	var query bytes.Buffer
+	if err := tmpl.Execute(&query, data); err != nil {
+		return 0, errors.Wrap(err, "rendering query")
+	}
+
+	u := strings.TrimSuffix(p.address, "/") + "/api/v1/query?" + url.Values{"query": {query.String()}}.Encode()
+	resp, err := p.client.Get(u)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var res prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return 0, errors.Wrapf(err, "decoding response of status %d", resp.StatusCode)
+	}
+	if res.Status != "success" {
+		return 0, errors.Errorf("prometheus returns %s: %s", res.Status, res.Error)
+	}
+
+	var sample []interface{}
+	switch res.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(res.Data.Result, &sample); err != nil {
+			return 0, errors.Wrap(err, "decoding scalar")
+		}
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(res.Data.Result, &vector); err != nil {
+			return 0, errors.Wrap(err, "decoding vector")
+		}
+		if len(vector) == 0 {
+			return 0, errors.New("no data")
+		}
+		if len(vector) > 1 {
+			return 0, errors.Errorf("query returns %d series, expecting 1", len(vector))
+		}
+		sample = vector[0].Value
+	default:
+		return 0, errors.Errorf("unsupported result type %q", res.Data.ResultType)
+	}
+
+	if len(sample) != 2 {
+		return 0, errors.Errorf("invalid sample %v", sample)
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, errors.Errorf("invalid sample value %v", sample[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "parsing sample value")
+	}
+	// rates of targets without traffic are NaN
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("no data")
+	}
+	return v, nil
+}
+
+// NewProvider returns the provider configured in the analysis of canary,
+// defaultAddress is used by prometheus provider without address
+func NewProvider(canary *configurationv1beta1.ManbaCanary, defaultAddress string) (Provider, error) {
+	provider := canary.Spec.Analysis.Provider
+	switch {
+	case provider.Static != nil && provider.Prometheus != nil:
+		return nil, errors.New("only one provider can be set")
+	case provider.Static != nil:
+		return &staticProvider{metrics: Metrics{
+			ErrorRate: provider.Static.ErrorRate,
+			Latency:   provider.Static.Latency.Duration,
+		}}, nil
+	case provider.Prometheus != nil:
+		address := provider.Prometheus.Address
+		if address == "" {
+			address = defaultAddress
+		}
+		if address == "" {
+			return nil, errors.New("address of prometheus is not set")
+		}
+		return &prometheusProvider{
+			address: address,
+			spec:    *provider.Prometheus,
+			client:  &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	}
+	return nil, errors.New("no provider is set")
+}
@@ -26,8 +26,11 @@ import (
 
 type ConfigurationV1beta1Interface interface {
 	RESTClient() rest.Interface
+	ManbaCachePoliciesGetter
+	ManbaCanariesGetter
 	ManbaClustersGetter
 	ManbaIngressesGetter
+	ManbaTrafficPoliciesGetter
 }
 
 // ConfigurationV1beta1Client is used to interact with features provided by the configuration.manba.io group.
@@ -35,6 +38,14 @@ type ConfigurationV1beta1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ConfigurationV1beta1Client) ManbaCachePolicies(namespace string) ManbaCachePolicyInterface {
+	return newManbaCachePolicies(c, namespace)
+}
+
+func (c *ConfigurationV1beta1Client) ManbaCanaries(namespace string) ManbaCanaryInterface {
+	return newManbaCanaries(c, namespace)
+}
+
 func (c *ConfigurationV1beta1Client) ManbaClusters(namespace string) ManbaClusterInterface {
 	return newManbaClusters(c, namespace)
 }
@@ -43,6 +54,10 @@ func (c *ConfigurationV1beta1Client) ManbaIngresses(namespace string) ManbaIngre
 	return newManbaIngresses(c, namespace)
 }
 
+func (c *ConfigurationV1beta1Client) ManbaTrafficPolicies(namespace string) ManbaTrafficPolicyInterface {
+	return newManbaTrafficPolicies(c, namespace)
+}
+
 // NewForConfig creates a new ConfigurationV1beta1Client for the given config.
 func NewForConfig(c *rest.Config) (*ConfigurationV1beta1Client, error) {
 	config := *c
@@ -28,6 +28,14 @@ type FakeConfigurationV1beta1 struct {
 	*testing.Fake
 }
 
+func (c *FakeConfigurationV1beta1) ManbaCachePolicies(namespace string) v1beta1.ManbaCachePolicyInterface {
+	return &FakeManbaCachePolicies{c, namespace}
+}
+
+func (c *FakeConfigurationV1beta1) ManbaCanaries(namespace string) v1beta1.ManbaCanaryInterface {
+	return &FakeManbaCanaries{c, namespace}
+}
+
 func (c *FakeConfigurationV1beta1) ManbaClusters(namespace string) v1beta1.ManbaClusterInterface {
 	return &FakeManbaClusters{c, namespace}
 }
@@ -36,6 +44,10 @@ func (c *FakeConfigurationV1beta1) ManbaIngresses(namespace string) v1beta1.Manb
 	return &FakeManbaIngresses{c, namespace}
 }
 
+func (c *FakeConfigurationV1beta1) ManbaTrafficPolicies(namespace string) v1beta1.ManbaTrafficPolicyInterface {
+	return &FakeManbaTrafficPolicies{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeConfigurationV1beta1) RESTClient() rest.Interface {
@@ -100,6 +100,18 @@ func (c *FakeManbaClusters) Update(manbaCluster *v1beta1.ManbaCluster) (result *
 	return obj.(*v1beta1.ManbaCluster), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeManbaClusters) UpdateStatus(manbaCluster *v1beta1.ManbaCluster) (*v1beta1.ManbaCluster, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(manbaclustersResource, "status", c.ns, manbaCluster), &v1beta1.ManbaCluster{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.ManbaCluster), err
+}
+
 // Delete takes name of the manbaCluster and deletes it. Returns an error if one occurs.
 func (c *FakeManbaClusters) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
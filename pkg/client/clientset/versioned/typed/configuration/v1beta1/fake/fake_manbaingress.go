@@ -100,6 +100,18 @@ func (c *FakeManbaIngresses) Update(manbaIngress *v1beta1.ManbaIngress) (result
 	return obj.(*v1beta1.ManbaIngress), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeManbaIngresses) UpdateStatus(manbaIngress *v1beta1.ManbaIngress) (*v1beta1.ManbaIngress, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(manbaingressesResource, "status", c.ns, manbaIngress), &v1beta1.ManbaIngress{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.ManbaIngress), err
+}
+
 // Delete takes name of the manbaIngress and deletes it. Returns an error if one occurs.
 func (c *FakeManbaIngresses) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
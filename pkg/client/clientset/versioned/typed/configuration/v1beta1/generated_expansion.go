@@ -18,6 +18,12 @@ limitations under the License.
 
 package v1beta1
 
+type ManbaCachePolicyExpansion interface{}
+
+type ManbaCanaryExpansion interface{}
+
 type ManbaClusterExpansion interface{}
 
 type ManbaIngressExpansion interface{}
+
+type ManbaTrafficPolicyExpansion interface{}
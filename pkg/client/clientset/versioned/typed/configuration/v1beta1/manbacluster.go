@@ -39,6 +39,7 @@ type ManbaClustersGetter interface {
 type ManbaClusterInterface interface {
 	Create(*v1beta1.ManbaCluster) (*v1beta1.ManbaCluster, error)
 	Update(*v1beta1.ManbaCluster) (*v1beta1.ManbaCluster, error)
+	UpdateStatus(*v1beta1.ManbaCluster) (*v1beta1.ManbaCluster, error)
 	Delete(name string, options *v1.DeleteOptions) error
 	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
 	Get(name string, options v1.GetOptions) (*v1beta1.ManbaCluster, error)
@@ -132,6 +133,22 @@ func (c *manbaClusters) Update(manbaCluster *v1beta1.ManbaCluster) (result *v1be
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *manbaClusters) UpdateStatus(manbaCluster *v1beta1.ManbaCluster) (result *v1beta1.ManbaCluster, err error) {
+	result = &v1beta1.ManbaCluster{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("manbaclusters").
+		Name(manbaCluster.Name).
+		SubResource("status").
+		Body(manbaCluster).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the manbaCluster and deletes it. Returns an error if one occurs.
 func (c *manbaClusters) Delete(name string, options *v1.DeleteOptions) error {
 	return c.client.Delete().
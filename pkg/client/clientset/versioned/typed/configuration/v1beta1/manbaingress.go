@@ -39,6 +39,7 @@ type ManbaIngressesGetter interface {
 type ManbaIngressInterface interface {
 	Create(*v1beta1.ManbaIngress) (*v1beta1.ManbaIngress, error)
 	Update(*v1beta1.ManbaIngress) (*v1beta1.ManbaIngress, error)
+	UpdateStatus(*v1beta1.ManbaIngress) (*v1beta1.ManbaIngress, error)
 	Delete(name string, options *v1.DeleteOptions) error
 	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
 	Get(name string, options v1.GetOptions) (*v1beta1.ManbaIngress, error)
@@ -132,6 +133,22 @@ func (c *manbaIngresses) Update(manbaIngress *v1beta1.ManbaIngress) (result *v1b
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *manbaIngresses) UpdateStatus(manbaIngress *v1beta1.ManbaIngress) (result *v1beta1.ManbaIngress, err error) {
+	result = &v1beta1.ManbaIngress{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("manbaingresses").
+		Name(manbaIngress.Name).
+		SubResource("status").
+		Body(manbaIngress).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the manbaIngress and deletes it. Returns an error if one occurs.
 func (c *manbaIngresses) Delete(name string, options *v1.DeleteOptions) error {
 	return c.client.Delete().
@@ -24,10 +24,16 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ManbaCachePolicies returns a ManbaCachePolicyInformer.
+	ManbaCachePolicies() ManbaCachePolicyInformer
+	// ManbaCanaries returns a ManbaCanaryInformer.
+	ManbaCanaries() ManbaCanaryInformer
 	// ManbaClusters returns a ManbaClusterInformer.
 	ManbaClusters() ManbaClusterInformer
 	// ManbaIngresses returns a ManbaIngressInformer.
 	ManbaIngresses() ManbaIngressInformer
+	// ManbaTrafficPolicies returns a ManbaTrafficPolicyInformer.
+	ManbaTrafficPolicies() ManbaTrafficPolicyInformer
 }
 
 type version struct {
@@ -41,6 +47,16 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ManbaCachePolicies returns a ManbaCachePolicyInformer.
+func (v *version) ManbaCachePolicies() ManbaCachePolicyInformer {
+	return &manbaCachePolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ManbaCanaries returns a ManbaCanaryInformer.
+func (v *version) ManbaCanaries() ManbaCanaryInformer {
+	return &manbaCanaryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ManbaClusters returns a ManbaClusterInformer.
 func (v *version) ManbaClusters() ManbaClusterInformer {
 	return &manbaClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -50,3 +66,8 @@ func (v *version) ManbaClusters() ManbaClusterInformer {
 func (v *version) ManbaIngresses() ManbaIngressInformer {
 	return &manbaIngressInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ManbaTrafficPolicies returns a ManbaTrafficPolicyInformer.
+func (v *version) ManbaTrafficPolicies() ManbaTrafficPolicyInformer {
+	return &manbaTrafficPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -53,10 +53,16 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=configuration.manba.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("manbacachepolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Configuration().V1beta1().ManbaCachePolicies().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("manbacanaries"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Configuration().V1beta1().ManbaCanaries().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("manbaclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Configuration().V1beta1().ManbaClusters().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("manbaingresses"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Configuration().V1beta1().ManbaIngresses().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("manbatrafficpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Configuration().V1beta1().ManbaTrafficPolicies().Informer()}, nil
 
 	}
 
@@ -18,6 +18,22 @@ limitations under the License.
 
 package v1beta1
 
+// ManbaCachePolicyListerExpansion allows custom methods to be added to
+// ManbaCachePolicyLister.
+type ManbaCachePolicyListerExpansion interface{}
+
+// ManbaCachePolicyNamespaceListerExpansion allows custom methods to be added to
+// ManbaCachePolicyNamespaceLister.
+type ManbaCachePolicyNamespaceListerExpansion interface{}
+
+// ManbaCanaryListerExpansion allows custom methods to be added to
+// ManbaCanaryLister.
+type ManbaCanaryListerExpansion interface{}
+
+// ManbaCanaryNamespaceListerExpansion allows custom methods to be added to
+// ManbaCanaryNamespaceLister.
+type ManbaCanaryNamespaceListerExpansion interface{}
+
 // ManbaClusterListerExpansion allows custom methods to be added to
 // ManbaClusterLister.
 type ManbaClusterListerExpansion interface{}
@@ -33,3 +49,11 @@ type ManbaIngressListerExpansion interface{}
 // ManbaIngressNamespaceListerExpansion allows custom methods to be added to
 // ManbaIngressNamespaceLister.
 type ManbaIngressNamespaceListerExpansion interface{}
+
+// ManbaTrafficPolicyListerExpansion allows custom methods to be added to
+// ManbaTrafficPolicyLister.
+type ManbaTrafficPolicyListerExpansion interface{}
+
+// ManbaTrafficPolicyNamespaceListerExpansion allows custom methods to be added to
+// ManbaTrafficPolicyNamespaceLister.
+type ManbaTrafficPolicyNamespaceListerExpansion interface{}
@@ -10,8 +10,26 @@ const (
 	// DefaultIngressClass defines the default class used
 	// by Manba's ingress controller.
 	DefaultIngressClass = "manba"
+
+	// AllowRouteOverrideKey allows a ManbaIngress to claim routes
+	// which are already claimed by other ManbaIngresses
+	AllowRouteOverrideKey = "configuration.manba.io/allow-route-override"
+
+	// NormalizeSplitWeightsKey makes rates of splits relative weights,
+	// which are scaled to Manba traffic rates adding up to 100
+	NormalizeSplitWeightsKey = "configuration.manba.io/normalize-split-weights"
 )
 
+// NormalizeSplitWeights returns true if rates of splits in obj are relative weights
+func NormalizeSplitWeights(obj metav1.Object) bool {
+	return obj.GetAnnotations()[NormalizeSplitWeightsKey] == "true"
+}
+
+// AllowRouteOverride returns true if obj deliberately overrides routes of other objects
+func AllowRouteOverride(obj metav1.Object) bool {
+	return obj.GetAnnotations()[AllowRouteOverrideKey] == "true"
+}
+
 // IngressClassValidatorFunc returns a function which can validate if an Object
 // belongs to an the ingressClass or not.
 // Deprecated: This method was designed for compatibility with k8s ingress, and now all crds are used
@@ -10,17 +10,22 @@ import (
 
 	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
 	"github.com/domgoer/manba-ingress/pkg/ingress/k8s"
+	"github.com/domgoer/manba-ingress/pkg/ingress/metric"
 	"github.com/domgoer/manba-ingress/pkg/ingress/status"
 	"github.com/pkg/errors"
 
 	"github.com/domgoer/manba-ingress/pkg/ingress/election"
 	"github.com/domgoer/manba-ingress/pkg/ingress/store"
 	"github.com/domgoer/manba-ingress/pkg/ingress/task"
+	manbaClient "github.com/domgoer/manba-ingress/pkg/manba/client"
+	"github.com/domgoer/manba-ingress/pkg/trafficpolicy"
 	"github.com/eapache/channels"
-	manbaClient "github.com/fagongzi/gateway/pkg/client"
 	"github.com/golang/glog"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/flowcontrol"
 )
 
@@ -48,6 +53,16 @@ type Config struct {
 	// MachineID uint16
 
 	Concurrency int
+
+	// InformersSynced reports whether informers have synced
+	InformersSynced []cache.InformerSynced
+	// MaxSyncAge is the max age of last successful sync before
+	// controller is considered as not ready, 0 means no limit
+	MaxSyncAge time.Duration
+
+	// TrafficPolicies resolves traffic policies inherited by manba clusters,
+	// the ones without a global policy are used if it's nil
+	TrafficPolicies *trafficpolicy.Resolver
 }
 
 // ManbaController listen ingress and update raw data in manba
@@ -58,6 +73,8 @@ type ManbaController struct {
 
 	syncQueue       *task.Queue
 	syncRateLimiter flowcontrol.RateLimiter
+	// scheduleTimer enqueues a sync when a schedule enters or leaves its window
+	scheduleTimer *time.Timer
 
 	stopCh   chan struct{}
 	updateCh *channels.RingChannel
@@ -67,8 +84,20 @@ type ManbaController struct {
 
 	parser *parser.Parser
 
+	eventWatcher watch.Interface
+	recorder     record.EventRecorder
+	// sources maps entities of the last sync to their k8s objects
+	sources sourceIndex
+
 	runningConfigHash [32]byte
 
+	statusLock sync.RWMutex
+	status     Status
+	// state is built by parser in last sync
+	state *parser.ManbaState
+	// reachability is checked by readiness probes
+	reachability reachability
+
 	syncStatus status.Syncer
 }
 
@@ -82,7 +111,11 @@ func NewManbaController(cfg Config, updateCh *channels.RingChannel, store store.
 		stopCh:          make(chan struct{}),
 	}
 	m.syncQueue = task.NewTaskQueue(m.syncManbaIngress)
-	m.parser = parser.New(m.store)
+	m.eventWatcher, m.recorder = newEventRecorder(cfg.KubeClient)
+	m.parser = parser.New(m.store, m.recorder)
+	if cfg.TrafficPolicies != nil {
+		m.parser.SetTrafficPolicyResolver(cfg.TrafficPolicies)
+	}
 
 	pod, err := k8s.GetPodDetails(cfg.KubeClient)
 	if err != nil {
@@ -144,12 +177,29 @@ func (m *ManbaController) syncManbaIngress(interface{}) error {
 
 	glog.V(2).Infof("syncing Ingress configuration...")
 
+	start := time.Now()
 	state, err := m.parser.Build()
 	if err != nil {
-		return errors.Wrap(err, "error building manba state")
+		metric.SyncErrors.WithLabelValues(metric.PhaseParse).Inc()
+		err = errors.Wrap(err, "error building manba state")
+		m.setSyncResult(err)
+		return err
+	}
+	metric.ObserveDuration(metric.PhaseParse, start)
+	parseErrors := map[string]int{kindManbaIngress: 0, kindManbaCluster: 0}
+	for _, e := range state.Errors {
+		parseErrors[e.Kind]++
 	}
+	for kind, count := range parseErrors {
+		metric.ParseErrors.WithLabelValues(kind).Set(float64(count))
+	}
+	m.statusLock.Lock()
+	m.state = state
+	m.statusLock.Unlock()
+	m.scheduleSync(state.NextSchedule)
 
 	err = m.OnUpdate(state)
+	m.setSyncResult(err)
 	if err != nil {
 		glog.Errorf("unexpected failure updating Manba configuration: %v", err)
 		return err
@@ -158,6 +208,21 @@ func (m *ManbaController) syncManbaIngress(interface{}) error {
 	return nil
 }
 
+// scheduleSync enqueues a sync at time at, the sync scheduled before is canceled
+func (m *ManbaController) scheduleSync(at *time.Time) {
+	if m.scheduleTimer != nil {
+		m.scheduleTimer.Stop()
+		m.scheduleTimer = nil
+	}
+	if at == nil {
+		return
+	}
+	glog.V(2).Infof("scheduling a sync at %v for schedule windows", *at)
+	m.scheduleTimer = time.AfterFunc(time.Until(*at), func() {
+		m.syncQueue.Enqueue(&networkingv1beta1.Ingress{})
+	})
+}
+
 // Start sync ingress
 func (m *ManbaController) Start() {
 	glog.Infof("starting Ingress controller")
@@ -175,6 +240,7 @@ func (m *ManbaController) Start() {
 	for {
 		select {
 		case event := <-m.updateCh.Out():
+			metric.UpdateQueueLength.Set(float64(m.updateCh.Len()))
 			if m.isShuttingDown {
 				break
 			}
@@ -191,6 +257,11 @@ func (m *ManbaController) Start() {
 	}
 }
 
+// IsLeader returns true if the controller is the leader of its election
+func (m *ManbaController) IsLeader() bool {
+	return m.elector.IsLeader()
+}
+
 // Stop gracefully stops the controller
 func (m *ManbaController) Stop() error {
 	m.isShuttingDown = true
@@ -208,5 +279,6 @@ func (m *ManbaController) Stop() error {
 	if m.syncStatus != nil {
 		m.syncStatus.Shutdown(m.elector.IsLeader())
 	}
+	m.eventWatcher.Stop()
 	return nil
 }
@@ -3,7 +3,7 @@ package controller
 import (
 	"log"
 
-	manbaClient "github.com/fagongzi/gateway/pkg/client"
+	manbaClient "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,7 +25,10 @@ func init() {
 	os.Setenv("POD_NAME", "test")
 	os.Setenv("POD_NAMESPACE", "test")
 
-	mc, err := manbaClient.NewClient(time.Second, "127.0.0.1:2379")
+	mc, err := manbaClient.NewClient(manbaClient.Config{
+		Addr:    "127.0.0.1:2379",
+		Timeout: time.Second,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/domgoer/manba-ingress/pkg/manba/crud"
+	"github.com/domgoer/manba-ingress/pkg/manba/dump"
+	"github.com/domgoer/manba-ingress/pkg/manba/solver"
+	"github.com/pkg/errors"
+)
+
+// DebugFilter selects entities generated from a namespace or a ManbaIngress,
+// empty fields match everything
+type DebugFilter struct {
+	Namespace string
+	Ingress   string
+}
+
+func (f DebugFilter) isEmpty() bool {
+	return f.Namespace == "" && f.Ingress == ""
+}
+
+// matchAPI returns true if the api or routing named name is generated from the filtered ingress,
+// sources are the entities of last parsed state
+func (f DebugFilter) matchAPI(sources sourceIndex, name string) bool {
+	ref, ok := sources.apiOwner(name)
+	if !ok {
+		// namespaces have no dots, so the first part of name is always the namespace
+		return f.Ingress == "" && strings.HasPrefix(name, f.Namespace+".")
+	}
+	return ref.Namespace == f.Namespace && (f.Ingress == "" || ref.Name == f.Ingress)
+}
+
+// apiOwner returns the ingress the api or routing named name is generated from, names in the format of
+// <namespace>.<ingress>.<suffix> not in index belong to the longest indexed ingress they start with,
+// since ingress names may have dots
+func (index sourceIndex) apiOwner(name string) (objectRef, bool) {
+	if ref, ok := index[sourceKey("api", name)]; ok {
+		return ref, true
+	}
+	if ref, ok := index[sourceKey("routing", name)]; ok {
+		return ref, true
+	}
+
+	var owner objectRef
+	for _, ref := range index {
+		if ref.Kind != kindManbaIngress || len(ref.Name) <= len(owner.Name) {
+			continue
+		}
+		if strings.HasPrefix(name, fmt.Sprintf("%s.%s.", ref.Namespace, ref.Name)) {
+			owner = ref
+		}
+	}
+	return owner, owner.Name != ""
+}
+
+// DiffEntry is an operation which will be sent to Manba on next sync
+type DiffEntry struct {
+	Op         string `json:"op"`
+	Kind       string `json:"kind"`
+	Identifier string `json:"identifier"`
+	// Diff is the difference between old and new objects of an update
+	Diff string `json:"diff,omitempty"`
+}
+
+// DebugParsed returns the state built by parser in last sync
+func (m *ManbaController) DebugParsed(f DebugFilter) *parser.ManbaState {
+	m.statusLock.RLock()
+	s := m.state
+	m.statusLock.RUnlock()
+
+	if s == nil {
+		return nil
+	}
+	return filterState(s, f)
+}
+
+// filterState returns entities of s which are generated from the filtered objects
+func filterState(s *parser.ManbaState, f DebugFilter) *parser.ManbaState {
+	if f.isEmpty() {
+		return s
+	}
+
+	var res parser.ManbaState
+	clusters := make(map[string]bool)
+	for _, api := range s.APIs {
+		if api.Namespace != f.Namespace || (f.Ingress != "" && api.IngressName != f.Ingress) {
+			continue
+		}
+		res.APIs = append(res.APIs, api)
+		for _, proxy := range api.Proxies {
+			clusters[proxy.ClusterName] = true
+		}
+	}
+	sources := newSourceIndex(s)
+	for _, routing := range s.Routings {
+		if f.matchAPI(sources, routing.APIName) {
+			res.Routings = append(res.Routings, routing)
+			clusters[routing.ClusterName] = true
+		}
+	}
+
+	servers := make(map[string]bool)
+	manbaClusters := make(map[string]bool)
+	for _, cluster := range s.Clusters {
+		if clusters[cluster.Name] || (f.Ingress == "" && cluster.Namespace == f.Namespace) {
+			res.Clusters = append(res.Clusters, cluster)
+			manbaClusters[cluster.ManbaClusterName] = true
+			for _, svr := range cluster.Servers {
+				servers[svr.Addr] = true
+			}
+		}
+	}
+	for _, server := range s.Servers {
+		if servers[server.Addr] {
+			res.Servers = append(res.Servers, server)
+		}
+	}
+	for _, e := range s.Errors {
+		if e.Namespace != f.Namespace {
+			continue
+		}
+		if f.Ingress == "" || (e.Kind == kindManbaIngress && e.Name == f.Ingress) ||
+			(e.Kind == kindManbaCluster && manbaClusters[e.Name]) {
+			res.Errors = append(res.Errors, e)
+		}
+	}
+	res.Plugins = s.Plugins
+	return &res
+}
+
+// DebugTarget returns the stable configuration which is going to be synced to Manba
+func (m *ManbaController) DebugTarget(f DebugFilter) *dump.ManbaRawState {
+	s := m.DebugParsed(DebugFilter{})
+	if s == nil {
+		return nil
+	}
+	return filterRawState(s.Raw(), newSourceIndex(s), f)
+}
+
+// DebugLive returns the configuration running in Manba
+func (m *ManbaController) DebugLive(f DebugFilter) (*dump.ManbaRawState, error) {
+	raw, err := dump.Get(m.cfg.Client)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading configuration from manba")
+	}
+	return filterRawState(raw, newSourceIndex(m.DebugParsed(DebugFilter{})), f), nil
+}
+
+// DebugDiff returns the operations which will be sent to Manba to
+// move the live configuration to target
+func (m *ManbaController) DebugDiff(f DebugFilter) ([]DiffEntry, error) {
+	s := m.DebugParsed(DebugFilter{})
+	if s == nil {
+		return nil, errors.New("configuration has not been parsed")
+	}
+
+	raw, err := dump.Get(m.cfg.Client)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading configuration from manba")
+	}
+	sources := newSourceIndex(s)
+	live := filterRawState(raw, sources, f)
+
+	targetRaw := s.Raw()
+	syncer, _, err := m.newSyncer(raw, targetRaw)
+	if err != nil {
+		return nil, err
+	}
+	events, err := solver.Plan(syncer)
+	if err != nil {
+		return nil, err
+	}
+
+	included := rawIdentifiers(filterRawState(targetRaw, sources, f))
+	for key := range rawIdentifiers(live) {
+		included[key] = true
+	}
+
+	var res []DiffEntry
+	for _, e := range events {
+		obj, ok := e.Obj.(interface{ Identifier() string })
+		if !ok || !included[sourceKey(e.Kind, obj.Identifier())] {
+			continue
+		}
+		entry := DiffEntry{
+			Op:         e.Op.String(),
+			Kind:       string(e.Kind),
+			Identifier: obj.Identifier(),
+		}
+		if e.Op == crud.Update {
+			entry.Diff, err = solver.Diff(e.OldObj, e.Obj)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+// filterRawState returns entities of raw which are generated from the filtered objects,
+// sources are the entities of last parsed state
+func filterRawState(raw *dump.ManbaRawState, sources sourceIndex, f DebugFilter) *dump.ManbaRawState {
+	if f.isEmpty() {
+		return raw
+	}
+
+	var res dump.ManbaRawState
+	clusterIDs := make(map[uint64]bool)
+	clusterNames := make(map[string]bool)
+	for _, api := range raw.APIs {
+		if !f.matchAPI(sources, api.GetName()) {
+			continue
+		}
+		res.APIs = append(res.APIs, api)
+		for _, node := range api.Nodes {
+			clusterIDs[node.ClusterID] = true
+		}
+		for _, proxy := range api.Proxies {
+			clusterNames[proxy.ClusterName] = true
+		}
+	}
+	for _, routing := range raw.Routings {
+		if !f.matchAPI(sources, routing.GetName()) {
+			continue
+		}
+		res.Routings = append(res.Routings, routing)
+		clusterIDs[routing.ClusterID] = true
+		clusterNames[routing.ClusterName] = true
+	}
+	// entities loaded from manba have no names of their relations
+	delete(clusterNames, "")
+	delete(clusterIDs, 0)
+
+	for _, cluster := range raw.Clusters {
+		// cluster names are in the format of <namespace>.<cluster>.<subset>.<port>.svc
+		inNamespace := f.Ingress == "" && strings.HasPrefix(cluster.GetName(), f.Namespace+".")
+		if inNamespace || clusterIDs[cluster.GetID()] || clusterNames[cluster.GetName()] {
+			res.Clusters = append(res.Clusters, cluster)
+			clusterIDs[cluster.GetID()] = true
+			clusterNames[cluster.GetName()] = true
+		}
+	}
+
+	serverIDs := make(map[uint64]bool)
+	serverAddrs := make(map[string]bool)
+	for _, bind := range raw.Binds {
+		if (bind.Bind != nil && clusterIDs[bind.GetClusterID()]) || clusterNames[bind.ClusterName] {
+			res.Binds = append(res.Binds, bind)
+			if bind.Bind != nil {
+				serverIDs[bind.GetServerID()] = true
+			}
+			serverAddrs[bind.ServerAddr] = true
+		}
+	}
+	delete(serverAddrs, "")
+	for _, server := range raw.Servers {
+		if serverIDs[server.GetID()] || serverAddrs[server.GetAddr()] {
+			res.Servers = append(res.Servers, server)
+		}
+	}
+	return &res
+}
+
+// rawIdentifiers returns keys of all entities in raw, keys are the same as sourceKey
+func rawIdentifiers(raw *dump.ManbaRawState) map[string]bool {
+	res := make(map[string]bool)
+	for _, api := range raw.APIs {
+		res[sourceKey("api", api.GetName())] = true
+	}
+	for _, routing := range raw.Routings {
+		res[sourceKey("routing", routing.GetName())] = true
+	}
+	for _, cluster := range raw.Clusters {
+		res[sourceKey("cluster", cluster.GetName())] = true
+	}
+	for _, server := range raw.Servers {
+		res[sourceKey("server", server.GetAddr())] = true
+	}
+	for _, bind := range raw.Binds {
+		if bind.Bind != nil {
+			res[sourceKey("bind", fmt.Sprintf("%d-%d", bind.GetClusterID(), bind.GetServerID()))] = true
+		}
+	}
+	return res
+}
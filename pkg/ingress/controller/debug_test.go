@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/domgoer/manba-ingress/pkg/manba/dump"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterRawState(t *testing.T) {
+	raw := &dump.ManbaRawState{
+		APIs: []*dump.API{
+			{API: &metapb.API{Name: "default.a.0000", Nodes: []*metapb.DispatchNode{{ClusterID: 1}}}},
+			{API: &metapb.API{Name: "default.b.0000", Nodes: []*metapb.DispatchNode{{ClusterID: 2}}}},
+			{API: &metapb.API{Name: "other.a.0000", Nodes: []*metapb.DispatchNode{{ClusterID: 3}}}},
+			// ingress a.b is not a part of ingress a
+			{API: &metapb.API{Name: "default.a.b.0000"}},
+			// not generated by last sync
+			{API: &metapb.API{Name: "default.a.b.1111"}},
+			{API: &metapb.API{Name: "default.a.1111"}},
+			{API: &metapb.API{Name: "default.gone.0000"}},
+		},
+		Routings: []*dump.Routing{
+			{Routing: &metapb.Routing{Name: "default.a.0000.split.0", ClusterID: 2}},
+		},
+		Clusters: []*dump.Cluster{
+			{Cluster: &metapb.Cluster{ID: 1, Name: "default.c1.v1.80.svc"}},
+			{Cluster: &metapb.Cluster{ID: 2, Name: "default.c2.v1.80.svc"}},
+			{Cluster: &metapb.Cluster{ID: 3, Name: "other.c1.v1.80.svc"}},
+		},
+		Binds: []*dump.Bind{
+			{Bind: &metapb.Bind{ClusterID: 1, ServerID: 10}},
+			{Bind: &metapb.Bind{ClusterID: 3, ServerID: 30}},
+		},
+		Servers: []*dump.Server{
+			{Server: &metapb.Server{ID: 10, Addr: "1.1.1.1:80"}},
+			{Server: &metapb.Server{ID: 30, Addr: "3.3.3.3:80"}},
+		},
+	}
+
+	sources := sourceIndex{
+		sourceKey("api", "default.a.0000"):   {Kind: kindManbaIngress, Namespace: "default", Name: "a"},
+		sourceKey("api", "default.b.0000"):   {Kind: kindManbaIngress, Namespace: "default", Name: "b"},
+		sourceKey("api", "other.a.0000"):     {Kind: kindManbaIngress, Namespace: "other", Name: "a"},
+		sourceKey("api", "default.a.b.0000"): {Kind: kindManbaIngress, Namespace: "default", Name: "a.b"},
+	}
+
+	assert.Equal(t, raw, filterRawState(raw, sources, DebugFilter{}))
+
+	res := filterRawState(raw, sources, DebugFilter{Namespace: "default", Ingress: "a"})
+	assert.Equal(t, []*dump.API{raw.APIs[0], raw.APIs[5]}, res.APIs)
+	assert.Equal(t, raw.Routings, res.Routings)
+	assert.Equal(t, raw.Clusters[:2], res.Clusters)
+	assert.Equal(t, raw.Binds[:1], res.Binds)
+	assert.Equal(t, raw.Servers[:1], res.Servers)
+
+	res = filterRawState(raw, sources, DebugFilter{Namespace: "default", Ingress: "a.b"})
+	assert.Equal(t, raw.APIs[3:5], res.APIs)
+	assert.Empty(t, res.Routings)
+
+	res = filterRawState(raw, sources, DebugFilter{Namespace: "default"})
+	assert.Equal(t, []*dump.API{raw.APIs[0], raw.APIs[1], raw.APIs[3], raw.APIs[4], raw.APIs[5], raw.APIs[6]}, res.APIs)
+
+	res = filterRawState(raw, sources, DebugFilter{Namespace: "other"})
+	assert.Equal(t, raw.APIs[2:3], res.APIs)
+	assert.Empty(t, res.Routings)
+	assert.Equal(t, raw.Clusters[2:], res.Clusters)
+	assert.Equal(t, raw.Servers[1:], res.Servers)
+}
+
+func TestManbaController_DebugParsed(t *testing.T) {
+	m := &ManbaController{
+		state: &parser.ManbaState{
+			APIs: []parser.API{
+				{
+					API:         metapb.API{Name: "default.a.0000"},
+					Namespace:   "default",
+					IngressName: "a",
+					Proxies:     map[string]parser.Proxy{"default.c1.v1.80.svc": {ClusterName: "default.c1.v1.80.svc"}},
+				},
+				{
+					API:         metapb.API{Name: "default.b.0000"},
+					Namespace:   "default",
+					IngressName: "b",
+				},
+			},
+			Clusters: []parser.Cluster{
+				{
+					Cluster:   metapb.Cluster{Name: "default.c1.v1.80.svc"},
+					Namespace: "default",
+					Servers:   []*parser.Server{{Server: metapb.Server{Addr: "1.1.1.1:80"}}},
+				},
+				{
+					Cluster:   metapb.Cluster{Name: "default.c2.v1.80.svc"},
+					Namespace: "default",
+				},
+			},
+			Servers: []parser.Server{
+				{Server: metapb.Server{Addr: "1.1.1.1:80"}},
+				{Server: metapb.Server{Addr: "2.2.2.2:80"}},
+			},
+		},
+	}
+
+	res := m.DebugParsed(DebugFilter{Namespace: "default", Ingress: "a"})
+	assert.Equal(t, m.state.APIs[:1], res.APIs)
+	assert.Equal(t, m.state.Clusters[:1], res.Clusters)
+	assert.Equal(t, m.state.Servers[:1], res.Servers)
+
+	res = m.DebugParsed(DebugFilter{Namespace: "default"})
+	assert.Equal(t, m.state.APIs, res.APIs)
+	assert.Equal(t, m.state.Clusters, res.Clusters)
+}
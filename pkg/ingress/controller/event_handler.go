@@ -130,3 +130,41 @@ func (reh EndpointsEventHandler) OnUpdate(old, cur interface{}) {
 		}
 	}
 }
+
+// ConfigMapEventHandler handles create, update and delete events for
+// configmap resources in k8s.
+// It is not ingress.class aware and the OnUpdate method filters out
+// events with same data, like renewals of leader election records.
+type ConfigMapEventHandler struct {
+	UpdateCh *channels.RingChannel
+}
+
+// OnAdd is invoked whenever a resource is added.
+func (reh ConfigMapEventHandler) OnAdd(obj interface{}) {
+	reh.UpdateCh.In() <- Event{
+		Type: CreateEvent,
+		Obj:  obj,
+	}
+}
+
+// OnDelete is invoked whenever a resource is deleted.
+func (reh ConfigMapEventHandler) OnDelete(obj interface{}) {
+	reh.UpdateCh.In() <- Event{
+		Type: DeleteEvent,
+		Obj:  obj,
+	}
+}
+
+// OnUpdate is invoked whenever a ConfigMap is changed.
+// If the data is same as before, an update is not sent on
+// the UpdateCh.
+func (reh ConfigMapEventHandler) OnUpdate(old, cur interface{}) {
+	ocm := old.(*corev1.ConfigMap)
+	ccm := cur.(*corev1.ConfigMap)
+	if !reflect.DeepEqual(ocm.Data, ccm.Data) || !reflect.DeepEqual(ocm.BinaryData, ccm.BinaryData) {
+		reh.UpdateCh.In() <- Event{
+			Type: UpdateEvent,
+			Obj:  cur,
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/domgoer/manba-ingress/pkg/manba/solver"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/pkg/errors"
+)
+
+// readyTimeout is how long probes wait for manba api server, it's under the 1s timeout of the readiness probe in deploy
+const readyTimeout = 800 * time.Millisecond
+
+// Status is a snapshot of the sync state of controller
+type Status struct {
+	// Leader is true if this replica is leader, others are standby
+	Leader          bool `json:"leader"`
+	InformersSynced bool `json:"informersSynced"`
+	// ConfigHash is the hash of the configuration running in Manba
+	ConfigHash string `json:"configHash,omitempty"`
+	// LastSyncTime is the time of last successful sync
+	LastSyncTime  time.Time    `json:"lastSyncTime,omitempty"`
+	LastError     string       `json:"lastError,omitempty"`
+	LastErrorTime time.Time    `json:"lastErrorTime,omitempty"`
+	LastStats     solver.Stats `json:"lastStats"`
+}
+
+// Status returns the sync state of controller
+func (m *ManbaController) Status() Status {
+	m.statusLock.RLock()
+	s := m.status
+	m.statusLock.RUnlock()
+
+	s.Leader = m.elector.IsLeader()
+	s.InformersSynced = m.informersSynced()
+	return s
+}
+
+// CheckReady returns an error if controller is not able to
+// sync configuration to Manba
+func (m *ManbaController) CheckReady() error {
+	if !m.informersSynced() {
+		return errors.New("informers have not synced")
+	}
+
+	err := m.reachability.check(func() error {
+		return m.cfg.Client.GetClusterList(func(*metapb.Cluster) bool {
+			return false
+		})
+	}, readyTimeout)
+	if err != nil {
+		return errors.Wrap(err, "manba api server is unreachable")
+	}
+
+	s := m.Status()
+	if s.LastSyncTime.IsZero() {
+		return errors.New("configuration has not been synced")
+	}
+	if age := time.Since(s.LastSyncTime); m.cfg.MaxSyncAge > 0 && age > m.cfg.MaxSyncAge {
+		return fmt.Errorf("last successful sync was %v ago", age.Truncate(time.Second))
+	}
+	return nil
+}
+
+// reachability runs one check of manba api server at a time, probes arriving during a check wait for its result,
+// so probes don't pile up on a slow server
+type reachability struct {
+	lock     sync.Mutex
+	inFlight *reachabilityCheck
+}
+
+type reachabilityCheck struct {
+	done chan struct{}
+	err  error
+}
+
+// check returns the result of the check in flight or a new one of fn, or an error if there's none in timeout,
+// the check goes on after timeout and is bounded by the timeout of client
+func (r *reachability) check(fn func() error, timeout time.Duration) error {
+	r.lock.Lock()
+	c := r.inFlight
+	if c == nil {
+		c = &reachabilityCheck{done: make(chan struct{})}
+		r.inFlight = c
+		go func() {
+			c.err = fn()
+			r.lock.Lock()
+			r.inFlight = nil
+			r.lock.Unlock()
+			close(c.done)
+		}()
+	}
+	r.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+		return c.err
+	case <-timer.C:
+		return errors.Errorf("no response in %v", timeout)
+	}
+}
+
+func (m *ManbaController) informersSynced() bool {
+	for _, synced := range m.cfg.InformersSynced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// setSyncResult records the result of a sync
+func (m *ManbaController) setSyncResult(err error) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
+	if err != nil {
+		m.status.LastError = err.Error()
+		m.status.LastErrorTime = time.Now()
+		return
+	}
+	m.status.LastSyncTime = time.Now()
+	m.status.ConfigHash = hex.EncodeToString(m.runningConfigHash[:])
+}
+
+// setSyncStats records the stats of last solve
+func (m *ManbaController) setSyncStats(stats solver.Stats) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
+	m.status.LastStats = stats
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeElector bool
+
+func (f fakeElector) IsLeader() bool { return bool(f) }
+
+func (f fakeElector) Run(context.Context) {}
+
+func TestManbaController_CheckReady(t *testing.T) {
+	m := &ManbaController{
+		cfg: Config{
+			InformersSynced: []cache.InformerSynced{func() bool { return false }},
+		},
+	}
+	assert.EqualError(t, m.CheckReady(), "informers have not synced")
+}
+
+func TestReachability(t *testing.T) {
+	var r reachability
+	var calls int32
+	release := make(chan struct{})
+	slow := func() error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return errors.New("refused")
+	}
+
+	// probes during a slow check time out without starting another one
+	assert.EqualError(t, r.check(slow, 10*time.Millisecond), "no response in 10ms")
+	assert.EqualError(t, r.check(slow, 10*time.Millisecond), "no response in 10ms")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	close(release)
+	assert.EqualError(t, r.check(slow, time.Second), "refused")
+	assert.Nil(t, r.check(func() error { return nil }, time.Second))
+}
+
+func TestManbaController_Status(t *testing.T) {
+	m := &ManbaController{elector: fakeElector(true)}
+
+	m.setSyncResult(errors.New("sync failed"))
+	s := m.Status()
+	assert.True(t, s.Leader)
+	assert.True(t, s.InformersSynced)
+	assert.Equal(t, "sync failed", s.LastError)
+	assert.True(t, s.LastSyncTime.IsZero())
+
+	m.runningConfigHash = [32]byte{1}
+	m.setSyncResult(nil)
+	s = m.Status()
+	assert.False(t, s.LastSyncTime.IsZero())
+	assert.Equal(t, "0100000000000000000000000000000000000000000000000000000000000000", s.ConfigHash)
+}
@@ -17,25 +17,28 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"reflect"
-	"sort"
+	"strings"
+	"time"
 
 	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/domgoer/manba-ingress/pkg/ingress/metric"
 	"github.com/domgoer/manba-ingress/pkg/manba/diff"
 	"github.com/domgoer/manba-ingress/pkg/manba/dump"
 	"github.com/domgoer/manba-ingress/pkg/manba/solver"
 	"github.com/domgoer/manba-ingress/pkg/manba/state"
+	"github.com/domgoer/manba-ingress/pkg/manba/validation"
 	"github.com/domgoer/manba-ingress/pkg/utils"
-	"github.com/fagongzi/gateway/pkg/pb"
 	"github.com/fagongzi/gateway/pkg/pb/metapb"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // OnUpdate is called periodically by syncQueue to keep the configuration in sync.
 // returning nil implies the synchronization finished correctly.
 // Returning an error means requeue the update.
 func (m *ManbaController) OnUpdate(state *parser.ManbaState) error {
-	target := m.toStable(state)
+	target := state.Raw()
 
 	jsonConfig, err := json.Marshal(target)
 	if err != nil {
@@ -51,127 +54,81 @@ func (m *ManbaController) OnUpdate(state *parser.ManbaState) error {
 	if err == nil {
 		glog.Info("successfully synced configuration to Manba")
 		m.runningConfigHash = shaSum
+		metric.SetConfigHash(shaSum)
 	}
 	return err
 }
 
 func (m *ManbaController) onUpdate(p *parser.ManbaState) error {
-	targetRaw := m.toStable(p)
+	targetRaw := p.Raw()
 	client := m.cfg.Client
+	m.sources = newSourceIndex(p)
 
+	start := time.Now()
 	raw, err := dump.Get(client)
 	if err != nil {
+		metric.SyncErrors.WithLabelValues(metric.PhaseDump).Inc()
 		return errors.Wrap(err, "loading configuration from manba")
 	}
+	metric.ObserveDuration(metric.PhaseDump, start)
 
-	currentState, err := state.Get(raw)
-	if err != nil {
-		return errors.Wrap(err, "get current state")
-	}
-
-	err = m.setTargetsIDs(targetRaw, currentState)
+	start = time.Now()
+	syncer, invalid, err := m.newSyncer(raw, targetRaw)
 	if err != nil {
-		return errors.Wrap(err, "set target IDs")
+		metric.SyncErrors.WithLabelValues(metric.PhaseDiff).Inc()
+		return err
 	}
+	metric.ObserveDuration(metric.PhaseDiff, start)
+	m.recordInvalidations(invalid)
 
-	targetRaw = m.filterInvalidations(targetRaw)
-
-	targetState, err := state.Get(targetRaw)
-	if err != nil {
-		return errors.Wrap(err, "get target state")
+	start = time.Now()
+	syncer.SilenceWarnings = true
+	stats, err := solver.Solve(nil, syncer, client, m.cfg.Concurrency, m.recordOp)
+	metric.ObserveDuration(metric.PhaseSolve, start)
+	m.setSyncStats(stats)
+	for kind, ops := range stats.KindOps {
+		for op, count := range ops {
+			metric.SolverOperations.WithLabelValues(string(kind), op).Add(float64(count))
+		}
 	}
-
-	syncer, err := diff.NewSyncer(currentState, targetState)
 	if err != nil {
-		return errors.Wrap(err, "new syncer")
+		metric.SyncErrors.WithLabelValues(metric.PhaseSolve).Inc()
 	}
 
-	syncer.SilenceWarnings = true
-	_, err = solver.Solve(nil, syncer, client, m.cfg.Concurrency)
-
 	return err
 }
 
-func (m *ManbaController) toStable(s *parser.ManbaState) *dump.ManbaRawState {
-	var ms dump.ManbaRawState
-	for _, api := range s.APIs {
-		a := api.API
-
-		var proxies []parser.Proxy
-		for _, a := range api.Proxies {
-			proxies = append(proxies, a)
-		}
-		sort.SliceStable(proxies, func(i, j int) bool {
-			return proxies[i].ClusterName < proxies[j].ClusterName
-		})
-
-		ms.APIs = append(ms.APIs, &dump.API{
-			API:     &a,
-			Proxies: proxies,
-		})
+// newSyncer builds the current state from raw and the target state from targetRaw,
+// then returns a syncer which moves Manba from current to target and
+// the entities dropped from target
+func (m *ManbaController) newSyncer(raw, targetRaw *dump.ManbaRawState) (*diff.Syncer, []validation.InvalidEntity, error) {
+	currentState, err := state.Get(raw)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get current state")
 	}
 
-	sort.SliceStable(ms.APIs, func(i, j int) bool {
-		return ms.APIs[i].Name < ms.APIs[j].Name
-	})
-
-	for _, server := range s.Servers {
-		svr := server.Server
-		ms.Servers = append(ms.Servers, &dump.Server{
-			Server: &svr,
-		})
+	err = setTargetsIDs(targetRaw, currentState)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "set target IDs")
 	}
 
-	sort.SliceStable(ms.Servers, func(i, j int) bool {
-		return ms.Servers[i].Addr < ms.Servers[j].Addr
-	})
+	targetRaw, invalid := validation.Filter(targetRaw)
 
-	for _, routing := range s.Routings {
-		r := routing.Routing
-		ms.Routings = append(ms.Routings, &dump.Routing{
-			APIName:     routing.APIName,
-			ClusterName: routing.ClusterName,
-			Routing:     &r,
-		})
+	targetState, err := state.Get(targetRaw)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get target state")
 	}
-	sort.SliceStable(ms.Routings, func(i, j int) bool {
-		return ms.Routings[i].Name < ms.Routings[j].Name
-	})
-
-	for _, cls := range s.Clusters {
-		c := cls.Cluster
-
-		ms.Clusters = append(ms.Clusters, &dump.Cluster{
-			Cluster: &c,
-		})
 
-		for _, svr := range cls.Servers {
-			// Add binds
-			ms.Binds = append(ms.Binds, &dump.Bind{
-				ClusterName: c.GetName(),
-				ServerAddr:  svr.GetAddr(),
-			})
-		}
+	syncer, err := diff.NewSyncer(currentState, targetState)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "new syncer")
 	}
-
-	sort.SliceStable(ms.Clusters, func(i, j int) bool {
-		return ms.Clusters[i].Name < ms.Clusters[j].Name
-	})
-
-	sort.SliceStable(ms.Binds, func(i, j int) bool {
-		return ms.Binds[i].ClusterName < ms.Binds[j].ClusterName && ms.Binds[i].ServerAddr < ms.Binds[j].ServerAddr
-	})
-
-	return &ms
+	return syncer, invalid, nil
 }
 
 // setTargetsIDs gets their id from the existing state in manba and fill it into k8s state
 // p: Used to obtain the relationship between various resources
-func (m *ManbaController) setTargetsIDs(target *dump.ManbaRawState, current *state.ManbaState) error {
-	serverAddrIDsMap := make(map[string]uint64, len(target.Servers))
-	clusterNameIDsMap := make(map[string]uint64, len(target.Clusters))
-	apiNameIDsMap := make(map[string]uint64, len(target.APIs))
-
+func setTargetsIDs(target *dump.ManbaRawState, current *state.ManbaState) error {
 	for _, server := range target.Servers {
 		if server.GetID() == 0 {
 			s, err := current.Servers.Get(server.GetAddr())
@@ -184,7 +141,6 @@ func (m *ManbaController) setTargetsIDs(target *dump.ManbaRawState, current *sta
 				server.ID = s.GetID()
 			}
 		}
-		serverAddrIDsMap[server.GetAddr()] = server.GetID()
 	}
 
 	for _, cluster := range target.Clusters {
@@ -198,124 +154,92 @@ func (m *ManbaController) setTargetsIDs(target *dump.ManbaRawState, current *sta
 				cluster.ID = c.GetID()
 			}
 		}
-
-		clusterNameIDsMap[cluster.GetName()] = cluster.GetID()
 	}
 
-	for _, bind := range target.Binds {
-		clusterID, ok := clusterNameIDsMap[bind.ClusterName]
-		if !ok {
-			glog.Warningf("not found cluster <%s> in bind", bind.ClusterName)
-			continue
-		}
-
-		serverID, ok := serverAddrIDsMap[bind.ServerAddr]
-		if !ok {
-			glog.Warningf("not found server <%s> in bind", bind.ServerAddr)
-			continue
-		}
-
-		bind.Bind = &metapb.Bind{
-			ClusterID: clusterID,
-			ServerID:  serverID,
-		}
+	// renamed apis keep their ids, so that they are updated instead of recreated
+	currentAPIs, err := current.APIs.GetAll()
+	if err != nil {
+		return err
 	}
+	apiNames := make(map[string]bool, len(target.APIs))
+	for _, api := range target.APIs {
+		apiNames[api.Name] = true
+	}
+	renamed := make(map[string]string)
+	claimed := make(map[uint64]bool)
 
 	for _, api := range target.APIs {
 		if api.GetID() == 0 {
 			a, err := current.APIs.Get(api.Name)
 			if err == state.ErrNotFound {
-				api.ID = utils.SnowID()
+				if old := findRenamedAPI(api.API, currentAPIs, apiNames, claimed); old != nil {
+					glog.Infof("api <%s> is renamed to <%s>", old.Name, api.Name)
+					api.ID = old.ID
+					claimed[old.ID] = true
+					renamed[api.Name] = old.Name
+				} else {
+					api.ID = utils.SnowID()
+				}
 			} else if err != nil {
 				return err
 			} else {
 				api.ID = a.GetID()
 			}
 		}
-		var nodes []*metapb.DispatchNode
-		for _, proxy := range api.Proxies {
-			n := proxy.DispatchNode
-			n.ClusterID = clusterNameIDsMap[proxy.ClusterName]
-			nodes = append(nodes, &n)
-		}
-
-		api.Nodes = nodes
+	}
 
-		apiNameIDsMap[api.Name] = api.ID
+	routingNames := make(map[string]bool, len(target.Routings))
+	for _, routing := range target.Routings {
+		routingNames[routing.Name] = true
 	}
 
 	for _, routing := range target.Routings {
 		if routing.ID == 0 {
 			r, err := current.Routings.Get(routing.Name)
 			if err == state.ErrNotFound {
-				routing.ID = utils.SnowID()
+				// routings of renamed apis are renamed with them
+				if oldAPI, ok := renamed[routing.APIName]; ok {
+					oldName := oldAPI + strings.TrimPrefix(routing.Name, routing.APIName)
+					if r, err := current.Routings.Get(oldName); err == nil && !routingNames[oldName] {
+						routing.ID = r.GetID()
+					}
+				}
+				if routing.ID == 0 {
+					routing.ID = utils.SnowID()
+				}
 			} else if err != nil {
 				return err
 			} else {
 				routing.ID = r.GetID()
 			}
 		}
-
-		if cid, ok := clusterNameIDsMap[routing.ClusterName]; !ok {
-			glog.Warningf("not found cluster name <%s>", routing.ClusterName)
-		} else {
-			routing.ClusterID = cid
-		}
-		if aid, ok := apiNameIDsMap[routing.APIName]; !ok {
-			glog.Warningf("not found api name <%s>", routing.APIName)
-		} else {
-			routing.API = aid
-		}
 	}
 
+	target.LinkIDs()
 	return nil
 }
 
-func (m *ManbaController) filterInvalidations(raw *dump.ManbaRawState) *dump.ManbaRawState {
-	res := new(dump.ManbaRawState)
-	validClusters := make(map[uint64]bool, len(raw.Clusters))
-	validServers := make(map[uint64]bool, len(raw.Servers))
-
-	for _, cluster := range raw.Clusters {
-		if err := pb.ValidateCluster(cluster.Cluster); err != nil {
-			glog.Warningf("cluster <%v> is invalid: %v", cluster, err)
-			continue
-		}
-		validClusters[cluster.GetID()] = true
-		res.Clusters = append(res.Clusters, cluster)
-	}
-
-	for _, server := range raw.Servers {
-		if err := pb.ValidateServer(server.Server); err != nil {
-			glog.Warningf("server <%v> is invalid: %v", server, err)
+// findRenamedAPI returns the api in current which matches the same requests as api
+// and is neither kept by name nor claimed by another renamed api
+func findRenamedAPI(api *metapb.API, current []*state.API, names map[string]bool, claimed map[uint64]bool) *state.API {
+	for _, c := range current {
+		if names[c.Name] || claimed[c.ID] {
 			continue
 		}
-		validServers[server.GetID()] = true
-		res.Servers = append(res.Servers, server)
-	}
-
-	for _, bind := range raw.Binds {
-		if validServers[bind.GetServerID()] && validClusters[bind.GetClusterID()] {
-			res.Binds = append(res.Binds, bind)
-		} else {
-			glog.Warningf("cluster: %d, server: %d", bind.GetClusterID(), bind.GetServerID())
-		}
-	}
-
-	for _, api := range raw.APIs {
-		if err := pb.ValidateAPI(api.API); err != nil {
-			glog.Warningf("api <%v> is invalid: %v", api, err)
-			continue
+		if c.Domain == api.Domain && c.URLPattern == api.URLPattern &&
+			c.Method == api.Method && c.MatchRule == api.MatchRule {
+			return c
 		}
-		res.APIs = append(res.APIs, api)
 	}
+	return nil
+}
 
-	for _, routing := range raw.Routings {
-		if err := pb.ValidateRouting(routing.Routing); err != nil {
-			glog.Warningf("routing <%v> is invalid: %v", routing, err)
-			continue
-		}
-		res.Routings = append(res.Routings, routing)
+// recordInvalidations reports entities dropped by validation.Filter
+func (m *ManbaController) recordInvalidations(invalid []validation.InvalidEntity) {
+	for _, e := range invalid {
+		glog.Warningf("%s <%s> is invalid: %v", e.Kind, e.Identifier, e.Err)
+		m.recordEvent(e.Kind, e.Identifier, corev1.EventTypeWarning, ReasonInvalid,
+			"%s <%s> is rejected by Manba validation: %v", e.Kind, e.Identifier, e.Err)
+		metric.InvalidEntities.WithLabelValues(string(e.Kind)).Inc()
 	}
-	return res
 }
@@ -1 +1,42 @@
 package controller
+
+import (
+	"testing"
+
+	"github.com/domgoer/manba-ingress/pkg/manba/dump"
+	"github.com/domgoer/manba-ingress/pkg/manba/state"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTargetsIDsRename(t *testing.T) {
+	current, err := state.NewManbaState()
+	assert.Nil(t, err)
+	assert.Nil(t, current.APIs.Add(state.API{API: metapb.API{
+		ID: 1, Name: "default.test-ing.0000", Domain: "example.com", URLPattern: "/", Method: "GET",
+	}}))
+	assert.Nil(t, current.APIs.Add(state.API{API: metapb.API{
+		ID: 2, Name: "default.test-ing.0001", Domain: "example.com", URLPattern: "/api", Method: "GET",
+	}}))
+	assert.Nil(t, current.Routings.Add(state.Routing{Routing: metapb.Routing{
+		ID: 3, Name: "default.test-ing.0000.split.0", API: 1,
+	}}))
+
+	target := &dump.ManbaRawState{
+		APIs: []*dump.API{
+			{API: &metapb.API{Name: "default.test-ing.root", Domain: "example.com", URLPattern: "/", Method: "GET"}},
+			// kept by name, although it matches the same requests as a renamed one
+			{API: &metapb.API{Name: "default.test-ing.0001", Domain: "example.com", URLPattern: "/", Method: "GET"}},
+		},
+		Routings: []*dump.Routing{{
+			APIName: "default.test-ing.root",
+			Routing: &metapb.Routing{Name: "default.test-ing.root.split.0"},
+		}},
+	}
+	assert.Nil(t, setTargetsIDs(target, current))
+
+	assert.Equal(t, uint64(1), target.APIs[0].ID)
+	assert.Equal(t, uint64(2), target.APIs[1].ID)
+	assert.Equal(t, uint64(3), target.Routings[0].ID)
+	assert.Equal(t, uint64(1), target.Routings[0].API)
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// resolveSubsets replaces "@active" and "@preview" subsets of routes, aggregate calls, splits and mirrors
+// of ingress with the subsets of their clusters, references which can't be resolved are kept.
+// A split sending preview requests to the preview subset is added to rules routing to "@active",
+// the returned clusters are the preview subsets of these splits.
+func (p *Parser) resolveSubsets(ingress *configurationv1beta1.ManbaIngress) ([]configurationv1beta1.ManbaHTTPRouteCluster, error) {
+	clusters := make(map[string]*configurationv1beta1.ManbaCluster)
+	resolve := func(cls *configurationv1beta1.ManbaHTTPRouteCluster) (*configurationv1beta1.ManbaCluster, error) {
+		if cls.Subset != configurationv1beta1.ActiveSubsetRef && cls.Subset != configurationv1beta1.PreviewSubsetRef {
+			return nil, nil
+		}
+		cluster, ok := clusters[cls.Name]
+		if !ok {
+			var err error
+			cluster, err = p.store.GetManbaCluster(ingress.Namespace, cls.Name)
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting ManbaCluster %s/%s", ingress.Namespace, cls.Name)
+			}
+			clusters[cls.Name] = cluster
+		}
+		if subset, ok := cluster.ResolveSubset(cls.Subset); ok {
+			cls.Subset = subset
+		}
+		return cluster, nil
+	}
+
+	var previews []configurationv1beta1.ManbaHTTPRouteCluster
+	for i := range ingress.Spec.HTTP {
+		rule := &ingress.Spec.HTTP[i]
+		for j := range rule.Mirror {
+			if _, err := resolve(&rule.Mirror[j].Cluster); err != nil {
+				return nil, err
+			}
+		}
+		for j := range rule.Split {
+			if _, err := resolve(&rule.Split[j].Cluster); err != nil {
+				return nil, err
+			}
+		}
+		// routings apply to all calls of an aggregate, so they get no preview splits
+		calls := rule.Aggregate.GetCalls()
+		for j := range calls {
+			if _, err := resolve(&calls[j].Cluster); err != nil {
+				return nil, err
+			}
+		}
+
+		added := make(map[string]bool)
+		for j := range rule.Route {
+			cls := &rule.Route[j].Cluster
+			active := cls.Subset == configurationv1beta1.ActiveSubsetRef
+			cluster, err := resolve(cls)
+			if err != nil {
+				return nil, err
+			}
+			if !active || cluster == nil || cluster.Spec.PreviewSubset == "" || cluster.Spec.PreviewSubset == cls.Subset {
+				continue
+			}
+
+			preview := configurationv1beta1.ManbaHTTPRouteCluster{
+				Name:   cls.Name,
+				Subset: cluster.Spec.PreviewSubset,
+				Port:   cls.Port,
+			}
+			key := preview.Name + "/" + preview.Port.String()
+			if added[key] {
+				continue
+			}
+			added[key] = true
+			previews = append(previews, preview)
+
+			header := cluster.Spec.GetPreviewHeader()
+			rate := configurationv1beta1.DefaultRoutingRate
+			rule.Split = append(rule.Split, configurationv1beta1.ManbaHTTPRouting{
+				Cluster: preview,
+				Rate:    &rate,
+				Conditions: []metapb.Condition{{
+					Parameter: metapb.Parameter{Name: header.Name, Source: metapb.Header},
+					Cmp:       metapb.CMPEQ,
+					Expect:    header.Value,
+				}},
+			})
+		}
+	}
+	return previews, nil
+}
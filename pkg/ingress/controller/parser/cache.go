@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"fmt"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ReasonCachePolicyNotFound is the reason of event when ManbaCachePolicy referred by ManbaIngress is not found
+const ReasonCachePolicyNotFound = "CachePolicyNotFound"
+
+// resolveCachePolicies sets caches of routes and aggregate calls of ingress from the ManbaCachePolicies they reference,
+// they are not cached if the policy is not found
+func (p *Parser) resolveCachePolicies(source, ingress *configurationv1beta1.ManbaIngress) error {
+	caches := make(map[string]*metapb.Cache)
+	resolve := func(name, path string) (*metapb.Cache, error) {
+		if cache, ok := caches[name]; ok {
+			return cache, nil
+		}
+		policy, err := p.store.GetManbaCachePolicy(ingress.Namespace, name)
+		if apierrors.IsNotFound(err) {
+			glog.Warningf("ManbaCachePolicy %s/%s of %s not found", ingress.Namespace, name, path)
+			p.recorder.Eventf(source, corev1.EventTypeWarning, ReasonCachePolicyNotFound,
+				"ManbaCachePolicy %s/%s not found, %s is not cached", ingress.Namespace, name, path)
+			caches[name] = nil
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting ManbaCachePolicy %s/%s", ingress.Namespace, name)
+		}
+		cache, err := policy.Spec.ToManbaCache()
+		if err != nil {
+			return nil, errors.Wrapf(err, "ManbaCachePolicy %s/%s", ingress.Namespace, name)
+		}
+		caches[name] = cache
+		return cache, nil
+	}
+
+	for i := range ingress.Spec.HTTP {
+		rule := &ingress.Spec.HTTP[i]
+		for j := range rule.Route {
+			route := &rule.Route[j]
+			if route.CachePolicy == "" {
+				continue
+			}
+			cache, err := resolve(route.CachePolicy, fmt.Sprintf("http[%d].route[%d]", i, j))
+			if err != nil {
+				return err
+			}
+			route.Cache = cache
+		}
+		calls := rule.Aggregate.GetCalls()
+		for j := range calls {
+			call := &calls[j]
+			if call.CachePolicy == "" {
+				continue
+			}
+			cache, err := resolve(call.CachePolicy, fmt.Sprintf("http[%d].aggregate.calls[%d]", i, j))
+			if err != nil {
+				return err
+			}
+			call.Cache = cache
+		}
+	}
+	return nil
+}
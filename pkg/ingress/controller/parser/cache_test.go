@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParser_ResolveCachePolicies(t *testing.T) {
+	policy := &configurationv1beta1.ManbaCachePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "per-user", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaCachePolicySpec{
+			TTL: metav1.Duration{Duration: 90 * time.Second},
+			Keys: []configurationv1beta1.ManbaCacheKey{
+				{Source: configurationv1beta1.CacheKeyHeader, Name: "X-User-ID"},
+				{Source: configurationv1beta1.CacheKeyQuery, Name: "page"},
+			},
+		},
+	}
+	s, err := store.NewFakeStore(nil, []runtime.Object{policy})
+	assert.Nil(t, err)
+	recorder := record.NewFakeRecorder(10)
+	p := New(s, recorder)
+
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{
+				{Route: []configurationv1beta1.ManbaHTTPRoute{{CachePolicy: "per-user"}, {CachePolicy: "missing"}}},
+				{Aggregate: &configurationv1beta1.ManbaHTTPAggregate{
+					Calls: []configurationv1beta1.ManbaHTTPAggregateCall{{Name: "user", CachePolicy: "per-user"}},
+				}},
+			},
+		},
+	}
+	assert.Nil(t, p.resolveCachePolicies(ingress, ingress))
+
+	expected := &metapb.Cache{
+		Deadline: 90,
+		Keys: []metapb.Parameter{
+			{Name: "X-User-ID", Source: metapb.Header},
+			{Name: "page", Source: metapb.QueryString},
+		},
+	}
+	assert.Equal(t, expected, ingress.Spec.HTTP[0].Route[0].Cache)
+	assert.Nil(t, ingress.Spec.HTTP[0].Route[1].Cache)
+	assert.Contains(t, <-recorder.Events, ReasonCachePolicyNotFound)
+	assert.Equal(t, expected, ingress.Spec.HTTP[1].Aggregate.Calls[0].Cache)
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"fmt"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// compileRoutingCanaries adds conditions compiled from canaries of splits and mirrors to their conditions
+func compileRoutingCanaries(spec *configurationv1beta1.ManbaIngressSpec) error {
+	compile := func(routing *configurationv1beta1.ManbaHTTPRouting, path string) error {
+		if routing.Canary == nil {
+			return nil
+		}
+		conds, err := routing.Canary.ToConditions()
+		if err != nil {
+			return errors.Wrapf(err, "%s.canary", path)
+		}
+		routing.Conditions = append(routing.Conditions, conds...)
+		routing.Canary = nil
+		return nil
+	}
+	for i := range spec.HTTP {
+		rule := &spec.HTTP[i]
+		for j := range rule.Split {
+			if err := compile(&rule.Split[j], fmt.Sprintf("http[%d].split[%d]", i, j)); err != nil {
+				return err
+			}
+		}
+		for j := range rule.Mirror {
+			if err := compile(&rule.Mirror[j].ManbaHTTPRouting, fmt.Sprintf("http[%d].mirror[%d]", i, j)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyCanaries overrides rates of splits targeted by canaries of ingress
+// with the rates the canaries are at, splits rolled back to 0 are removed
+func applyCanaries(ingress *configurationv1beta1.ManbaIngress, canaries []*configurationv1beta1.ManbaCanary) {
+	for _, canary := range canaries {
+		if canary.Namespace != ingress.Namespace || canary.Spec.Ingress != ingress.Name {
+			continue
+		}
+		// the rollout is not started yet
+		if canary.Status.Phase == "" {
+			continue
+		}
+		for i := range ingress.Spec.HTTP {
+			rule := &ingress.Spec.HTTP[i]
+			var splits []configurationv1beta1.ManbaHTTPRouting
+			for _, split := range rule.Split {
+				if IsCanaryTarget(canary.Spec.Target, split.Cluster) {
+					if canary.Status.Rate <= 0 {
+						continue
+					}
+					rate := canary.Status.Rate
+					split.Rate = &rate
+				}
+				splits = append(splits, split)
+			}
+			rule.Split = splits
+		}
+	}
+}
+
+// IsCanaryTarget returns true if cluster is the target of a canary,
+// a target without port matches all ports of the subset
+func IsCanaryTarget(target, cluster configurationv1beta1.ManbaHTTPRouteCluster) bool {
+	if target.Name != cluster.Name || target.Subset != cluster.Subset {
+		return false
+	}
+	return target.Port.String() == "0" || target.Port.String() == cluster.Port.String()
+}
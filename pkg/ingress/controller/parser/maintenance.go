@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"fmt"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ReasonMaintenanceBodyNotFound is the reason of event when the ConfigMap key of maintenance body is not found
+const ReasonMaintenanceBodyNotFound = "MaintenanceBodyNotFound"
+
+// maintenance is what apis of an ingress in maintenance return
+type maintenance struct {
+	response *metapb.HTTPResult
+	// cluster has no servers, it's set if there's an allowlist,
+	// proxies are sent to it so only clients matching conditions reach clusters
+	cluster    string
+	conditions []metapb.Condition
+}
+
+// MaintenanceClusterName returns the name of cluster without servers used by maintenance of ingress
+func MaintenanceClusterName(ingress *configurationv1beta1.ManbaIngress) string {
+	return fmt.Sprintf("%s.%s.maintenance", ingress.Namespace, ingress.Name)
+}
+
+// parseMaintenance returns the maintenance of ingress and the service of its cluster,
+// both are nil if maintenance is disabled, the service is nil if there's no allowlist
+func (p *Parser) parseMaintenance(source, ingress *configurationv1beta1.ManbaIngress) (*maintenance, *Service, error) {
+	spec := ingress.Spec.Maintenance
+	if !spec.IsEnabled() {
+		return nil, nil, nil
+	}
+
+	res := &maintenance{response: spec.GetResponse()}
+	if spec.BodyFrom != nil {
+		body, err := p.maintenanceBody(ingress.Namespace, spec.BodyFrom)
+		if err != nil {
+			return nil, nil, err
+		}
+		if body != nil {
+			res.response.Body = body
+		} else {
+			glog.Warningf("maintenance body %s of manba ingress %s/%s not found, using response", spec.BodyFrom.Key, ingress.Namespace, ingress.Name)
+			p.recorder.Eventf(source, corev1.EventTypeWarning, ReasonMaintenanceBodyNotFound,
+				"key %s of ConfigMap %s/%s not found, using body of response", spec.BodyFrom.Key, ingress.Namespace, spec.BodyFrom.Name)
+		}
+	}
+
+	conds, err := spec.AllowlistConditions()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "maintenance")
+	}
+	if conds == nil {
+		return res, nil, nil
+	}
+	res.cluster = MaintenanceClusterName(ingress)
+	res.conditions = conds
+	return res, &Service{
+		Cluster: &Cluster{
+			Cluster: metapb.Cluster{
+				Name: res.cluster,
+			},
+			Namespace:   ingress.Namespace,
+			IngressName: ingress.Name,
+		},
+		Namespace: ingress.Namespace,
+	}, nil
+}
+
+// maintenanceBody returns the value of the key selected by sel, it's nil if the key is not found
+func (p *Parser) maintenanceBody(namespace string, sel *corev1.ConfigMapKeySelector) ([]byte, error) {
+	cm, err := p.store.GetConfigMap(namespace, sel.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting ConfigMap %s/%s", namespace, sel.Name)
+	}
+	if data, ok := cm.Data[sel.Key]; ok {
+		return []byte(data), nil
+	}
+	return cm.BinaryData[sel.Key], nil
+}
+
+// applyMaintenance makes apis in maintenance return its response, it's applied after proxies
+// of apis are filled, apis shared by services are applied once
+func applyMaintenance(services map[string]*Service) {
+	done := make(map[*API]bool)
+	for _, service := range services {
+		for _, api := range service.APIs {
+			if !done[api] {
+				done[api] = true
+				api.applyMaintenance()
+			}
+		}
+	}
+}
+
+func (a *API) applyMaintenance() {
+	m := a.maintenance
+	if m == nil {
+		return
+	}
+	a.DefaultValue = m.response
+
+	var key string
+	var proxy Proxy
+	for k, p := range a.Proxies {
+		key, proxy = k, p
+	}
+	// routings only change the cluster of a single proxy which calls its cluster
+	if m.cluster == "" || len(a.Proxies) != 1 || proxy.UseDefault {
+		if m.cluster != "" && len(a.Proxies) > 1 {
+			glog.Warningf("api %s has %d proxies, allowlist of maintenance is ignored", a.Name, len(a.Proxies))
+		}
+		a.UseDefault = true
+		a.Routings = nil
+		return
+	}
+
+	// requests fail on the cluster without servers and get the default value of api,
+	// except the ones routed back to the cluster of proxy
+	a.UseDefault = false
+	a.Routings = []Routing{{
+		APIName:     a.Name,
+		ClusterName: proxy.ClusterName,
+		Routing: metapb.Routing{
+			Name:        a.Name + ".maintenance",
+			TrafficRate: 100,
+			Status:      metapb.Up,
+			Strategy:    metapb.Split,
+			Conditions:  m.conditions,
+		},
+	}}
+	if key == proxy.ClusterName {
+		key = m.cluster
+	}
+	proxy.ClusterName = m.cluster
+	a.Proxies = map[string]Proxy{key: proxy}
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParser_BuildMaintenance(t *testing.T) {
+	method := "GET"
+	rate := int32(10)
+	cls := configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v1", Port: intstr.FromInt(8080)}
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Match: []configurationv1beta1.ManbaHTTPMatch{{
+					Host: "example.com",
+					Rules: []configurationv1beta1.ManbaHTTPMatchRule{{
+						URI:    configurationv1beta1.ManbaHTTPURIMatch{Pattern: "/"},
+						Method: &method,
+					}},
+				}},
+				Route: []configurationv1beta1.ManbaHTTPRoute{{Cluster: cls}},
+				Split: []configurationv1beta1.ManbaHTTPRouting{{Cluster: cls, Rate: &rate}},
+			}},
+		},
+	}
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			Subsets: []configurationv1beta1.ManbaClusterSubSet{{Name: "v1", Labels: map[string]string{"app": "test"}}},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-svc", Namespace: "default", Labels: map[string]string{"app": "test"}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-svc", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "1.1.1.1"}},
+			Ports:     []corev1.EndpointPort{{Port: 8080, Protocol: corev1.ProtocolTCP}},
+		}},
+	}
+	page := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "page", Namespace: "default"},
+		Data:       map[string]string{"index.html": "down for maintenance"},
+	}
+	fakeStore, err := store.NewFakeStore([]runtime.Object{service, endpoints, page}, []runtime.Object{ingress, cluster})
+	assert.Nil(t, err)
+	recorder := record.NewFakeRecorder(10)
+	p := New(fakeStore, recorder)
+
+	baseline, err := p.Build()
+	assert.Nil(t, err)
+	assert.Len(t, baseline.Routings, 1)
+
+	// everyone gets the response
+	ing := ingress.DeepCopy()
+	ing.Spec.Maintenance = &configurationv1beta1.ManbaMaintenance{
+		Enabled:  true,
+		BodyFrom: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "page"}, Key: "index.html"},
+	}
+	state, err := p.BuildWith(ing)
+	assert.Nil(t, err)
+	assert.Len(t, state.APIs, 1)
+	api := state.APIs[0]
+	assert.True(t, api.UseDefault)
+	assert.Equal(t, &metapb.HTTPResult{Code: 503, Body: []byte("down for maintenance")}, api.DefaultValue)
+	assert.Empty(t, state.Routings)
+
+	// the body of response is used without the key
+	ing.Spec.Maintenance.BodyFrom.Key = "missing"
+	ing.Spec.Maintenance.Response = &metapb.HTTPResult{Code: 502, Body: []byte("down")}
+	state, err = p.BuildWith(ing)
+	assert.Nil(t, err)
+	assert.Equal(t, &metapb.HTTPResult{Code: 502, Body: []byte("down")}, state.APIs[0].DefaultValue)
+	assert.Contains(t, <-recorder.Events, ReasonMaintenanceBodyNotFound)
+
+	// clients of allowlist are routed back to the cluster
+	ing.Spec.Maintenance.Allowlist = []string{"10.0.0.1"}
+	state, err = p.BuildWith(ing)
+	assert.Nil(t, err)
+	api = state.APIs[0]
+	assert.False(t, api.UseDefault)
+	assert.Equal(t, []string{"default.ing.maintenance"}, proxyClusters(api))
+	assert.Len(t, state.Routings, 1)
+	routing := state.Routings[0]
+	assert.Equal(t, "default.cls.v1.8080.svc", routing.ClusterName)
+	assert.Equal(t, metapb.Split, routing.Strategy)
+	assert.Equal(t, int32(100), routing.TrafficRate)
+	assert.Len(t, routing.Conditions, 1)
+	assert.Len(t, state.Clusters, 2)
+	for _, c := range state.Clusters {
+		if c.Name == "default.ing.maintenance" {
+			assert.Empty(t, c.Servers)
+			assert.Equal(t, "ing", c.IngressName)
+		}
+	}
+
+	// turning it off restores the apis
+	ing.Spec.Maintenance.Enabled = false
+	state, err = p.BuildWith(ing)
+	assert.Nil(t, err)
+	assert.Equal(t, baseline, state)
+}
+
+func proxyClusters(api API) []string {
+	var names []string
+	for name := range api.Proxies {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,25 @@
+package parser
+
+import (
+	"fmt"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+)
+
+// MirrorWarnings returns warnings of mirrors copying requests to the subset their rule routes to,
+// the subset gets those requests twice
+func MirrorWarnings(ingress *configurationv1beta1.ManbaIngress) []string {
+	var warnings []string
+	for i, rule := range ingress.Spec.HTTP {
+		for j, mirror := range rule.Mirror {
+			for _, route := range rule.Route {
+				if route.Cluster.Name == mirror.Cluster.Name && route.Cluster.Subset == mirror.Cluster.Subset {
+					warnings = append(warnings, fmt.Sprintf("http[%d].mirror[%d]: cluster %s subset %q is the route of the rule, it gets mirrored requests twice",
+						i, j, mirror.Cluster.Name, mirror.Cluster.Subset))
+					break
+				}
+			}
+		}
+	}
+	return warnings
+}
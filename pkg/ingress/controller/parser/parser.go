@@ -2,21 +2,51 @@ package parser
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math"
 	"reflect"
 	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 
+	"github.com/domgoer/manba-ingress/pkg/ingress/annotations"
 	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/domgoer/manba-ingress/pkg/trafficpolicy"
 	"github.com/domgoer/manba-ingress/pkg/utils"
 	"github.com/fagongzi/gateway/pkg/pb/metapb"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ReasonClusterNotFound is the reason of event when ManbaCluster referred by ManbaIngress is not found
+	ReasonClusterNotFound = "ClusterNotFound"
+	// ReasonSubsetNotFound is the reason of event when subset referred by ManbaIngress is not found
+	ReasonSubsetNotFound = "SubsetNotFound"
+	// ReasonParseFailed is the reason of event when parsing ManbaIngress or ManbaCluster fails
+	ReasonParseFailed = "ParseFailed"
+	// ReasonDuplicateAPIName is the reason of event when two match rules of ManbaIngress get the same api name
+	ReasonDuplicateAPIName = "DuplicateAPIName"
+	// ReasonMirrorToRoute is the reason of event when a mirror of ManbaIngress copies requests to the subset they are routed to
+	ReasonMirrorToRoute = "MirrorToRoute"
+	// ReasonTrafficPolicyNotFound is the reason of event when ManbaTrafficPolicy referred by ManbaCluster is not found
+	ReasonTrafficPolicyNotFound = "TrafficPolicyNotFound"
+)
+
+// kinds of objects in ObjectError
+const (
+	KindManbaIngress = "ManbaIngress"
+	KindManbaCluster = "ManbaCluster"
 )
 
 var (
@@ -50,7 +80,12 @@ type Cluster struct {
 	Servers   []*Server
 	Port      string
 	Namespace string
-	K8SSbuSet configurationv1beta1.ManbaClusterSubSet
+	// ManbaClusterName is the name of ManbaCluster which cluster is generated from
+	ManbaClusterName string
+	K8SSbuSet        configurationv1beta1.ManbaClusterSubSet
+	// IngressName is the name of ManbaIngress which cluster is generated from,
+	// it's only set on maintenance clusters, they have no servers
+	IngressName string
 }
 
 // Server contains k8s endpoint and manba server
@@ -63,10 +98,19 @@ type API struct {
 	metapb.API
 
 	Namespace string
-	// Proxies key: clusterName, value: Proxy
+	// IngressName is the name of ManbaIngress which api is generated from
+	IngressName string
+	// Created is the creation time of the ManbaIngress
+	Created metav1.Time
+	// Proxies key: attrName of the node, or clusterName if it has no attrName, value: Proxy
 	Proxies  map[string]Proxy
 	HTTPRule configurationv1beta1.ManbaHTTPRule
 	Routings []Routing
+
+	// index is the order of api in the ManbaIngress
+	index int
+	// maintenance is set if the ManbaIngress is in maintenance
+	maintenance *maintenance
 }
 
 // Plugin implements manba Plugin
@@ -84,7 +128,22 @@ type Proxy struct {
 // Parser parses Kubernetes CRDs and Ingress rules and generates a
 // Manba configuration.
 type Parser struct {
-	store store.Store
+	store           store.Store
+	recorder        record.EventRecorder
+	trafficPolicies *trafficpolicy.Resolver
+
+	lock sync.Mutex
+	// last-known-good outputs of objects, they are used when parsing the objects fails,
+	// lastIngresses key: namespace/name of ManbaIngress, lastClusters key: cluster name
+	lastIngresses map[string]map[string]*Service
+	lastClusters  map[string]*Cluster
+	errors        []ObjectError
+	// inherited are the traffic policies inherited by manba clusters in the build, key: namespace/name
+	inherited map[string][]*configurationv1beta1.TrafficPolicy
+
+	now func() time.Time
+	// nextSchedule is the earliest time a schedule of the parsed objects enters or leaves its window
+	nextSchedule time.Time
 }
 
 // ManbaState holds the configuration that should be applied to Manba.
@@ -94,36 +153,106 @@ type ManbaState struct {
 	Clusters []Cluster
 	Routings []Routing
 	Plugins  []Plugin
+	// Errors are the objects which failed to parse,
+	// their last-known-good outputs are in the state
+	Errors []ObjectError `json:",omitempty"`
+	// NextSchedule is the time the state changes because of schedules, it's nil if there's no schedule
+	NextSchedule *time.Time `json:",omitempty"`
+}
+
+// ObjectError is the error of parsing a ManbaIngress or ManbaCluster
+type ObjectError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+func (e ObjectError) Error() string {
+	return fmt.Sprintf("%s %s/%s: %s", e.Kind, e.Namespace, e.Name, e.Message)
 }
 
 type parsedIngressRules struct {
 	ServiceNameToServices map[string]*Service
 }
 
-// New returns a new parser backed with store.
-func New(s store.Store) *Parser {
-	return &Parser{store: s}
+// New returns a new parser backed with store,
+// recorder is used to report problems of the parsed resources.
+func New(s store.Store, recorder record.EventRecorder) *Parser {
+	// the resolver without a global policy can't fail
+	trafficPolicies, _ := trafficpolicy.NewResolver(s, "")
+	return &Parser{store: s, recorder: recorder, trafficPolicies: trafficPolicies, now: time.Now}
+}
+
+// SetTrafficPolicyResolver replaces the resolver of traffic policies inherited by manba clusters
+func (p *Parser) SetTrafficPolicyResolver(r *trafficpolicy.Resolver) {
+	p.trafficPolicies = r
 }
 
 // Build creates a Manba configuration from Ingress and Custom resources
 // defined in Kuberentes.
 // It throws an error if there is an error returned from client-go.
 func (p *Parser) Build() (*ManbaState, error) {
+	return p.build(p.store.ListManbaIngresses())
+}
+
+// BuildWith creates a Manba configuration as if ingress was stored,
+// ingress replaces the stored one with the same namespace and name.
+// It's used to validate ingress before it's stored.
+func (p *Parser) BuildWith(ingress *configurationv1beta1.ManbaIngress) (*ManbaState, error) {
+	ings := []*configurationv1beta1.ManbaIngress{ingress}
+	for _, ing := range p.store.ListManbaIngresses() {
+		if ing.Namespace != ingress.Namespace || ing.Name != ingress.Name {
+			ings = append(ings, ing)
+		}
+	}
+	return p.build(ings)
+}
+
+// BuildIngress creates the Manba configuration of ingress alone, it's cheaper than BuildWith
+// to validate the entities generated from ingress, but they're not checked against other ingresses
+func (p *Parser) BuildIngress(ingress *configurationv1beta1.ManbaIngress) (*ManbaState, error) {
+	return p.build([]*configurationv1beta1.ManbaIngress{ingress})
+}
+
+func (p *Parser) build(ings []*configurationv1beta1.ManbaIngress) (*ManbaState, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.errors = nil
+	p.nextSchedule = time.Time{}
+	p.inherited = make(map[string][]*configurationv1beta1.TrafficPolicy)
+
 	var state ManbaState
-	ings := p.store.ListManbaIngresses()
 	// parse ingress rules
 	parsedInfo, err := p.parseIngressRules(ings)
 	if err != nil {
 		return nil, errors.Wrap(err, "error parsing ingress rules")
 	}
 
-	for _, service := range parsedInfo.ServiceNameToServices {
-
-		err = p.fillOverride(service)
-		if err != nil {
-			return nil, errors.Wrap(err, "overriding ManbaIngress values")
+	lastClusters := make(map[string]*Cluster)
+	for name, service := range parsedInfo.ServiceNameToServices {
+		if service.Cluster.IngressName != "" {
+			continue
+		}
+		if err := p.fillServers(service); err != nil {
+			p.objectFailed(&service.Backend, KindManbaCluster, service.Namespace, service.Backend.Name, err)
+			last, ok := p.lastClusters[name]
+			if !ok {
+				delete(parsedInfo.ServiceNameToServices, name)
+				continue
+			}
+			service.Servers = last.Servers
+			service.Cluster.LoadBalance = last.LoadBalance
 		}
+		cluster := *service.Cluster
+		cluster.Servers = service.Servers
+		lastClusters[name] = &cluster
+
+		p.fillAPIs(service)
 	}
+	p.lastClusters = lastClusters
+	applyMaintenance(parsedInfo.ServiceNameToServices)
+	state.Errors = p.errors
 
 	var keysMap = make(map[string]bool)
 	// return true if everything is ok
@@ -158,6 +287,11 @@ func (p *Parser) Build() (*ManbaState, error) {
 			}
 		}
 	}
+	setPositions(state.APIs)
+	if !p.nextSchedule.IsZero() {
+		next := p.nextSchedule
+		state.NextSchedule = &next
+	}
 
 	return &state, nil
 }
@@ -169,110 +303,268 @@ func (p *Parser) parseIngressRules(
 		return ingressList[i].CreationTimestamp.Before(
 			&ingressList[j].CreationTimestamp)
 	})
-	// secretNameToSNIs := make(map[string][]string)
 	serviceNameToServices := make(map[string]*Service)
+	lastIngresses := make(map[string]map[string]*Service)
 
-	for i := 0; i < len(ingressList); i++ {
-		ingress := *ingressList[i]
-		ingressSpec := ingress.Spec
-
-		var apis []*API
+	for _, source := range ingressList {
+		key := source.Namespace + "/" + source.Name
+		services, err := p.parseIngress(source)
+		if err != nil {
+			p.objectFailed(source, KindManbaIngress, source.Namespace, source.Name, err)
+			services = p.lastIngresses[key]
+		}
+		if services != nil {
+			lastIngresses[key] = services
+		}
 
-		for j, rule := range ingressSpec.HTTP {
-			base := API{
-				API:       metapb.API{},
-				Namespace: ingress.Namespace,
-				HTTPRule:  rule,
+		// outputs of last builds are kept, so they are copied before filled,
+		// an api shared by services of the ingress stays shared
+		copies := make(map[*API]*API)
+		for name, svc := range services {
+			service, ok := serviceNameToServices[name]
+			if !ok {
+				cluster := *svc.Cluster
+				service = &Service{
+					Cluster:   &cluster,
+					Namespace: svc.Namespace,
+					Backend:   svc.Backend,
+				}
+				serviceNameToServices[name] = service
 			}
+			for _, api := range svc.APIs {
+				a, ok := copies[api]
+				if !ok {
+					c := *api
+					a = &c
+					copies[api] = a
+				}
+				service.APIs = append(service.APIs, a)
+			}
+		}
+	}
+	p.lastIngresses = lastIngresses
 
-			base.fromManbaHTTPRule(&rule)
-
-			for k, match := range rule.Match {
-
-				for g, rule := range match.Rules {
+	return &parsedIngressRules{
+		ServiceNameToServices: serviceNameToServices,
+	}, nil
+}
 
-					api := base
-					api.Name = fmt.Sprintf("%s.%s.%d%d%d%d", ingress.Namespace, ingress.Name, i, j, k, g)
-					api.Domain = match.Host
-					api.MatchRule = metapb.MatchRule(metapb.MatchRule_value[rule.MatchType])
-					api.Position = uint32(g + 1)
-					api.Status = metapb.Up
+// parseIngress returns the services referenced by routes of source,
+// they only contain apis of source
+func (p *Parser) parseIngress(source *configurationv1beta1.ManbaIngress) (map[string]*Service, error) {
+	services := make(map[string]*Service)
+	for _, warning := range MirrorWarnings(source) {
+		p.recorder.Event(source, corev1.EventTypeWarning, ReasonMirrorToRoute, warning)
+	}
+	// objects stored before defaulting was enabled have no defaults
+	ingress := source.DeepCopy()
+	configurationv1beta1.SetManbaIngressDefaults(ingress)
+	if err := p.applySchedules(ingress); err != nil {
+		return nil, err
+	}
+	if annotations.NormalizeSplitWeights(ingress) {
+		normalizeSplitWeights(&ingress.Spec)
+	}
+	applyCanaries(ingress, p.store.ListManbaCanaries())
+	previews, err := p.resolveSubsets(ingress)
+	if err != nil {
+		return nil, err
+	}
+	if err := compileRoutingCanaries(&ingress.Spec); err != nil {
+		return nil, err
+	}
+	if err := p.resolveCachePolicies(source, ingress); err != nil {
+		return nil, err
+	}
+	maintenance, maintenanceService, err := p.parseMaintenance(source, ingress)
+	if err != nil {
+		return nil, err
+	}
+	if maintenanceService != nil {
+		services[maintenanceService.Cluster.Name] = maintenanceService
+	}
+	ingressSpec := ingress.Spec
+
+	var apis []*API
+	apiNames := make(map[string]bool)
+
+	for _, rule := range ingressSpec.HTTP {
+		base := API{
+			API:         metapb.API{},
+			Namespace:   ingress.Namespace,
+			IngressName: ingress.Name,
+			Created:     ingress.CreationTimestamp,
+			HTTPRule:    rule,
+		}
 
-					urlPattern := rule.URI.Pattern
-					if urlPattern == "" {
-						urlPattern = "/"
-					}
-					api.URLPattern = urlPattern
+		base.fromManbaHTTPRule(&rule)
 
-					if rule.Method != nil {
-						api.Method = *rule.Method
-					} else {
-						api.Method = "*"
-					}
-					_, _, err := p.getTLS(api.Domain, ingress.Namespace, ingressSpec.TLS)
-					if err != nil {
-						glog.Errorf("getting secret failed, err: %v", err)
-					} else {
-						// TODO: set tls
-					}
+		for _, match := range rule.Match {
 
-					// append to list
-					apis = append(apis, &api)
+			for _, matchRule := range match.Rules {
 
+				name := APIName(ingress, &rule, match.Host, &matchRule)
+				if apiNames[name] {
+					glog.Warningf("duplicate api name %s in manba ingress %s/%s", name, ingress.Namespace, ingress.Name)
+					p.recorder.Eventf(source, corev1.EventTypeWarning, ReasonDuplicateAPIName,
+						"api %s is defined more than once, name the rules to tell them apart", name)
+					continue
+				}
+				apiNames[name] = true
+				rule := matchRule
+
+				api := base
+				api.Name = name
+				api.Domain = match.Host
+				api.MatchRule = metapb.MatchRule(metapb.MatchRule_value[rule.MatchType])
+				api.index = len(apis)
+				api.maintenance = maintenance
+				api.Status = metapb.Up
+				api.applyRateLimit(ingress)
+
+				api.URLPattern = rule.URI.Pattern
+				api.Method = *rule.Method
+				_, _, err := p.getTLS(api.Domain, ingress.Namespace, ingressSpec.TLS)
+				if err != nil {
+					glog.Errorf("getting secret failed, err: %v", err)
+				} else {
+					// TODO: set tls
 				}
 
+				// append to list
+				apis = append(apis, &api)
+
 			}
 
-			for _, route := range rule.Route {
+		}
 
-				cls := route.Cluster
+		for _, cls := range routeClusters(&rule) {
 
-				serviceName := fmt.Sprintf("%s.%s.%s.%s.svc", ingress.Namespace, cls.Name, cls.Subset, cls.Port.String())
+			serviceName := clusterServiceName(ingress.Namespace, cls)
 
-				service, ok := serviceNameToServices[serviceName]
-				if !ok {
-					cluster, err := p.store.GetManbaCluster(ingress.Namespace, cls.Name)
-					if err != nil {
-						glog.Errorf("getting manba cluster: %v", err)
-						continue
-					}
-					subSet, err := p.getClusterSubset(cluster.Spec.Subsets, cls.Subset)
-					if err != nil {
-						glog.Errorf("getting manba subset: %v", err)
-						continue
-					}
-
-					if subSet.TrafficPolicy == nil {
-						subSet.TrafficPolicy = cluster.Spec.TrafficPolicy
-					}
-
-					service = &Service{
-						Cluster: &Cluster{
-							Cluster: metapb.Cluster{
-								Name: serviceName,
-							},
-							Port:      cls.Port.String(),
-							Namespace: ingress.Namespace,
-							K8SSbuSet: subSet,
-						},
-						Namespace: ingress.Namespace,
-						Backend:   *cluster,
-					}
+			service, ok := services[serviceName]
+			if !ok {
+				var err error
+				service, err = p.newService(source, serviceName, cls)
+				if err != nil {
+					return nil, err
+				}
+				if service == nil {
+					continue
 				}
+			}
+			service.APIs = append(service.APIs, apis...)
 
-				service.APIs = append(service.APIs, apis...)
+			services[serviceName] = service
+		}
 
-				serviceNameToServices[serviceName] = service
-			}
+	}
 
+	// preview subsets only receive traffic of splits, so they have no apis
+	for _, cls := range previews {
+		serviceName := clusterServiceName(ingress.Namespace, cls)
+		if _, ok := services[serviceName]; ok {
+			continue
+		}
+		service, err := p.newService(source, serviceName, cls)
+		if err != nil {
+			return nil, err
+		}
+		if service != nil {
+			services[serviceName] = service
 		}
 	}
 
-	return &parsedIngressRules{
-		ServiceNameToServices: serviceNameToServices,
+	return services, nil
+}
+
+// routeClusters returns the clusters which apis of rule call
+func routeClusters(rule *configurationv1beta1.ManbaHTTPRule) []configurationv1beta1.ManbaHTTPRouteCluster {
+	var clusters []configurationv1beta1.ManbaHTTPRouteCluster
+	for _, route := range rule.Route {
+		clusters = append(clusters, route.Cluster)
+	}
+	for _, call := range rule.Aggregate.GetCalls() {
+		clusters = append(clusters, call.Cluster)
+	}
+	return clusters
+}
+
+// clusterServiceName returns the name of manba cluster generated from subset of cls
+func clusterServiceName(namespace string, cls configurationv1beta1.ManbaHTTPRouteCluster) string {
+	return fmt.Sprintf("%s.%s.%s.%s.svc", namespace, cls.Name, cls.Subset, cls.Port.String())
+}
+
+// newService returns the service of subset referenced by cls, it's nil if
+// the cluster or the subset is not found
+func (p *Parser) newService(source *configurationv1beta1.ManbaIngress, serviceName string, cls configurationv1beta1.ManbaHTTPRouteCluster) (*Service, error) {
+	cluster, err := p.store.GetManbaCluster(source.Namespace, cls.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "getting ManbaCluster %s/%s", source.Namespace, cls.Name)
+	}
+	if err != nil {
+		glog.Errorf("getting manba cluster: %v", err)
+		p.recorder.Eventf(source, corev1.EventTypeWarning, ReasonClusterNotFound,
+			"ManbaCluster %s/%s not found: %v", source.Namespace, cls.Name, err)
+		return nil, nil
+	}
+	subSet, err := p.getClusterSubset(cluster.Spec.Subsets, cls.Subset)
+	if err != nil {
+		glog.Errorf("getting manba subset: %v", err)
+		p.recorder.Eventf(source, corev1.EventTypeWarning, ReasonSubsetNotFound,
+			"subset %s not found in ManbaCluster %s/%s", cls.Subset, source.Namespace, cls.Name)
+		return nil, nil
+	}
+
+	inherited, err := p.inheritedPolicies(cluster)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ManbaCluster %s/%s", source.Namespace, cls.Name)
+	}
+	subSet.TrafficPolicy = trafficpolicy.Effective(cluster, &subSet, inherited)
+	schedule, err := p.activeSchedule(subSet.Schedules)
+	if err != nil {
+		return nil, errors.Wrapf(err, "subset %s of ManbaCluster %s/%s", subSet.Name, source.Namespace, cls.Name)
+	}
+	if schedule != nil && schedule.TrafficPolicy != nil {
+		subSet.TrafficPolicy = configurationv1beta1.MergeTrafficPolicies(schedule.TrafficPolicy, subSet.TrafficPolicy)
+	}
+
+	return &Service{
+		Cluster: &Cluster{
+			Cluster: metapb.Cluster{
+				Name: serviceName,
+			},
+			Port:             cls.Port.String(),
+			Namespace:        source.Namespace,
+			ManbaClusterName: cls.Name,
+			K8SSbuSet:        subSet,
+		},
+		Namespace: source.Namespace,
+		Backend:   *cluster,
 	}, nil
 }
 
+// inheritedPolicies resolves the traffic policies inherited by cluster once in a build,
+// a missing referenced policy and ignored options are reported once no matter how many routes use the cluster
+func (p *Parser) inheritedPolicies(cluster *configurationv1beta1.ManbaCluster) ([]*configurationv1beta1.TrafficPolicy, error) {
+	key := cluster.Namespace + "/" + cluster.Name
+	if inherited, ok := p.inherited[key]; ok {
+		return inherited, nil
+	}
+	inherited, missing, err := p.trafficPolicies.Inherited(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if missing {
+		glog.Warningf("traffic policy %s of manba cluster %s not found", cluster.Spec.TrafficPolicyRef, key)
+		p.recorder.Eventf(cluster, corev1.EventTypeWarning, ReasonTrafficPolicyNotFound,
+			"ManbaTrafficPolicy %s/%s not found, it's not inherited", cluster.Namespace, cluster.Spec.TrafficPolicyRef)
+	}
+	p.reportRateLimitOptions(cluster, inherited)
+	p.inherited[key] = inherited
+	return inherited, nil
+}
+
 func (p *Parser) getTLS(host, namespace string, tls networkingv1beta1.IngressTLS) (certData []byte, keyData []byte, err error) {
 	for _, h := range tls.Hosts {
 		if host == h {
@@ -290,7 +582,21 @@ func (p *Parser) getTLS(host, namespace string, tls networkingv1beta1.IngressTLS
 	return
 }
 
-func (p *Parser) fillOverride(service *Service) error {
+// objectFailed reports the error of parsing obj
+func (p *Parser) objectFailed(obj runtime.Object, kind, namespace, name string, err error) {
+	glog.Errorf("parsing %s %s/%s: %v, using its last-known-good output", kind, namespace, name, err)
+	p.recorder.Eventf(obj, corev1.EventTypeWarning, ReasonParseFailed,
+		"parsing failed, using last-known-good output: %v", err)
+	p.errors = append(p.errors, ObjectError{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Message:   err.Error(),
+	})
+}
+
+// fillServers fills servers of service from endpoints of its subset
+func (p *Parser) fillServers(service *Service) error {
 	cls := service.Cluster
 	namespace := cls.Namespace
 
@@ -312,43 +618,68 @@ func (p *Parser) fillOverride(service *Service) error {
 
 		// fill cluster
 		if traffic.LoadBalancer != nil {
-			service.Cluster.LoadBalance = metapb.LoadBalance(metapb.LoadBalance_value[*traffic.LoadBalancer])
+			lb, ok := metapb.LoadBalance_value[*traffic.LoadBalancer]
+			if !ok {
+				glog.Warningf("unknown load balancer %q of cluster %s, using %s", *traffic.LoadBalancer, cls.Name, metapb.LoadBalance(lb))
+			}
+			service.Cluster.LoadBalance = metapb.LoadBalance(lb)
 		}
 
 	}
 
 	service.Servers = servers
+	return nil
+}
 
-	// fill api nodes
+// fillAPIs fills dispatch nodes and routings of apis of service,
+// the nodes are the ones of routes and aggregate calls to the cluster of service
+func (p *Parser) fillAPIs(service *Service) {
 	for _, api := range service.APIs {
 		rule := api.HTTPRule
+		addProxy := func(proxy Proxy) {
+			// ini map
+			if api.Proxies == nil {
+				api.Proxies = make(map[string]Proxy)
+			}
+			key := proxy.AttrName
+			if key == "" {
+				key = proxy.ClusterName
+			}
+			if _, ok := api.Proxies[key]; !ok {
+				api.Proxies[key] = proxy
+			}
+		}
 		for _, r := range api.HTTPRule.Route {
+			if clusterServiceName(api.Namespace, r.Cluster) != service.Cluster.Name {
+				continue
+			}
 			proxy := Proxy{
 				ClusterName: service.Cluster.Name,
 			}
 
 			proxy.fromManbaHTTPRule(&rule)
 			proxy.fromManbaHTTPRoute(&r)
-
-			// ini map
-			if api.Proxies == nil {
-				api.Proxies = make(map[string]Proxy)
+			addProxy(proxy)
+		}
+		for _, call := range api.HTTPRule.Aggregate.GetCalls() {
+			if clusterServiceName(api.Namespace, call.Cluster) != service.Cluster.Name {
+				continue
 			}
-			if _, ok := api.Proxies[proxy.ClusterName]; !ok {
-				api.Proxies[proxy.ClusterName] = proxy
+			proxy := Proxy{
+				ClusterName: service.Cluster.Name,
 			}
+
+			proxy.fromManbaHTTPRule(&rule)
+			proxy.fromManbaHTTPAggregateCall(&call)
+			addProxy(proxy)
 		}
 
 		parseRouting := func(m configurationv1beta1.ManbaHTTPRouting, override func(Routing) Routing) Routing {
-			var rate int32 = 100
-			if m.Rate != nil {
-				rate = *m.Rate
-			}
 			return override(Routing{
 				APIName:     api.Name,
-				ClusterName: fmt.Sprintf("%s.%s.%s.%s.svc", api.Namespace, m.Cluster.Name, m.Cluster.Subset, m.Cluster.Port.String()),
+				ClusterName: clusterServiceName(api.Namespace, m.Cluster),
 				Routing: metapb.Routing{
-					TrafficRate: rate,
+					TrafficRate: *m.Rate,
 					Status:      metapb.Up,
 					Conditions:  m.Conditions,
 				},
@@ -356,7 +687,13 @@ func (p *Parser) fillOverride(service *Service) error {
 		}
 
 		for i, mirror := range api.HTTPRule.Mirror {
-			api.Routings = append(api.Routings, parseRouting(mirror, func(r Routing) Routing {
+			if !mirror.CopiesMethod(api.Method) {
+				continue
+			}
+			routing := mirror.ManbaHTTPRouting
+			sample := mirror.GetSample()
+			routing.Rate = &sample
+			api.Routings = append(api.Routings, parseRouting(routing, func(r Routing) Routing {
 				r.Name = fmt.Sprintf("%s.mirror.%d", api.Name, i)
 				r.Strategy = metapb.Copy
 				return r
@@ -370,7 +707,6 @@ func (p *Parser) fillOverride(service *Service) error {
 			}))
 		}
 	}
-	return nil
 }
 
 func (p *Parser) getServiceEndpoints(subset configurationv1beta1.ManbaClusterSubSet, namespace string,
@@ -383,37 +719,16 @@ func (p *Parser) getServiceEndpoints(subset configurationv1beta1.ManbaClusterSub
 	}
 
 	for _, svc := range svcs {
-		var servicePort corev1.ServicePort
-
 		svcKey := svc.Namespace + "/" + svc.Name
 
-		for _, port := range svc.Spec.Ports {
-			// targetPort could be a string, use the name or the port (int)
-			if strconv.Itoa(int(port.Port)) == backendPort ||
-				port.TargetPort.String() == backendPort ||
-				port.Name == backendPort {
-				servicePort = port
-				break
-			}
-		}
-
-		// Ingress with an ExternalName service and no port defined in the service.
-		if len(svc.Spec.Ports) == 0 &&
+		servicePort, ok := ServicePort(svc, backendPort)
+		if !ok && len(svc.Spec.Ports) == 0 &&
 			svc.Spec.Type == corev1.ServiceTypeExternalName {
-			externalPort, err := strconv.Atoi(backendPort)
-			if err != nil {
-				glog.Warningf("only numeric ports are allowed in"+
-					" ExternalName services: %v is not valid as a TCP/UDP port",
-					backendPort)
+			glog.Warningf("only numeric ports are allowed in"+
+				" ExternalName services: %v is not valid as a TCP/UDP port",
+				backendPort)
 
-				return servers, nil
-			}
-
-			servicePort = corev1.ServicePort{
-				Protocol:   corev1.ProtocolTCP,
-				Port:       int32(externalPort),
-				TargetPort: intstr.FromString(backendPort),
-			}
+			return servers, nil
 		}
 
 		endpoints = getEndpoints(svc, &servicePort,
@@ -439,6 +754,120 @@ func (p *Parser) getServiceEndpoints(subset configurationv1beta1.ManbaClusterSub
 	return servers, nil
 }
 
+// APIName returns the name of manba api generated from a match rule of ingress.
+// Names of rule and match rule are used if they are set, otherwise the match rule
+// is identified by a hash of what it matches, so reordering rules keeps the name
+func APIName(ingress *configurationv1beta1.ManbaIngress, rule *configurationv1beta1.ManbaHTTPRule,
+	host string, matchRule *configurationv1beta1.ManbaHTTPMatchRule) string {
+	name := fmt.Sprintf("%s.%s", ingress.Namespace, ingress.Name)
+	if rule.Name != "" {
+		name += "." + rule.Name
+	}
+	if matchRule.Name != "" {
+		return name + "." + matchRule.Name
+	}
+
+	var method string
+	if matchRule.Method != nil {
+		method = *matchRule.Method
+	}
+	h := fnv.New32a()
+	for _, field := range []string{host, matchRule.URI.Pattern, method, matchRule.MatchType} {
+		h.Write([]byte(field))
+		// separator keeps ("ab", "c") and ("a", "bc") apart
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s.%08x", name, h.Sum32())
+}
+
+// ServicePort returns the port of svc which backendPort refers to,
+// backendPort could be the port, target port or name of the port
+func ServicePort(svc *corev1.Service, backendPort string) (corev1.ServicePort, bool) {
+	for _, port := range svc.Spec.Ports {
+		// targetPort could be a string, use the name or the port (int)
+		if strconv.Itoa(int(port.Port)) == backendPort ||
+			port.TargetPort.String() == backendPort ||
+			port.Name == backendPort {
+			return port, true
+		}
+	}
+
+	// Ingress with an ExternalName service and no port defined in the service.
+	if len(svc.Spec.Ports) == 0 &&
+		svc.Spec.Type == corev1.ServiceTypeExternalName {
+		externalPort, err := strconv.Atoi(backendPort)
+		if err != nil {
+			return corev1.ServicePort{}, false
+		}
+
+		return corev1.ServicePort{
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(externalPort),
+			TargetPort: intstr.FromString(backendPort),
+		}, true
+	}
+	return corev1.ServicePort{}, false
+}
+
+// normalizeSplitWeights scales rates of splits in each rule, which are
+// relative weights, to traffic rates adding up to 100.
+// Every positive weight gets at least 1 percent of traffic.
+func normalizeSplitWeights(spec *configurationv1beta1.ManbaIngressSpec) {
+	for i := range spec.HTTP {
+		splits := spec.HTTP[i].Split
+
+		var total int64
+		var weighted []int
+		for j, split := range splits {
+			if split.Rate != nil && *split.Rate > 0 {
+				total += int64(*split.Rate)
+				weighted = append(weighted, j)
+			}
+		}
+		if len(weighted) == 0 || len(weighted) > 100 {
+			continue
+		}
+
+		rates := make(map[int]int32, len(weighted))
+		remainders := make(map[int]int64, len(weighted))
+		var sum int32
+		for _, j := range weighted {
+			exact := int64(*splits[j].Rate) * 100
+			rates[j] = int32(exact / total)
+			remainders[j] = exact % total
+			if rates[j] == 0 {
+				rates[j] = 1
+				remainders[j] = 0
+			}
+			sum += rates[j]
+		}
+
+		// largest remainders get the rest, largest rates give back the excess
+		sort.SliceStable(weighted, func(a, b int) bool {
+			return remainders[weighted[a]] > remainders[weighted[b]]
+		})
+		for k := 0; sum < 100; k++ {
+			rates[weighted[k%len(weighted)]]++
+			sum++
+		}
+		for sum > 100 {
+			largest := weighted[0]
+			for _, j := range weighted {
+				if rates[j] > rates[largest] {
+					largest = j
+				}
+			}
+			rates[largest]--
+			sum--
+		}
+
+		for j, rate := range rates {
+			r := rate
+			splits[j].Rate = &r
+		}
+	}
+}
+
 // getEndpoints returns a list of <endpoint ip>:<port> for a given service/target port combination.
 func getEndpoints(
 	s *corev1.Service,
@@ -583,3 +1012,21 @@ func (p *Proxy) fromManbaHTTPRoute(route *configurationv1beta1.ManbaHTTPRoute) {
 
 	p.DispatchNode = node
 }
+
+// fromManbaHTTPAggregateCall sets the node of call, its default value is only used when the call fails
+func (p *Proxy) fromManbaHTTPAggregateCall(call *configurationv1beta1.ManbaHTTPAggregateCall) {
+	node := p.DispatchNode
+	node.AttrName = call.Name
+	node.BatchIndex = call.Batch
+	node.WriteTimeout = call.WriteTimeout
+	node.ReadTimeout = call.ReadTimeout
+	node.DefaultValue = call.DefaultValue
+	node.Cache = call.Cache
+	node.Validations = call.Match.ToManbaValidations()
+
+	if call.Rewrite != nil && call.Rewrite.URI != "" {
+		node.URLRewrite = call.Rewrite.URI
+	}
+
+	p.DispatchNode = node
+}
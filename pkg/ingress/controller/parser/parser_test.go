@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/fagongzi/gateway/pkg/pb/metapb"
 
@@ -12,6 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 
 	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
 	"github.com/domgoer/manba-ingress/pkg/ingress/store"
@@ -52,16 +57,18 @@ func TestParser_Build(t *testing.T) {
 							},
 						},
 					},
-					Mirror: []configurationv1beta1.ManbaHTTPRouting{
+					Mirror: []configurationv1beta1.ManbaHTTPMirror{
 						{
-							Cluster: configurationv1beta1.ManbaHTTPRouteCluster{
-								Name:   "test-cls",
-								Subset: "v1",
-								Port: intstr.IntOrString{
-									IntVal: 8080,
+							ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{
+								Cluster: configurationv1beta1.ManbaHTTPRouteCluster{
+									Name:   "test-cls",
+									Subset: "v1",
+									Port: intstr.IntOrString{
+										IntVal: 8080,
+									},
 								},
+								Rate: &rate,
 							},
-							Rate: &rate,
 						},
 					},
 				},
@@ -144,14 +151,15 @@ func TestParser_Build(t *testing.T) {
 		APIs: []API{
 			{
 				API: metapb.API{
-					Name:       "default.test-ing.0000",
+					Name:       "default.test-ing.ea7ce587",
 					URLPattern: "/",
 					Method:     method,
 					Domain:     "test",
 					Status:     metapb.Up,
 					Position:   1,
 				},
-				Namespace: "default",
+				Namespace:   "default",
+				IngressName: "test-ing",
 				Proxies: map[string]Proxy{
 					"default.test-cls.v1.8080.svc": {
 						ClusterName:  "default.test-cls.v1.8080.svc",
@@ -184,29 +192,31 @@ func TestParser_Build(t *testing.T) {
 							},
 						},
 					},
-					Mirror: []configurationv1beta1.ManbaHTTPRouting{
+					Mirror: []configurationv1beta1.ManbaHTTPMirror{
 						{
-							Cluster: configurationv1beta1.ManbaHTTPRouteCluster{
-								Name:   "test-cls",
-								Subset: "v1",
-								Port: intstr.IntOrString{
-									IntVal: 8080,
+							ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{
+								Cluster: configurationv1beta1.ManbaHTTPRouteCluster{
+									Name:   "test-cls",
+									Subset: "v1",
+									Port: intstr.IntOrString{
+										IntVal: 8080,
+									},
 								},
+								Rate: &rate,
 							},
-							Rate: &rate,
 						},
 					},
 				},
 				Routings: []Routing{
 					{
-						APIName:     "default.test-ing.0000",
+						APIName:     "default.test-ing.ea7ce587",
 						ClusterName: "default.test-cls.v1.8080.svc",
 						Routing: metapb.Routing{
 							Conditions:  nil,
 							Strategy:    metapb.Copy,
 							TrafficRate: rate,
 							Status:      metapb.Up,
-							Name:        "default.test-ing.0000.mirror.0",
+							Name:        "default.test-ing.ea7ce587.mirror.0",
 						},
 					},
 				},
@@ -232,15 +242,17 @@ func TestParser_Build(t *testing.T) {
 					Name:        "default.test-cls.v1.8080.svc",
 					LoadBalance: metapb.RoundRobin,
 				},
-				Port:      "8080",
-				Namespace: "default",
+				Port:             "8080",
+				Namespace:        "default",
+				ManbaClusterName: "test-cls",
 				K8SSbuSet: configurationv1beta1.ManbaClusterSubSet{
 					Name: "v1",
 					Labels: map[string]string{
 						"app": "test",
 					},
 					TrafficPolicy: &configurationv1beta1.TrafficPolicy{
-						MaxQPS: 500,
+						LoadBalancer: &configurationv1beta1.DefaultLoadBalancer,
+						MaxQPS:       500,
 					},
 				},
 				Servers: []*Server{
@@ -261,13 +273,13 @@ func TestParser_Build(t *testing.T) {
 		},
 		Routings: []Routing{
 			{
-				APIName:     "default.test-ing.0000",
+				APIName:     "default.test-ing.ea7ce587",
 				ClusterName: "default.test-cls.v1.8080.svc",
 				Routing: metapb.Routing{
 					Strategy:    metapb.Copy,
 					TrafficRate: rate,
 					Status:      metapb.Up,
-					Name:        "default.test-ing.0000.mirror.0",
+					Name:        "default.test-ing.ea7ce587.mirror.0",
 				},
 			},
 		},
@@ -282,9 +294,455 @@ func TestParser_Build(t *testing.T) {
 	fakeStore, err := store.NewFakeStore([]runtime.Object{service, endpoint, secret}, []runtime.Object{ingress, cluster})
 	assert.Nil(t, err)
 
-	parser := New(fakeStore)
+	parser := New(fakeStore, &record.FakeRecorder{})
 
 	ms, err := parser.Build()
 	assert.Nil(t, err)
 	assert.Equal(t, ms, want)
 }
+
+func TestNormalizeSplitWeights(t *testing.T) {
+	tests := []struct {
+		weights []int32
+		want    []int32
+	}{
+		{[]int32{1, 1}, []int32{50, 50}},
+		{[]int32{1, 1, 1}, []int32{34, 33, 33}},
+		{[]int32{3, 1}, []int32{75, 25}},
+		{[]int32{1000, 1}, []int32{99, 1}},
+		{[]int32{0, 2}, []int32{0, 100}},
+	}
+	for _, tt := range tests {
+		spec := configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{}},
+		}
+		for i := range tt.weights {
+			spec.HTTP[0].Split = append(spec.HTTP[0].Split, configurationv1beta1.ManbaHTTPRouting{Rate: &tt.weights[i]})
+		}
+		normalizeSplitWeights(&spec)
+
+		var got []int32
+		for _, split := range spec.HTTP[0].Split {
+			got = append(got, *split.Rate)
+		}
+		assert.Equal(t, tt.want, got, "weights %v", tt.weights)
+	}
+}
+
+func TestAPIName(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ing",
+			Namespace: "default",
+		},
+	}
+	rule := &configurationv1beta1.ManbaHTTPRule{}
+	newMatchRule := func(pattern string, method *string) *configurationv1beta1.ManbaHTTPMatchRule {
+		return &configurationv1beta1.ManbaHTTPMatchRule{
+			URI:    configurationv1beta1.ManbaHTTPURIMatch{Pattern: pattern},
+			Method: method,
+		}
+	}
+
+	hashed := APIName(ingress, rule, "example.com", newMatchRule("/api", str("GET")))
+	assert.Regexp(t, `^default\.test-ing\.[0-9a-f]{8}$`, hashed)
+	assert.Equal(t, hashed, APIName(ingress, rule, "example.com", newMatchRule("/api", str("GET"))))
+	assert.NotEqual(t, hashed, APIName(ingress, rule, "example.com", newMatchRule("/api", str("POST"))))
+	assert.NotEqual(t, APIName(ingress, rule, "a", newMatchRule("bc", nil)), APIName(ingress, rule, "ab", newMatchRule("c", nil)))
+
+	named := newMatchRule("/api", str("GET"))
+	named.Name = "list"
+	assert.Equal(t, "default.test-ing.list", APIName(ingress, rule, "example.com", named))
+	rule.Name = "users"
+	assert.Equal(t, "default.test-ing.users.list", APIName(ingress, rule, "example.com", named))
+	assert.Equal(t, "default.test-ing.users."+hashed[len("default.test-ing."):],
+		APIName(ingress, rule, "example.com", newMatchRule("/api", str("GET"))))
+}
+
+// failingStore fails to list services or get manba clusters on demand
+type failingStore struct {
+	store.Store
+	failServices bool
+	failClusters map[string]bool
+}
+
+func (s *failingStore) ListServices(namespace string, label map[string]string) ([]*corev1.Service, error) {
+	if s.failServices {
+		return nil, errors.New("listing services failed")
+	}
+	return s.Store.ListServices(namespace, label)
+}
+
+func (s *failingStore) GetManbaCluster(namespace, name string) (*configurationv1beta1.ManbaCluster, error) {
+	if s.failClusters[name] {
+		return nil, errors.New("getting cluster failed")
+	}
+	return s.Store.GetManbaCluster(namespace, name)
+}
+
+func TestParser_BuildIsolatesErrors(t *testing.T) {
+	method := "GET"
+	newIngress := func(name, cluster string) *configurationv1beta1.ManbaIngress {
+		return &configurationv1beta1.ManbaIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP: []configurationv1beta1.ManbaHTTPRule{{
+					Match: []configurationv1beta1.ManbaHTTPMatch{{
+						Host: "example.com",
+						Rules: []configurationv1beta1.ManbaHTTPMatchRule{{
+							URI:    configurationv1beta1.ManbaHTTPURIMatch{Pattern: "/" + name},
+							Method: &method,
+						}},
+					}},
+					Route: []configurationv1beta1.ManbaHTTPRoute{{
+						Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: cluster, Subset: "v1", Port: intstr.FromInt(8080)},
+					}},
+				}},
+			},
+		}
+	}
+	newCluster := func(name string) *configurationv1beta1.ManbaCluster {
+		return &configurationv1beta1.ManbaCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: configurationv1beta1.ManbaClusterSpec{
+				Subsets: []configurationv1beta1.ManbaClusterSubSet{{
+					Name:          "v1",
+					Labels:        map[string]string{"app": "test"},
+					TrafficPolicy: &configurationv1beta1.TrafficPolicy{MaxQPS: 100},
+				}},
+			},
+		}
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-svc", Namespace: "default", Labels: map[string]string{"app": "test"}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-svc", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "1.1.1.1"}},
+			Ports:     []corev1.EndpointPort{{Port: 8080, Protocol: corev1.ProtocolTCP}},
+		}},
+	}
+	fakeStore, err := store.NewFakeStore([]runtime.Object{service, endpoints},
+		[]runtime.Object{newIngress("a", "cls-a"), newIngress("b", "cls-b"), newCluster("cls-a"), newCluster("cls-b")})
+	assert.Nil(t, err)
+	s := &failingStore{Store: fakeStore, failClusters: map[string]bool{}}
+	parser := New(s, &record.FakeRecorder{})
+
+	apiNames := func(state *ManbaState) []string {
+		var names []string
+		for _, api := range state.APIs {
+			names = append(names, api.URLPattern)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	state, err := parser.Build()
+	assert.Nil(t, err)
+	assert.Empty(t, state.Errors)
+	assert.Equal(t, []string{"/a", "/b"}, apiNames(state))
+	assert.Len(t, state.Servers, 1)
+
+	// broken ingress keeps its last output
+	s.failClusters["cls-b"] = true
+	state, err = parser.Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/a", "/b"}, apiNames(state))
+	assert.Len(t, state.Errors, 1)
+	assert.Equal(t, KindManbaIngress, state.Errors[0].Kind)
+	assert.Equal(t, "b", state.Errors[0].Name)
+
+	// broken clusters keep their last servers
+	s.failClusters["cls-b"] = false
+	s.failServices = true
+	state, err = parser.Build()
+	assert.Nil(t, err)
+	assert.Len(t, state.Errors, 2)
+	assert.Equal(t, KindManbaCluster, state.Errors[0].Kind)
+	assert.Len(t, state.Clusters, 2)
+	assert.Len(t, state.Servers, 1)
+
+	// nothing to keep without a successful parse
+	s.failServices = false
+	s.failClusters["cls-b"] = true
+	state, err = New(s, &record.FakeRecorder{}).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/a"}, apiNames(state))
+	assert.Len(t, state.Errors, 1)
+}
+
+func TestParser_BuildReportsMissingTrafficPolicyOnce(t *testing.T) {
+	method := "GET"
+	var rules []configurationv1beta1.ManbaHTTPRule
+	// routes to other ports of the cluster are other services
+	for i, pattern := range []string{"/a", "/b", "/c"} {
+		rules = append(rules, configurationv1beta1.ManbaHTTPRule{
+			Match: []configurationv1beta1.ManbaHTTPMatch{{
+				Host: "example.com",
+				Rules: []configurationv1beta1.ManbaHTTPMatchRule{{
+					URI:    configurationv1beta1.ManbaHTTPURIMatch{Pattern: pattern},
+					Method: &method,
+				}},
+			}},
+			Route: []configurationv1beta1.ManbaHTTPRoute{{
+				Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v1", Port: intstr.FromInt(8080 + i)},
+			}},
+		})
+	}
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+		Spec:       configurationv1beta1.ManbaIngressSpec{HTTP: rules},
+	}
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			TrafficPolicyRef: "missing",
+			Subsets:          []configurationv1beta1.ManbaClusterSubSet{{Name: "v1", Labels: map[string]string{"app": "test"}}},
+		},
+	}
+	fakeStore, err := store.NewFakeStore(nil, []runtime.Object{ingress, cluster})
+	assert.Nil(t, err)
+	recorder := record.NewFakeRecorder(10)
+	p := New(fakeStore, recorder)
+
+	for i := 0; i < 2; i++ {
+		state, err := p.Build()
+		assert.Nil(t, err)
+		assert.Len(t, state.APIs, 3)
+		// every build reports the cluster once
+		assert.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, ReasonTrafficPolicyNotFound)
+	}
+}
+
+func TestPatternSpecificity(t *testing.T) {
+	assert.Equal(t, SpecificityExact, PatternSpecificity("^/api/users$"))
+	assert.Equal(t, SpecificityExact, PatternSpecificity("/api/users$"))
+	assert.Equal(t, SpecificityPrefix, PatternSpecificity("/api"))
+	assert.Equal(t, SpecificityPrefix, PatternSpecificity("^/api/.*"))
+	assert.Equal(t, SpecificityRegex, PatternSpecificity("^/api/[0-9]+$"))
+	assert.Equal(t, SpecificityRegex, PatternSpecificity("/api/(users|groups)"))
+}
+
+func TestSetPositions(t *testing.T) {
+	older := metav1.NewTime(time.Unix(1000, 0))
+	newer := metav1.NewTime(time.Unix(2000, 0))
+	newAPI := func(name string, priority int32, pattern string, created metav1.Time, index int) API {
+		api := API{
+			Namespace:   "default",
+			IngressName: name,
+			Created:     created,
+			HTTPRule:    configurationv1beta1.ManbaHTTPRule{Priority: priority},
+			index:       index,
+		}
+		api.Name = fmt.Sprintf("%s.%d", name, index)
+		api.URLPattern = pattern
+		return api
+	}
+	apis := []API{
+		newAPI("regex", 0, "^/api/[0-9]+$", older, 0),
+		newAPI("new-prefix", 0, "/api", newer, 0),
+		newAPI("old-prefix", 0, "/api", older, 1),
+		newAPI("old-prefix", 0, "/api/v1", older, 0),
+		newAPI("exact", 0, "^/api$", newer, 0),
+		newAPI("important", 10, "/", newer, 0),
+	}
+
+	for i := 0; i < 2; i++ {
+		// shuffled input gets the same positions
+		sort.Slice(apis, func(i, j int) bool { return apis[i].Name > apis[j].Name })
+		setPositions(apis)
+
+		var names []string
+		for i, api := range apis {
+			assert.Equal(t, uint32(i+1), api.Position)
+			names = append(names, api.Name)
+		}
+		assert.Equal(t, []string{"important.0", "exact.0", "old-prefix.0", "old-prefix.1", "new-prefix.0", "regex.0"}, names)
+	}
+}
+
+func TestApplyCanaries(t *testing.T) {
+	rate := func(r int32) *int32 { return &r }
+	newIngress := func() *configurationv1beta1.ManbaIngress {
+		return &configurationv1beta1.ManbaIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+			Spec: configurationv1beta1.ManbaIngressSpec{
+				HTTP: []configurationv1beta1.ManbaHTTPRule{{
+					Split: []configurationv1beta1.ManbaHTTPRouting{
+						{Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v1", Port: intstr.FromInt(80)}, Rate: rate(100)},
+						{Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v2", Port: intstr.FromInt(80)}, Rate: rate(100)},
+					},
+				}},
+			},
+		}
+	}
+	newCanary := func(phase configurationv1beta1.ManbaCanaryPhase, r int32) *configurationv1beta1.ManbaCanary {
+		return &configurationv1beta1.ManbaCanary{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default"},
+			Spec: configurationv1beta1.ManbaCanarySpec{
+				Ingress: "ing",
+				Target:  configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v2"},
+			},
+			Status: configurationv1beta1.ManbaCanaryStatus{Phase: phase, Rate: r},
+		}
+	}
+
+	ingress := newIngress()
+	applyCanaries(ingress, []*configurationv1beta1.ManbaCanary{newCanary(configurationv1beta1.CanaryProgressing, 25)})
+	assert.Equal(t, int32(100), *ingress.Spec.HTTP[0].Split[0].Rate)
+	assert.Equal(t, int32(25), *ingress.Spec.HTTP[0].Split[1].Rate)
+
+	// not started
+	ingress = newIngress()
+	applyCanaries(ingress, []*configurationv1beta1.ManbaCanary{newCanary("", 0)})
+	assert.Len(t, ingress.Spec.HTTP[0].Split, 2)
+	assert.Equal(t, int32(100), *ingress.Spec.HTTP[0].Split[1].Rate)
+
+	// rolled back
+	ingress = newIngress()
+	applyCanaries(ingress, []*configurationv1beta1.ManbaCanary{newCanary(configurationv1beta1.CanaryRolledBack, 0)})
+	assert.Len(t, ingress.Spec.HTTP[0].Split, 1)
+	assert.Equal(t, "v1", ingress.Spec.HTTP[0].Split[0].Cluster.Subset)
+
+	// other ingress
+	canary := newCanary(configurationv1beta1.CanaryProgressing, 5)
+	canary.Spec.Ingress = "other"
+	ingress = newIngress()
+	applyCanaries(ingress, []*configurationv1beta1.ManbaCanary{canary})
+	assert.Equal(t, int32(100), *ingress.Spec.HTTP[0].Split[1].Rate)
+}
+
+func TestParser_ResolveSubsets(t *testing.T) {
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			Subsets: []configurationv1beta1.ManbaClusterSubSet{
+				{Name: "v1", Labels: map[string]string{"version": "v1"}},
+				{Name: "v2", Labels: map[string]string{"version": "v2"}},
+			},
+			ActiveSubset:  "v1",
+			PreviewSubset: "v2",
+		},
+	}
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ing", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{{
+				Route: []configurationv1beta1.ManbaHTTPRoute{{
+					Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "test-cls", Subset: "@active", Port: intstr.FromInt(80)},
+				}},
+				Mirror: []configurationv1beta1.ManbaHTTPMirror{{ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{
+					Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "test-cls", Subset: "@preview", Port: intstr.FromInt(80)},
+				}}},
+			}},
+		},
+	}
+	s, err := store.NewFakeStore(nil, []runtime.Object{cluster})
+	assert.Nil(t, err)
+	p := New(s, &record.FakeRecorder{})
+
+	previews, err := p.resolveSubsets(ingress)
+	assert.Nil(t, err)
+	rule := ingress.Spec.HTTP[0]
+	assert.Equal(t, "v1", rule.Route[0].Cluster.Subset)
+	assert.Equal(t, "v2", rule.Mirror[0].Cluster.Subset)
+	assert.Equal(t, []configurationv1beta1.ManbaHTTPRouteCluster{
+		{Name: "test-cls", Subset: "v2", Port: intstr.FromInt(80)},
+	}, previews)
+	assert.Len(t, rule.Split, 1)
+	assert.Equal(t, "v2", rule.Split[0].Cluster.Subset)
+	assert.Equal(t, int32(100), *rule.Split[0].Rate)
+	assert.Equal(t, []metapb.Condition{{
+		Parameter: metapb.Parameter{Name: "X-Manba-Preview", Source: metapb.Header},
+		Cmp:       metapb.CMPEQ,
+		Expect:    "true",
+	}}, rule.Split[0].Conditions)
+}
+
+func TestParser_FillAPIsMirrors(t *testing.T) {
+	sample := int32(10)
+	rule := configurationv1beta1.ManbaHTTPRule{
+		Mirror: []configurationv1beta1.ManbaHTTPMirror{
+			{
+				ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{
+					Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "shadow", Subset: "v1", Port: intstr.FromInt(80)},
+				},
+				Sample:  &sample,
+				Methods: []string{"get"},
+			},
+			{
+				ManbaHTTPRouting: configurationv1beta1.ManbaHTTPRouting{
+					Cluster: configurationv1beta1.ManbaHTTPRouteCluster{Name: "shadow", Subset: "v2", Port: intstr.FromInt(80)},
+				},
+			},
+		},
+	}
+	newAPI := func(name, method string) *API {
+		return &API{
+			API:       metapb.API{Name: name, Method: method},
+			Namespace: "default",
+			HTTPRule:  rule,
+		}
+	}
+	service := &Service{
+		Cluster: &Cluster{Cluster: metapb.Cluster{Name: "default.test-cls.v1.80.svc"}},
+		APIs:    []*API{newAPI("get", "GET"), newAPI("post", "POST")},
+	}
+
+	New(nil, &record.FakeRecorder{}).fillAPIs(service)
+
+	get := service.APIs[0].Routings
+	if assert.Len(t, get, 2) {
+		assert.Equal(t, "get.mirror.0", get[0].Name)
+		assert.Equal(t, "default.shadow.v1.80.svc", get[0].ClusterName)
+		assert.Equal(t, metapb.Copy, get[0].Strategy)
+		assert.Equal(t, int32(10), get[0].TrafficRate)
+		assert.Equal(t, "get.mirror.1", get[1].Name)
+	}
+	// mirrors without methods copy requests of all methods
+	post := service.APIs[1].Routings
+	if assert.Len(t, post, 1) {
+		assert.Equal(t, "post.mirror.1", post[0].Name)
+		assert.Equal(t, "default.shadow.v2.80.svc", post[0].ClusterName)
+	}
+}
+
+func TestParser_FillAPIsAggregate(t *testing.T) {
+	users := configurationv1beta1.ManbaHTTPRouteCluster{Name: "users", Subset: "v1", Port: intstr.FromInt(80)}
+	orders := configurationv1beta1.ManbaHTTPRouteCluster{Name: "orders", Subset: "v1", Port: intstr.FromInt(80)}
+	fallback := &metapb.HTTPResult{Code: 200, Body: []byte("[]")}
+	api := &API{
+		API:       metapb.API{Name: "home"},
+		Namespace: "default",
+		HTTPRule: configurationv1beta1.ManbaHTTPRule{
+			Rewrite: &configurationv1beta1.ManbaHTTPURIRewrite{URI: "/home"},
+			Aggregate: &configurationv1beta1.ManbaHTTPAggregate{
+				Calls: []configurationv1beta1.ManbaHTTPAggregateCall{
+					{Name: "user", Cluster: users},
+					{Name: "profile", Cluster: users, Rewrite: &configurationv1beta1.ManbaHTTPURIRewrite{URI: "/profile"}},
+					{Name: "orders", Cluster: orders, Batch: 1, DefaultValue: fallback},
+				},
+			},
+		},
+	}
+	p := New(nil, &record.FakeRecorder{})
+	p.fillAPIs(&Service{Cluster: &Cluster{Cluster: metapb.Cluster{Name: "default.users.v1.80.svc"}}, APIs: []*API{api}})
+	p.fillAPIs(&Service{Cluster: &Cluster{Cluster: metapb.Cluster{Name: "default.orders.v1.80.svc"}}, APIs: []*API{api}})
+
+	// calls to the same cluster get their own nodes
+	assert.Len(t, api.Proxies, 3)
+	user := api.Proxies["user"]
+	assert.Equal(t, "default.users.v1.80.svc", user.ClusterName)
+	assert.Equal(t, "user", user.AttrName)
+	assert.Equal(t, "/home", user.URLRewrite)
+	assert.Equal(t, "/profile", api.Proxies["profile"].URLRewrite)
+	order := api.Proxies["orders"]
+	assert.Equal(t, "default.orders.v1.80.svc", order.ClusterName)
+	assert.Equal(t, int32(1), order.BatchIndex)
+	assert.Equal(t, fallback, order.DefaultValue)
+	assert.False(t, order.UseDefault)
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Specificity tells how specific the url pattern of an api is,
+// more specific apis are matched first
+type Specificity int
+
+// specificities of url patterns, from the most specific one
+const (
+	// SpecificityExact matches a single path, e.g. ^/api/users$
+	SpecificityExact Specificity = iota
+	// SpecificityPrefix matches paths with a literal prefix, e.g. /api or ^/api/.*
+	SpecificityPrefix
+	// SpecificityRegex matches paths with other regular expressions
+	SpecificityRegex
+)
+
+// PatternSpecificity returns the specificity of url pattern
+func PatternSpecificity(pattern string) Specificity {
+	literal := strings.TrimPrefix(pattern, "^")
+	if strings.HasSuffix(literal, "$") && isLiteral(strings.TrimSuffix(literal, "$")) {
+		return SpecificityExact
+	}
+	if isLiteral(strings.TrimSuffix(literal, ".*")) {
+		return SpecificityPrefix
+	}
+	return SpecificityRegex
+}
+
+func isLiteral(s string) bool {
+	return regexp.QuoteMeta(s) == s
+}
+
+// setPositions orders apis by priority of their rules, specificity of url patterns,
+// and age of their ingresses, then sets their positions in manba.
+// Ties are broken by namespace, name of ingress and the order of rules,
+// so the same apis always get the same positions
+func setPositions(apis []API) {
+	sort.SliceStable(apis, func(i, j int) bool {
+		a, b := &apis[i], &apis[j]
+		if a.HTTPRule.Priority != b.HTTPRule.Priority {
+			return a.HTTPRule.Priority > b.HTTPRule.Priority
+		}
+		if sa, sb := PatternSpecificity(a.URLPattern), PatternSpecificity(b.URLPattern); sa != sb {
+			return sa < sb
+		}
+		if !a.Created.Equal(&b.Created) {
+			return a.Created.Before(&b.Created)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.IngressName != b.IngressName {
+			return a.IngressName < b.IngressName
+		}
+		return a.index < b.index
+	})
+	for i := range apis {
+		apis[i].Position = uint32(i + 1)
+	}
+}
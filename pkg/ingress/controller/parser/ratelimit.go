@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"fmt"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/ratelimit"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+)
+
+// RateLimitScope returns the scope of quotas of api, apis of an ingress with shared limits have the same scope
+func RateLimitScope(ingress *configurationv1beta1.ManbaIngress, limit *configurationv1beta1.ManbaHTTPRateLimit, api string) string {
+	if limit.Shared {
+		return fmt.Sprintf("%s/%s", ingress.Namespace, ingress.Name)
+	}
+	return api
+}
+
+// applyRateLimit tags api with the rate limit of its rule, the limit is enforced by the filter of manba proxy
+func (a *API) applyRateLimit(ingress *configurationv1beta1.ManbaIngress) {
+	limit := a.HTTPRule.RateLimit
+	if limit == nil {
+		return
+	}
+	l := &ratelimit.Limit{
+		Scope:  RateLimitScope(ingress, limit, a.Name),
+		Source: limit.Key.Source,
+		Name:   limit.Key.Name,
+		QPS:    limit.QPS,
+		Burst:  limit.GetBurst(),
+	}
+	// apis of a rule share the tags of base
+	a.Tags = append(a.Tags[:len(a.Tags):len(a.Tags)], &metapb.PairValue{Name: ratelimit.TagName, Value: l.TagValue()})
+}
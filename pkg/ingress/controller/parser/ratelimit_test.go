@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/domgoer/manba-ingress/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParser_BuildRateLimit(t *testing.T) {
+	method := "GET"
+	cls := configurationv1beta1.ManbaHTTPRouteCluster{Name: "cls", Subset: "v1", Port: intstr.FromInt(8080)}
+	rule := func(name, pattern string, limit *configurationv1beta1.ManbaHTTPRateLimit) configurationv1beta1.ManbaHTTPRule {
+		return configurationv1beta1.ManbaHTTPRule{
+			Name: name,
+			Match: []configurationv1beta1.ManbaHTTPMatch{{
+				Host: "example.com",
+				Rules: []configurationv1beta1.ManbaHTTPMatchRule{{
+					URI:    configurationv1beta1.ManbaHTTPURIMatch{Pattern: pattern},
+					Method: &method,
+				}},
+			}},
+			Route:     []configurationv1beta1.ManbaHTTPRoute{{Cluster: cls}},
+			RateLimit: limit,
+		}
+	}
+	byKey := &configurationv1beta1.ManbaHTTPRateLimit{
+		Key:    configurationv1beta1.ManbaRateLimitKey{Source: configurationv1beta1.RateLimitKeyHeader, Name: "X-Api-Key"},
+		QPS:    10,
+		Shared: true,
+	}
+	byIP := &configurationv1beta1.ManbaHTTPRateLimit{
+		Key:   configurationv1beta1.ManbaRateLimitKey{Source: configurationv1beta1.RateLimitKeyClientIP},
+		QPS:   5,
+		Burst: 20,
+	}
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{
+				rule("users", "/users", byKey),
+				rule("orders", "/orders", byKey),
+				rule("search", "/search", byIP),
+				rule("health", "/health", nil),
+			},
+		},
+	}
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			Subsets: []configurationv1beta1.ManbaClusterSubSet{{Name: "v1", Labels: map[string]string{"app": "test"}}},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-svc", Namespace: "default", Labels: map[string]string{"app": "test"}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+	}
+	fakeStore, err := store.NewFakeStore([]runtime.Object{service}, []runtime.Object{ingress, cluster})
+	assert.Nil(t, err)
+	p := New(fakeStore, record.NewFakeRecorder(10))
+
+	state, err := p.Build()
+	assert.Nil(t, err)
+	assert.Len(t, state.APIs, 4)
+	limits := make(map[string]*ratelimit.Limit)
+	for _, api := range state.APIs {
+		limits[api.URLPattern] = nil
+		for _, tag := range api.Tags {
+			if tag.Name == ratelimit.TagName {
+				limit, err := ratelimit.ParseTag(tag.Value)
+				assert.Nil(t, err)
+				limits[api.URLPattern] = limit
+			}
+		}
+	}
+
+	// shared limits use the quota of ingress
+	assert.Equal(t, &ratelimit.Limit{Scope: "default/ing", Source: "header", Name: "X-Api-Key", QPS: 10, Burst: 10}, limits["/users"])
+	assert.Equal(t, limits["/users"], limits["/orders"])
+	// others use the quota of api
+	assert.Equal(t, "clientIP", limits["/search"].Source)
+	assert.Equal(t, uint32(20), limits["/search"].Burst)
+	assert.NotEqual(t, "default/ing", limits["/search"].Scope)
+	assert.Nil(t, limits["/health"])
+}
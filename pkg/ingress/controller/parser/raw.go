@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/domgoer/manba-ingress/pkg/manba/dump"
+)
+
+// Raw converts the state to raw entities of manba, sorted by their identifiers,
+// ids of entities are not set
+func (s *ManbaState) Raw() *dump.ManbaRawState {
+	var ms dump.ManbaRawState
+	for _, api := range s.APIs {
+		a := api.API
+
+		var proxies []dump.Proxy
+		for _, p := range api.Proxies {
+			proxies = append(proxies, dump.Proxy{
+				ClusterName:  p.ClusterName,
+				DispatchNode: p.DispatchNode,
+			})
+		}
+		sort.SliceStable(proxies, func(i, j int) bool {
+			if proxies[i].ClusterName != proxies[j].ClusterName {
+				return proxies[i].ClusterName < proxies[j].ClusterName
+			}
+			return proxies[i].AttrName < proxies[j].AttrName
+		})
+
+		ms.APIs = append(ms.APIs, &dump.API{
+			API:     &a,
+			Proxies: proxies,
+		})
+	}
+
+	sort.SliceStable(ms.APIs, func(i, j int) bool {
+		return ms.APIs[i].Name < ms.APIs[j].Name
+	})
+
+	for _, server := range s.Servers {
+		svr := server.Server
+		ms.Servers = append(ms.Servers, &dump.Server{
+			Server: &svr,
+		})
+	}
+
+	sort.SliceStable(ms.Servers, func(i, j int) bool {
+		return ms.Servers[i].Addr < ms.Servers[j].Addr
+	})
+
+	for _, routing := range s.Routings {
+		r := routing.Routing
+		ms.Routings = append(ms.Routings, &dump.Routing{
+			APIName:     routing.APIName,
+			ClusterName: routing.ClusterName,
+			Routing:     &r,
+		})
+	}
+	sort.SliceStable(ms.Routings, func(i, j int) bool {
+		return ms.Routings[i].Name < ms.Routings[j].Name
+	})
+
+	for _, cls := range s.Clusters {
+		c := cls.Cluster
+
+		ms.Clusters = append(ms.Clusters, &dump.Cluster{
+			Cluster: &c,
+		})
+
+		for _, svr := range cls.Servers {
+			// Add binds
+			ms.Binds = append(ms.Binds, &dump.Bind{
+				ClusterName: c.GetName(),
+				ServerAddr:  svr.GetAddr(),
+			})
+		}
+	}
+
+	sort.SliceStable(ms.Clusters, func(i, j int) bool {
+		return ms.Clusters[i].Name < ms.Clusters[j].Name
+	})
+
+	sort.SliceStable(ms.Binds, func(i, j int) bool {
+		if ms.Binds[i].ClusterName != ms.Binds[j].ClusterName {
+			return ms.Binds[i].ClusterName < ms.Binds[j].ClusterName
+		}
+		return ms.Binds[i].ServerAddr < ms.Binds[j].ServerAddr
+	})
+
+	return &ms
+}
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/domgoer/manba-ingress/pkg/manba/dump"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManbaState_RawSortsBinds(t *testing.T) {
+	server := func(addr string) *Server {
+		return &Server{Server: metapb.Server{Addr: addr}}
+	}
+	s := &ManbaState{
+		Clusters: []Cluster{
+			{Cluster: metapb.Cluster{Name: "b"}, Servers: []*Server{server("10.0.0.1:80")}},
+			{Cluster: metapb.Cluster{Name: "a"}, Servers: []*Server{server("10.0.0.3:80"), server("10.0.0.2:80")}},
+		},
+	}
+	assert.Equal(t, []*dump.Bind{
+		{ClusterName: "a", ServerAddr: "10.0.0.2:80"},
+		{ClusterName: "a", ServerAddr: "10.0.0.3:80"},
+		{ClusterName: "b", ServerAddr: "10.0.0.1:80"},
+	}, s.Raw().Binds)
+}
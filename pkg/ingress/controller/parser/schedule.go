@@ -0,0 +1,39 @@
+package parser
+
+import (
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// activeSchedule returns the schedule in its window, the time it changes is recorded for the next sync
+func (p *Parser) activeSchedule(schedules []configurationv1beta1.ManbaSchedule) (*configurationv1beta1.ManbaSchedule, error) {
+	schedule, next, err := configurationv1beta1.ActiveSchedule(schedules, p.now())
+	if err != nil {
+		return nil, err
+	}
+	if !next.IsZero() && (p.nextSchedule.IsZero() || next.Before(p.nextSchedule)) {
+		p.nextSchedule = next
+	}
+	return schedule, nil
+}
+
+// applySchedules replaces policies of rules with the ones of their schedules in window
+func (p *Parser) applySchedules(ingress *configurationv1beta1.ManbaIngress) error {
+	for i := range ingress.Spec.HTTP {
+		rule := &ingress.Spec.HTTP[i]
+		schedule, err := p.activeSchedule(rule.Schedules)
+		if err != nil {
+			return errors.Wrapf(err, "http[%d]", i)
+		}
+		if schedule == nil {
+			continue
+		}
+		if schedule.DefaultValue != nil {
+			rule.DefaultValue = schedule.DefaultValue
+		}
+		if schedule.Split != nil {
+			rule.Split = schedule.Split
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParser_ApplySchedules(t *testing.T) {
+	rate := func(r int32) *int32 { return &r }
+	now := time.Date(2020, 1, 6, 23, 0, 0, 0, time.UTC)
+	p := New(nil, &record.FakeRecorder{})
+	p.now = func() time.Time { return now }
+
+	maintenance := &metapb.HTTPResult{Code: 503, Body: []byte("maintenance")}
+	ingress := &configurationv1beta1.ManbaIngress{
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{
+				{
+					Split: []configurationv1beta1.ManbaHTTPRouting{{Rate: rate(10)}},
+					Schedules: []configurationv1beta1.ManbaSchedule{
+						{
+							Name:   "business",
+							Window: configurationv1beta1.ManbaTimeWindow{Start: "09:00", End: "18:00"},
+							Split:  []configurationv1beta1.ManbaHTTPRouting{{Rate: rate(50)}},
+						},
+						{
+							Name:         "night",
+							Window:       configurationv1beta1.ManbaTimeWindow{Start: "22:00", End: "06:00"},
+							DefaultValue: maintenance,
+						},
+					},
+				},
+				{
+					Split: []configurationv1beta1.ManbaHTTPRouting{{Rate: rate(10)}},
+					Schedules: []configurationv1beta1.ManbaSchedule{{
+						Name:   "business",
+						Window: configurationv1beta1.ManbaTimeWindow{Start: "09:00", End: "18:00", TimeZone: "Asia/Shanghai"},
+						Split:  []configurationv1beta1.ManbaHTTPRouting{{Rate: rate(50)}},
+					}},
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, p.applySchedules(ingress))
+	rules := ingress.Spec.HTTP
+	assert.Equal(t, maintenance, rules[0].DefaultValue)
+	assert.Equal(t, int32(10), *rules[0].Split[0].Rate)
+	assert.Nil(t, rules[1].DefaultValue)
+	assert.Equal(t, int32(10), *rules[1].Split[0].Rate)
+	// 09:00 in Shanghai
+	assert.True(t, p.nextSchedule.Equal(time.Date(2020, 1, 7, 1, 0, 0, 0, time.UTC)), "next is %v", p.nextSchedule)
+
+	now = time.Date(2020, 1, 7, 2, 0, 0, 0, time.UTC)
+	assert.Nil(t, p.applySchedules(ingress))
+	assert.Equal(t, int32(50), *rules[1].Split[0].Rate)
+
+	ingress.Spec.HTTP[0].Schedules[0].Window.Start = "9"
+	assert.Contains(t, p.applySchedules(ingress).Error(), "http[0]: schedule business: start")
+}
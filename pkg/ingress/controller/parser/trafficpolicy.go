@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ReasonRateLimitOptionIgnored is the reason of event when traffic policies of ManbaCluster set rateLimitOption,
+// manba api server has no option of rate limit so it's dropped
+const ReasonRateLimitOptionIgnored = "RateLimitOptionIgnored"
+
+// ClusterRateLimitOptions returns the rate limit options of all traffic policies in spec by their path
+func ClusterRateLimitOptions(spec configurationv1beta1.ManbaClusterSpec) map[string]string {
+	res := RateLimitOptions(nil, "trafficPolicy.", spec.TrafficPolicy)
+	for _, subset := range spec.Subsets {
+		res = RateLimitOptions(res, fmt.Sprintf("subset %s: trafficPolicy.", subset.Name), subset.TrafficPolicy)
+		for _, schedule := range subset.Schedules {
+			res = RateLimitOptions(res, fmt.Sprintf("subset %s: schedule %s: trafficPolicy.", subset.Name, schedule.Name), schedule.TrafficPolicy)
+		}
+	}
+	return res
+}
+
+// RateLimitOptions adds the rate limit option of policy to options by path
+func RateLimitOptions(options map[string]string, path string, policy *configurationv1beta1.TrafficPolicy) map[string]string {
+	if options == nil {
+		options = make(map[string]string)
+	}
+	if policy != nil && policy.RateLimitOption != nil {
+		options[path+"rateLimitOption"] = *policy.RateLimitOption
+	}
+	return options
+}
+
+// reportRateLimitOptions records an event on cluster if its traffic policies or the inherited ones set rateLimitOption
+func (p *Parser) reportRateLimitOptions(cluster *configurationv1beta1.ManbaCluster, inherited []*configurationv1beta1.TrafficPolicy) {
+	var paths []string
+	for path := range ClusterRateLimitOptions(cluster.Spec) {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, policy := range inherited {
+		if policy != nil && policy.RateLimitOption != nil {
+			paths = append(paths, "rateLimitOption of inherited ManbaTrafficPolicy")
+			break
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	p.recorder.Eventf(cluster, corev1.EventTypeWarning, ReasonRateLimitOptionIgnored,
+		"%s not supported by manba and ignored", strings.Join(paths, ", "))
+}
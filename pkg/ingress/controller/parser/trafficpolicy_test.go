@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParser_ReportRateLimitOptions(t *testing.T) {
+	option := "Wait"
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			Subsets: []configurationv1beta1.ManbaClusterSubSet{{Name: "v1"}},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	p := New(nil, recorder)
+
+	p.reportRateLimitOptions(cluster, []*configurationv1beta1.TrafficPolicy{nil, {}})
+	assert.Len(t, recorder.Events, 0)
+
+	cluster.Spec.Subsets[0].TrafficPolicy = &configurationv1beta1.TrafficPolicy{RateLimitOption: &option}
+	p.reportRateLimitOptions(cluster, []*configurationv1beta1.TrafficPolicy{{RateLimitOption: &option}})
+	assert.Equal(t, "Warning "+ReasonRateLimitOptionIgnored+
+		" subset v1: trafficPolicy.rateLimitOption, rateLimitOption of inherited ManbaTrafficPolicy not supported by manba and ignored",
+		<-recorder.Events)
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/scheme"
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/domgoer/manba-ingress/pkg/manba/crud"
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ReasonSynced is the reason of event when an entity is synced to Manba
+	ReasonSynced = "Synced"
+	// ReasonSyncFailed is the reason of event when Manba rejects an operation
+	ReasonSyncFailed = "SyncFailed"
+	// ReasonInvalid is the reason of event when an entity fails Manba validation
+	ReasonInvalid = "Invalid"
+
+	component = "manba-ingress-controller"
+
+	kindManbaIngress = "ManbaIngress"
+	kindManbaCluster = "ManbaCluster"
+)
+
+// objectRef refers to the ManbaIngress or ManbaCluster an entity is generated from
+type objectRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// sourceIndex maps manba entities to the k8s objects they are generated from,
+// key is <kind>/<identifier>, kind is the same as crud.Kind
+type sourceIndex map[string]objectRef
+
+func sourceKey(kind crud.Kind, identifier string) string {
+	return fmt.Sprintf("%s/%s", kind, identifier)
+}
+
+// newSourceIndex indexes all entities in s by their identifier in Manba
+func newSourceIndex(s *parser.ManbaState) sourceIndex {
+	index := make(sourceIndex)
+	if s == nil {
+		return index
+	}
+
+	for _, api := range s.APIs {
+		index[sourceKey("api", api.Name)] = objectRef{
+			Kind:      kindManbaIngress,
+			Namespace: api.Namespace,
+			Name:      api.IngressName,
+		}
+	}
+
+	for _, routing := range s.Routings {
+		if ref, ok := index[sourceKey("api", routing.APIName)]; ok {
+			index[sourceKey("routing", routing.Name)] = ref
+		}
+	}
+
+	for _, cluster := range s.Clusters {
+		ref := objectRef{
+			Kind:      kindManbaCluster,
+			Namespace: cluster.Namespace,
+			Name:      cluster.ManbaClusterName,
+		}
+		if cluster.IngressName != "" {
+			ref = objectRef{
+				Kind:      kindManbaIngress,
+				Namespace: cluster.Namespace,
+				Name:      cluster.IngressName,
+			}
+		}
+		index[sourceKey("cluster", cluster.Name)] = ref
+		for _, svr := range cluster.Servers {
+			key := sourceKey("server", svr.Addr)
+			if _, ok := index[key]; !ok {
+				index[key] = ref
+			}
+		}
+	}
+	return index
+}
+
+// newEventRecorder returns a recorder which is able to refer to manba resources,
+// the returned watch should be stopped to stop sending events to k8s
+func newEventRecorder(client kubernetes.Interface) (watch.Interface, record.EventRecorder) {
+	s := runtime.NewScheme()
+	clientgoscheme.AddToScheme(s)
+	scheme.AddToScheme(s)
+
+	broadcaster := record.NewBroadcaster()
+	w := broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: client.CoreV1().Events(""),
+	})
+	return w, broadcaster.NewRecorder(s, corev1.EventSource{Component: component})
+}
+
+// recordEvent records an event on the object which the entity is generated from,
+// nothing happens if the object is unknown
+func (m *ManbaController) recordEvent(kind crud.Kind, identifier, eventType, reason, messageFmt string, args ...interface{}) {
+	ref, ok := m.sources[sourceKey(kind, identifier)]
+	if !ok {
+		return
+	}
+
+	var obj runtime.Object
+	var err error
+	switch ref.Kind {
+	case kindManbaIngress:
+		obj, err = m.store.GetManbaIngress(ref.Namespace, ref.Name)
+	case kindManbaCluster:
+		obj, err = m.store.GetManbaCluster(ref.Namespace, ref.Name)
+	default:
+		return
+	}
+	if err != nil {
+		glog.V(3).Infof("skipping event of %s <%s>: %v", kind, identifier, err)
+		return
+	}
+
+	m.recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// recordOp records the result of an operation sent to Manba
+func (m *ManbaController) recordOp(e crud.Event, err error) {
+	obj, ok := e.Obj.(interface{ Identifier() string })
+	if !ok {
+		return
+	}
+
+	op := strings.ToLower(e.Op.String())
+	if err != nil {
+		m.recordEvent(e.Kind, obj.Identifier(), corev1.EventTypeWarning, ReasonSyncFailed,
+			"failed to %s %s <%s> in Manba: %v", op, e.Kind, obj.Identifier(), err)
+		return
+	}
+	m.recordEvent(e.Kind, obj.Identifier(), corev1.EventTypeNormal, ReasonSynced,
+		"%sd %s <%s> in Manba", op, e.Kind, obj.Identifier())
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSourceIndex(t *testing.T) {
+	state := &parser.ManbaState{
+		APIs: []parser.API{
+			{
+				API:         metapb.API{Name: "default.test-ing.0000"},
+				Namespace:   "default",
+				IngressName: "test-ing",
+			},
+		},
+		Routings: []parser.Routing{
+			{
+				APIName: "default.test-ing.0000",
+				Routing: metapb.Routing{Name: "default.test-ing.0000.mirror.0"},
+			},
+		},
+		Clusters: []parser.Cluster{
+			{
+				Cluster:          metapb.Cluster{Name: "default.test-cls.v1.8080.svc"},
+				Namespace:        "default",
+				ManbaClusterName: "test-cls",
+				Servers: []*parser.Server{
+					{Server: metapb.Server{Addr: "1.1.1.1:8080"}},
+				},
+			},
+		},
+	}
+
+	ingress := objectRef{Kind: kindManbaIngress, Namespace: "default", Name: "test-ing"}
+	cluster := objectRef{Kind: kindManbaCluster, Namespace: "default", Name: "test-cls"}
+	assert.Equal(t, sourceIndex{
+		"api/default.test-ing.0000":              ingress,
+		"routing/default.test-ing.0000.mirror.0": ingress,
+		"cluster/default.test-cls.v1.8080.svc":   cluster,
+		"server/1.1.1.1:8080":                    cluster,
+	}, newSourceIndex(state))
+}
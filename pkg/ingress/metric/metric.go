@@ -0,0 +1,107 @@
+package metric
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "manba_ingress"
+
+	// PhaseParse is the phase which builds ManbaState from k8s resources
+	PhaseParse = "parse"
+	// PhaseDump is the phase which loads the running configuration from Manba
+	PhaseDump = "dump"
+	// PhaseDiff is the phase which builds the current and target states
+	PhaseDiff = "diff"
+	// PhaseSolve is the phase which sends the diff to Manba
+	PhaseSolve = "solve"
+)
+
+var (
+	// SyncDuration observes the time spent in each phase of a sync
+	SyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Time spent in each phase of syncing configuration to Manba",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"phase"})
+
+	// SyncErrors counts failed syncs by the phase that failed
+	SyncErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_errors_total",
+		Help:      "Number of failed syncs by phase",
+	}, []string{"phase"})
+
+	// SolverOperations counts the operations sent to Manba by kind and op
+	SolverOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "solver_operations_total",
+		Help:      "Number of create, update and delete operations sent to Manba by entity kind",
+	}, []string{"kind", "op"})
+
+	// InvalidEntities counts entities dropped before they reach Manba
+	InvalidEntities = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invalid_entities_total",
+		Help:      "Number of entities dropped because they failed Manba validation",
+	}, []string{"kind"})
+
+	// ParseErrors is the number of objects which failed to parse in last sync
+	ParseErrors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "parse_errors",
+		Help:      "Number of objects whose last-known-good output is used because they failed to parse",
+	}, []string{"kind"})
+
+	// UpdateQueueLength is the number of events waiting in the update channel
+	UpdateQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "update_queue_length",
+		Help:      "Number of k8s events waiting to be processed",
+	})
+
+	// ConfigHash is 1 with the hash of the configuration running in Manba in label hash,
+	// a float value can't hold the hash without losing bits
+	ConfigHash = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_hash",
+		Help:      "Hash of the configuration running in Manba in label hash, the value is always 1",
+	}, []string{"hash"})
+
+	// LastSyncSuccess is the unix timestamp of the last successful sync
+	LastSyncSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_sync_success_timestamp_seconds",
+		Help:      "Timestamp of the last successful sync to Manba",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		SyncDuration,
+		SyncErrors,
+		SolverOperations,
+		InvalidEntities,
+		ParseErrors,
+		UpdateQueueLength,
+		ConfigHash,
+		LastSyncSuccess,
+	)
+}
+
+// ObserveDuration records the time elapsed since start for phase
+func ObserveDuration(phase string, start time.Time) {
+	SyncDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+}
+
+// SetConfigHash records a successful sync of the configuration with hash sum
+func SetConfigHash(sum [32]byte) {
+	// only the running configuration is kept
+	ConfigHash.Reset()
+	ConfigHash.WithLabelValues(hex.EncodeToString(sum[:])).Set(1)
+	LastSyncSuccess.SetToCurrentTime()
+}
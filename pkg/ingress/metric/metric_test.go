@@ -0,0 +1,22 @@
+package metric
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetConfigHash(t *testing.T) {
+	old := sha256.Sum256([]byte("old"))
+	SetConfigHash(old)
+	sum := sha256.Sum256([]byte("test"))
+	SetConfigHash(sum)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(ConfigHash.WithLabelValues(hex.EncodeToString(sum[:]))))
+	// the hash of the replaced configuration is gone
+	assert.False(t, ConfigHash.DeleteLabelValues(hex.EncodeToString(old[:])))
+	assert.NotZero(t, testutil.ToFloat64(LastSyncSuccess))
+}
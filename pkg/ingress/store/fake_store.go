@@ -56,8 +56,8 @@ func (f *fakeStore) ListServices(namespace string, label map[string]string) ([]*
 		return nil, err
 	}
 	var res []*corev1.Service
-	for _, item := range svc.Items {
-		res = append(res, &item)
+	for i := range svc.Items {
+		res = append(res, &svc.Items[i])
 	}
 	return res, nil
 }
@@ -70,14 +70,46 @@ func (f *fakeStore) GetManbaCluster(namespace, name string) (*configurationv1bet
 	return f.manbaClient.ConfigurationV1beta1().ManbaClusters(namespace).Get(name, metav1.GetOptions{})
 }
 
+func (f *fakeStore) GetManbaCachePolicy(namespace, name string) (*configurationv1beta1.ManbaCachePolicy, error) {
+	return f.manbaClient.ConfigurationV1beta1().ManbaCachePolicies(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (f *fakeStore) GetManbaTrafficPolicy(namespace, name string) (*configurationv1beta1.ManbaTrafficPolicy, error) {
+	return f.manbaClient.ConfigurationV1beta1().ManbaTrafficPolicies(namespace).Get(name, metav1.GetOptions{})
+}
+
 func (f *fakeStore) ListManbaIngresses() []*configurationv1beta1.ManbaIngress {
 	ing, err := f.manbaClient.ConfigurationV1beta1().ManbaIngresses(metav1.NamespaceAll).List(metav1.ListOptions{})
 	if err != nil {
 		panic(err)
 	}
 	var res []*configurationv1beta1.ManbaIngress
-	for _, item := range ing.Items {
-		res = append(res, &item)
+	for i := range ing.Items {
+		res = append(res, &ing.Items[i])
+	}
+	return res
+}
+
+func (f *fakeStore) ListManbaClusters() []*configurationv1beta1.ManbaCluster {
+	clusters, err := f.manbaClient.ConfigurationV1beta1().ManbaClusters(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		panic(err)
+	}
+	var res []*configurationv1beta1.ManbaCluster
+	for i := range clusters.Items {
+		res = append(res, &clusters.Items[i])
+	}
+	return res
+}
+
+func (f *fakeStore) ListManbaCanaries() []*configurationv1beta1.ManbaCanary {
+	canaries, err := f.manbaClient.ConfigurationV1beta1().ManbaCanaries(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		panic(err)
+	}
+	var res []*configurationv1beta1.ManbaCanary
+	for i := range canaries.Items {
+		res = append(res, &canaries.Items[i])
 	}
 	return res
 }
@@ -85,3 +117,7 @@ func (f *fakeStore) ListManbaIngresses() []*configurationv1beta1.ManbaIngress {
 func (f *fakeStore) GetSecret(namespace, name string) (*corev1.Secret, error) {
 	return f.client.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
 }
+
+func (f *fakeStore) GetConfigMap(namespace, name string) (*corev1.ConfigMap, error) {
+	return f.client.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+}
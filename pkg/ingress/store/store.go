@@ -16,6 +16,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -23,6 +24,9 @@ import (
 const (
 	manbaIngress = iota
 	manbaCluster
+	manbaCanary
+	manbaCachePolicy
+	manbaTrafficPolicy
 	service
 	endpoint
 )
@@ -36,8 +40,13 @@ type Store interface {
 	ListServices(namespace string, label map[string]string) ([]*corev1.Service, error)
 	GetManbaIngress(namespace, name string) (*configurationv1beta1.ManbaIngress, error)
 	GetManbaCluster(namespace, name string) (*configurationv1beta1.ManbaCluster, error)
+	GetManbaCachePolicy(namespace, name string) (*configurationv1beta1.ManbaCachePolicy, error)
+	GetManbaTrafficPolicy(namespace, name string) (*configurationv1beta1.ManbaTrafficPolicy, error)
 	ListManbaIngresses() []*configurationv1beta1.ManbaIngress
+	ListManbaClusters() []*configurationv1beta1.ManbaCluster
+	ListManbaCanaries() []*configurationv1beta1.ManbaCanary
 	GetSecret(namespace, name string) (*corev1.Secret, error)
+	GetConfigMap(namespace, name string) (*corev1.ConfigMap, error)
 }
 
 type store struct {
@@ -52,6 +61,10 @@ func (s *store) GetSecret(namespace, name string) (*corev1.Secret, error) {
 	return s.factory.Core().V1().Secrets().Lister().Secrets(namespace).Get(name)
 }
 
+func (s *store) GetConfigMap(namespace, name string) (*corev1.ConfigMap, error) {
+	return s.factory.Core().V1().ConfigMaps().Lister().ConfigMaps(namespace).Get(name)
+}
+
 func (s *store) ListServices(namespace string, label map[string]string) ([]*corev1.Service, error) {
 	return s.factory.Core().V1().Services().Lister().Services(namespace).List(labels.SelectorFromSet(label))
 }
@@ -95,6 +108,36 @@ func (s *store) ListManbaIngresses() []*configurationv1beta1.ManbaIngress {
 	return ingresses
 }
 
+// ListManbaClusters returns the list of Manba Clusters
+func (s *store) ListManbaClusters() []*configurationv1beta1.ManbaCluster {
+	var clusters []*configurationv1beta1.ManbaCluster
+	for _, item := range s.getStore(manbaCluster).List() {
+		cluster, ok := item.(*configurationv1beta1.ManbaCluster)
+		if !ok {
+			glog.Warningf("invalid type for cluster, %v", item)
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters
+}
+
+// ListManbaCanaries returns the list of Manba Canaries
+func (s *store) ListManbaCanaries() []*configurationv1beta1.ManbaCanary {
+	var canaries []*configurationv1beta1.ManbaCanary
+	for _, item := range s.getStore(manbaCanary).List() {
+		canary, ok := item.(*configurationv1beta1.ManbaCanary)
+		if !ok {
+			glog.Warningf("invalid type for canary, %v", item)
+			continue
+		}
+		canaries = append(canaries, canary)
+	}
+
+	return canaries
+}
+
 func (s *store) GetService(namespace, name string) (*corev1.Service, error) {
 	key := fmt.Sprintf("%v/%v", namespace, name)
 	service, exists, err := s.getStore(service).GetByKey(key)
@@ -107,6 +150,7 @@ func (s *store) GetService(namespace, name string) (*corev1.Service, error) {
 	return service.(*corev1.Service), nil
 }
 
+// GetManbaIngress returns the ManbaIngress, the error is NotFound if it doesn't exist
 func (s *store) GetManbaIngress(namespace, name string) (*configurationv1beta1.ManbaIngress, error) {
 	key := fmt.Sprintf("%v/%v", namespace, name)
 	p, exist, err := s.getStore(manbaIngress).GetByKey(key)
@@ -114,11 +158,12 @@ func (s *store) GetManbaIngress(namespace, name string) (*configurationv1beta1.M
 		return nil, err
 	}
 	if !exist {
-		return nil, fmt.Errorf("ManbaIngress %v was not found", key)
+		return nil, apierrors.NewNotFound(configurationv1beta1.Resource("manbaingresses"), key)
 	}
 	return p.(*configurationv1beta1.ManbaIngress), nil
 }
 
+// GetManbaCluster returns the ManbaCluster, the error is NotFound if it doesn't exist
 func (s *store) GetManbaCluster(namespace, name string) (*configurationv1beta1.ManbaCluster, error) {
 	key := fmt.Sprintf("%s/%s", namespace, name)
 	p, exist, err := s.getStore(manbaCluster).GetByKey(key)
@@ -126,15 +171,47 @@ func (s *store) GetManbaCluster(namespace, name string) (*configurationv1beta1.M
 		return nil, err
 	}
 	if !exist {
-		return nil, fmt.Errorf("ManbaCluster %v was not found", key)
+		return nil, apierrors.NewNotFound(configurationv1beta1.Resource("manbaclusters"), key)
 	}
 	return p.(*configurationv1beta1.ManbaCluster), nil
 }
 
+// GetManbaCachePolicy returns the ManbaCachePolicy, the error is NotFound if it doesn't exist
+func (s *store) GetManbaCachePolicy(namespace, name string) (*configurationv1beta1.ManbaCachePolicy, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	p, exist, err := s.getStore(manbaCachePolicy).GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, apierrors.NewNotFound(configurationv1beta1.Resource("manbacachepolicies"), key)
+	}
+	return p.(*configurationv1beta1.ManbaCachePolicy), nil
+}
+
+// GetManbaTrafficPolicy returns the ManbaTrafficPolicy, the error is NotFound if it doesn't exist
+func (s *store) GetManbaTrafficPolicy(namespace, name string) (*configurationv1beta1.ManbaTrafficPolicy, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	p, exist, err := s.getStore(manbaTrafficPolicy).GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, apierrors.NewNotFound(configurationv1beta1.Resource("manbatrafficpolicies"), key)
+	}
+	return p.(*configurationv1beta1.ManbaTrafficPolicy), nil
+}
+
 func (s *store) getStore(t int) cache.Store {
 	switch t {
 	case manbaCluster:
 		return s.manbaFactory.Configuration().V1beta1().ManbaClusters().Informer().GetStore()
+	case manbaCanary:
+		return s.manbaFactory.Configuration().V1beta1().ManbaCanaries().Informer().GetStore()
+	case manbaCachePolicy:
+		return s.manbaFactory.Configuration().V1beta1().ManbaCachePolicies().Informer().GetStore()
+	case manbaTrafficPolicy:
+		return s.manbaFactory.Configuration().V1beta1().ManbaTrafficPolicies().Informer().GetStore()
 	case manbaIngress:
 		return s.manbaFactory.Configuration().V1beta1().ManbaIngresses().Informer().GetStore()
 	case service:
@@ -1 +1,36 @@
 package store
+
+import (
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/fake"
+	"github.com/domgoer/manba-ingress/pkg/client/informers/externalversions"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStore_GetNotFound(t *testing.T) {
+	kc := k8sfake.NewSimpleClientset()
+	manbaFactory := externalversions.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	s := New(kc, informers.NewSharedInformerFactory(kc, 0), manbaFactory, func(*metav1.ObjectMeta) bool { return true })
+
+	cluster := &configurationv1beta1.ManbaCluster{ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"}}
+	assert.Nil(t, manbaFactory.Configuration().V1beta1().ManbaClusters().Informer().GetIndexer().Add(cluster))
+	res, err := s.GetManbaCluster("default", "cls")
+	assert.Nil(t, err)
+	assert.Equal(t, cluster, res)
+
+	// missing objects are reported the way the api server reports them
+	_, err = s.GetManbaCluster("default", "missing")
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = s.GetManbaIngress("default", "missing")
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = s.GetManbaCachePolicy("default", "missing")
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = s.GetManbaTrafficPolicy("default", "missing")
+	assert.True(t, apierrors.IsNotFound(err))
+}
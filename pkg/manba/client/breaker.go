@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// BreakerConfig configures the circuit breaker of requests to Manba API Server
+type BreakerConfig struct {
+	// Threshold is the number of continuous failures which opens the breaker,
+	// breaker is disabled if it's not positive
+	Threshold int
+	// Cooldown is the time the breaker stays open before a trial request is allowed
+	Cooldown time.Duration
+}
+
+// Breaker fails requests fast after Manba API Server keeps failing, so that
+// a down server doesn't block every sync until timeout
+type Breaker struct {
+	sync.Mutex
+
+	cfg      BreakerConfig
+	failures int
+	openedAt time.Time
+	trial    bool
+	now      func() time.Time
+}
+
+// NewBreaker returns a closed breaker
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	return &Breaker{
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Allow returns false if the breaker is open, after cooldown only one trial
+// request is allowed until it reports its result
+func (b *Breaker) Allow() bool {
+	if b.cfg.Threshold <= 0 {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.failures < b.cfg.Threshold {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// Done reports the result of an allowed request
+func (b *Breaker) Done(err error) {
+	if b.cfg.Threshold <= 0 {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.trial = false
+	if !isServerFailure(err) {
+		if b.failures >= b.cfg.Threshold {
+			glog.Info("manba api server recovered, closing circuit breaker")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.cfg.Threshold {
+		if b.failures == b.cfg.Threshold {
+			glog.Warningf("manba api server failed %d times, opening circuit breaker for %v", b.failures, b.cfg.Cooldown)
+		}
+		b.openedAt = b.now()
+	}
+}
+
+var errBreakerOpen = status.Error(codes.Unavailable, "circuit breaker is open, manba api server keeps failing")
+
+// isServerFailure returns true if err means Manba API Server is not healthy,
+// errors of invalid requests don't count
+func isServerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// isRetryable returns true if the request may succeed by retrying
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return err != errBreakerOpen
+	}
+	return false
+}
+
+// unaryInterceptor retries requests failed with transient errors with backoff,
+// every attempt goes through the breaker
+func unaryInterceptor(backoff wait.Backoff, breaker *Breaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		delay := backoff.Duration
+		for attempt := 0; ; attempt++ {
+			if !breaker.Allow() {
+				return errBreakerOpen
+			}
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			breaker.Done(err)
+			if err == nil || !isRetryable(err) || attempt >= backoff.Steps {
+				return err
+			}
+
+			glog.V(2).Infof("retrying %s in %v: %v", method, delay, err)
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait.Jitter(delay, backoff.Jitter)):
+			}
+			if backoff.Factor > 0 {
+				delay = time.Duration(float64(delay) * backoff.Factor)
+			}
+			if backoff.Cap > 0 && delay > backoff.Cap {
+				delay = backoff.Cap
+			}
+		}
+	}
+}
+
+// streamInterceptor guards the creation of streams with the breaker,
+// list streams are not retried since they may have called back partially
+func streamInterceptor(breaker *Breaker) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !breaker.Allow() {
+			return nil, errBreakerOpen
+		}
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		breaker.Done(err)
+		return s, err
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	unavailable := status.Error(codes.Unavailable, "down")
+	assert.True(t, b.Allow())
+	b.Done(unavailable)
+	assert.True(t, b.Allow())
+	b.Done(unavailable)
+	assert.False(t, b.Allow())
+
+	// only one trial request after cooldown
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	b.Done(unavailable)
+	assert.False(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	b.Done(nil)
+	assert.True(t, b.Allow())
+
+	// invalid requests don't open the breaker
+	for i := 0; i < 3; i++ {
+		b.Done(status.Error(codes.InvalidArgument, "invalid"))
+	}
+	assert.True(t, b.Allow())
+
+	disabled := NewBreaker(BreakerConfig{})
+	disabled.Done(unavailable)
+	assert.True(t, disabled.Allow())
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 2}
+
+	var calls int
+	invoker := func(errs ...error) grpc.UnaryInvoker {
+		calls = 0
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls++
+			if calls <= len(errs) {
+				return errs[calls-1]
+			}
+			return nil
+		}
+	}
+	unavailable := status.Error(codes.Unavailable, "down")
+	invalid := status.Error(codes.InvalidArgument, "invalid")
+
+	interceptor := unaryInterceptor(backoff, NewBreaker(BreakerConfig{}))
+	err := interceptor(context.Background(), "put", nil, nil, nil, invoker(unavailable, unavailable))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+
+	err = interceptor(context.Background(), "put", nil, nil, nil, invoker(unavailable, unavailable, unavailable))
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, 3, calls)
+
+	err = interceptor(context.Background(), "put", nil, nil, nil, invoker(invalid))
+	assert.Equal(t, invalid, err)
+	assert.Equal(t, 1, calls)
+
+	// breaker opens between retries
+	interceptor = unaryInterceptor(backoff, NewBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute}))
+	err = interceptor(context.Background(), "put", nil, nil, nil, invoker(unavailable, unavailable, unavailable))
+	assert.Equal(t, errBreakerOpen, err)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,306 @@
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fagongzi/gateway/pkg/pb"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/fagongzi/gateway/pkg/pb/rpcpb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Client is a client of Manba API Server, it contains the parts of
+// Manba meta service used by the controller
+type Client interface {
+	PutCluster(cluster metapb.Cluster) (uint64, error)
+	RemoveCluster(id uint64) error
+	GetClusterList(fn func(*metapb.Cluster) bool) error
+
+	PutServer(server metapb.Server) (uint64, error)
+	RemoveServer(id uint64) error
+	GetServerList(fn func(*metapb.Server) bool) error
+
+	PutAPI(api metapb.API) (uint64, error)
+	RemoveAPI(id uint64) error
+	GetAPIList(fn func(*metapb.API) bool) error
+
+	PutRouting(routing metapb.Routing) (uint64, error)
+	RemoveRouting(id uint64) error
+	GetRoutingList(fn func(*metapb.Routing) bool) error
+
+	AddBind(cluster, server uint64) error
+	RemoveBind(cluster, server uint64) error
+	GetBindServers(cluster uint64) ([]uint64, error)
+
+	Close() error
+}
+
+// Config contains the settings to connect to Manba API Server
+type Config struct {
+	// Addr of Manba API Server, like grpc://localhost:9092
+	Addr string
+	// Timeout of every request, a list request times out if the whole list is not received in time
+	Timeout time.Duration
+
+	TLS TLSConfig
+	// Token is sent as bearer token in every request if it's not empty
+	Token string
+	// TokenFile is read on every request to get the token, it's
+	// used in favor of Token to support rotating tokens of mounted secrets
+	TokenFile string
+
+	// Retry of requests which failed with transient errors
+	Retry wait.Backoff
+	// Breaker stops sending requests after continuous failures
+	Breaker BreakerConfig
+}
+
+type client struct {
+	conn    *grpc.ClientConn
+	meta    rpcpb.MetaServiceClient
+	timeout time.Duration
+}
+
+// NewClient returns a client of Manba API Server, the connection
+// is established in background
+func NewClient(cfg Config) (Client, error) {
+	opts, err := dialOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	breaker := NewBreaker(cfg.Breaker)
+	opts = append(opts,
+		grpc.WithUnaryInterceptor(unaryInterceptor(cfg.Retry, breaker)),
+		grpc.WithStreamInterceptor(streamInterceptor(breaker)),
+	)
+
+	conn, err := grpc.Dial(strings.TrimPrefix(cfg.Addr, "grpc://"), opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial manba api server")
+	}
+	return &client{
+		conn:    conn,
+		meta:    rpcpb.NewMetaServiceClient(conn),
+		timeout: cfg.Timeout,
+	}, nil
+}
+
+func (c *client) context() (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+func (c *client) PutCluster(cluster metapb.Cluster) (uint64, error) {
+	if err := pb.ValidateCluster(&cluster); err != nil {
+		return 0, err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	rsp, err := c.meta.PutCluster(ctx, &rpcpb.PutClusterReq{Cluster: cluster})
+	if err != nil {
+		return 0, err
+	}
+	return rsp.ID, nil
+}
+
+func (c *client) RemoveCluster(id uint64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.meta.RemoveCluster(ctx, &rpcpb.RemoveClusterReq{ID: id})
+	return err
+}
+
+func (c *client) GetClusterList(fn func(*metapb.Cluster) bool) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	stream, err := c.meta.GetClusterList(ctx, &rpcpb.GetClusterListReq{})
+	if err != nil {
+		return err
+	}
+	for {
+		v, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(v) {
+			return nil
+		}
+	}
+}
+
+func (c *client) PutServer(server metapb.Server) (uint64, error) {
+	if err := pb.ValidateServer(&server); err != nil {
+		return 0, err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	rsp, err := c.meta.PutServer(ctx, &rpcpb.PutServerReq{Server: server})
+	if err != nil {
+		return 0, err
+	}
+	return rsp.ID, nil
+}
+
+func (c *client) RemoveServer(id uint64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.meta.RemoveServer(ctx, &rpcpb.RemoveServerReq{ID: id})
+	return err
+}
+
+func (c *client) GetServerList(fn func(*metapb.Server) bool) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	stream, err := c.meta.GetServerList(ctx, &rpcpb.GetServerListReq{})
+	if err != nil {
+		return err
+	}
+	for {
+		v, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(v) {
+			return nil
+		}
+	}
+}
+
+func (c *client) PutAPI(api metapb.API) (uint64, error) {
+	if err := pb.ValidateAPI(&api); err != nil {
+		return 0, err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	rsp, err := c.meta.PutAPI(ctx, &rpcpb.PutAPIReq{API: api})
+	if err != nil {
+		return 0, err
+	}
+	return rsp.ID, nil
+}
+
+func (c *client) RemoveAPI(id uint64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.meta.RemoveAPI(ctx, &rpcpb.RemoveAPIReq{ID: id})
+	return err
+}
+
+func (c *client) GetAPIList(fn func(*metapb.API) bool) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	stream, err := c.meta.GetAPIList(ctx, &rpcpb.GetAPIListReq{})
+	if err != nil {
+		return err
+	}
+	for {
+		v, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(v) {
+			return nil
+		}
+	}
+}
+
+func (c *client) PutRouting(routing metapb.Routing) (uint64, error) {
+	if err := pb.ValidateRouting(&routing); err != nil {
+		return 0, err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	rsp, err := c.meta.PutRouting(ctx, &rpcpb.PutRoutingReq{Routing: routing})
+	if err != nil {
+		return 0, err
+	}
+	return rsp.ID, nil
+}
+
+func (c *client) RemoveRouting(id uint64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.meta.RemoveRouting(ctx, &rpcpb.RemoveRoutingReq{ID: id})
+	return err
+}
+
+func (c *client) GetRoutingList(fn func(*metapb.Routing) bool) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	stream, err := c.meta.GetRoutingList(ctx, &rpcpb.GetRoutingListReq{})
+	if err != nil {
+		return err
+	}
+	for {
+		v, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(v) {
+			return nil
+		}
+	}
+}
+
+func (c *client) AddBind(cluster, server uint64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.meta.AddBind(ctx, &rpcpb.AddBindReq{Cluster: cluster, Server: server})
+	return err
+}
+
+func (c *client) RemoveBind(cluster, server uint64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.meta.RemoveBind(ctx, &rpcpb.RemoveBindReq{Cluster: cluster, Server: server})
+	return err
+}
+
+func (c *client) GetBindServers(cluster uint64) ([]uint64, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	rsp, err := c.meta.GetBindServers(ctx, &rpcpb.GetBindServersReq{Cluster: cluster})
+	if err != nil {
+		return nil, err
+	}
+	return rsp.Servers, nil
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
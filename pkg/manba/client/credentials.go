@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// CACertFile is the name of the ca certificate in cert dir
+	CACertFile = "ca.crt"
+	// CertFile is the name of the client certificate in cert dir
+	CertFile = "tls.crt"
+	// KeyFile is the name of the client key in cert dir
+	KeyFile = "tls.key"
+)
+
+// TLSConfig configures the transport security to Manba API Server
+type TLSConfig struct {
+	Enabled bool
+	// CertDir contains ca.crt to verify Manba API Server and an optional
+	// pair of tls.crt and tls.key for mutual TLS, usually a mounted secret
+	CertDir string
+	// ServerName overrides the name used to verify the server certificate
+	ServerName string
+	// InsecureSkipVerify disables verification of the server certificate
+	InsecureSkipVerify bool
+}
+
+func dialOptions(cfg Config) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if cfg.TLS.Enabled {
+		tlsConfig, err := loadTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	if cfg.Token != "" || cfg.TokenFile != "" {
+		if !cfg.TLS.Enabled {
+			glog.Warning("sending token to manba api server without TLS")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(&tokenCredentials{
+			token:     cfg.Token,
+			tokenFile: cfg.TokenFile,
+			secure:    cfg.TLS.Enabled,
+		}))
+	}
+	return opts, nil
+}
+
+// loadTLSConfig loads certificates from cert dir, missing files are skipped
+// so that the system roots and one-way TLS can be used.
+// The client certificate is reloaded on every handshake, but ca.crt is only loaded here:
+// reloading roots needs InsecureSkipVerify with the verification written by hand,
+// which can't see the server name gRPC takes from the target, so a new ca needs a restart
+func loadTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CertDir == "" {
+		return tlsConfig, nil
+	}
+
+	ca, err := ioutil.ReadFile(filepath.Join(cfg.CertDir, CACertFile))
+	if err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("no certificate found in %s", CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "reading ca certificate")
+	}
+
+	certFile := filepath.Join(cfg.CertDir, CertFile)
+	keyFile := filepath.Join(cfg.CertDir, KeyFile)
+	if _, err := os.Stat(certFile); err != nil {
+		if os.IsNotExist(err) {
+			return tlsConfig, nil
+		}
+		return nil, errors.Wrap(err, "reading client certificate")
+	}
+	pair := &keyPair{certFile: certFile, keyFile: keyFile}
+	if err := pair.load(); err != nil {
+		return nil, err
+	}
+	tlsConfig.GetClientCertificate = pair.get
+	return tlsConfig, nil
+}
+
+// keyPair is the client certificate in cert dir, it's reloaded on every handshake
+// so that new connections use a renewed secret without restarting
+type keyPair struct {
+	certFile, keyFile string
+
+	lock sync.Mutex
+	cert *tls.Certificate
+}
+
+func (k *keyPair) load() error {
+	cert, err := tls.LoadX509KeyPair(k.certFile, k.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "loading client certificate")
+	}
+	k.lock.Lock()
+	k.cert = &cert
+	k.lock.Unlock()
+	return nil
+}
+
+// get returns the client certificate, the last loaded one is kept if reloading fails,
+// e.g. while the secret is updated and tls.crt doesn't match tls.key yet
+func (k *keyPair) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := k.load(); err != nil {
+		glog.Warningf("%v, using the last loaded one", err)
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return k.cert, nil
+}
+
+// tokenCredentials sends a bearer token in the metadata of every request
+type tokenCredentials struct {
+	token     string
+	tokenFile string
+	secure    bool
+}
+
+func (t *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token := t.token
+	if t.tokenFile != "" {
+		b, err := ioutil.ReadFile(t.tokenFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading token file")
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	return map[string]string{
+		"authorization": "Bearer " + token,
+	}, nil
+}
+
+func (t *tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
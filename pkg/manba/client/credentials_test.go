@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCert writes a self-signed certificate and its key to dir
+func writeCert(t *testing.T, dir, certFile, keyFile string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "manba"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, certFile), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	if keyFile != "" {
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, keyFile), keyPEM, 0600))
+	}
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manba-client")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// system roots without cert dir
+	cfg, err := loadTLSConfig(TLSConfig{Enabled: true, ServerName: "manba", InsecureSkipVerify: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "manba", cfg.ServerName)
+	assert.True(t, cfg.InsecureSkipVerify)
+	assert.Nil(t, cfg.RootCAs)
+
+	// empty cert dir is one-way TLS with system roots
+	cfg, err = loadTLSConfig(TLSConfig{Enabled: true, CertDir: dir})
+	assert.Nil(t, err)
+	assert.Nil(t, cfg.RootCAs)
+	assert.Empty(t, cfg.Certificates)
+
+	writeCert(t, dir, CACertFile, "")
+	cfg, err = loadTLSConfig(TLSConfig{Enabled: true, CertDir: dir})
+	assert.Nil(t, err)
+	assert.NotNil(t, cfg.RootCAs)
+	assert.Empty(t, cfg.Certificates)
+
+	writeCert(t, dir, CertFile, KeyFile)
+	cfg, err = loadTLSConfig(TLSConfig{Enabled: true, CertDir: dir})
+	assert.Nil(t, err)
+	cert, err := cfg.GetClientCertificate(nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, cert)
+
+	// renewed certificate is used by the next handshake
+	writeCert(t, dir, CertFile, KeyFile)
+	renewed, err := cfg.GetClientCertificate(nil)
+	assert.Nil(t, err)
+	assert.NotEqual(t, cert.Certificate, renewed.Certificate)
+
+	// the key must match the certificate, the last loaded one is kept on reload
+	writeCert(t, dir, "other.crt", KeyFile)
+	kept, err := cfg.GetClientCertificate(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, renewed, kept)
+	_, err = loadTLSConfig(TLSConfig{Enabled: true, CertDir: dir})
+	assert.Contains(t, err.Error(), "loading client certificate")
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, CACertFile), []byte("not a certificate"), 0600))
+	_, err = loadTLSConfig(TLSConfig{Enabled: true, CertDir: dir})
+	assert.Contains(t, err.Error(), "no certificate found in ca.crt")
+}
+
+func TestTokenCredentials(t *testing.T) {
+	creds := &tokenCredentials{token: "static"}
+	md, err := creds.GetRequestMetadata(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"authorization": "Bearer static"}, md)
+	assert.False(t, creds.RequireTransportSecurity())
+
+	f, err := ioutil.TempFile("", "manba-token")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	f.Close()
+
+	// the file is read on every request and replaces the token
+	creds = &tokenCredentials{token: "static", tokenFile: f.Name(), secure: true}
+	assert.Nil(t, ioutil.WriteFile(f.Name(), []byte("first\n"), 0600))
+	md, err = creds.GetRequestMetadata(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer first", md["authorization"])
+	assert.Nil(t, ioutil.WriteFile(f.Name(), []byte("rotated"), 0600))
+	md, err = creds.GetRequestMetadata(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer rotated", md["authorization"])
+	assert.True(t, creds.RequireTransportSecurity())
+
+	os.Remove(f.Name())
+	_, err = creds.GetRequestMetadata(context.Background())
+	assert.Contains(t, err.Error(), "reading token file")
+}
+
+func TestDialOptions(t *testing.T) {
+	opts, err := dialOptions(Config{})
+	assert.Nil(t, err)
+	assert.Len(t, opts, 1)
+
+	opts, err = dialOptions(Config{Token: "token", TLS: TLSConfig{Enabled: true}})
+	assert.Nil(t, err)
+	assert.Len(t, opts, 2)
+
+	_, err = dialOptions(Config{TLS: TLSConfig{Enabled: true, CertDir: "/dev/null"}})
+	assert.NotNil(t, err)
+}
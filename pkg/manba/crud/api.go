@@ -1,8 +1,8 @@
 package crud
 
 import (
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/domgoer/manba-ingress/pkg/manba/state"
-	manba "github.com/fagongzi/gateway/pkg/client"
 )
 
 // apiPostAction crud api in mem-db
@@ -38,8 +38,7 @@ func apiFromObj(obj interface{}) *state.API {
 func (c *apiRawAction) Create(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	api := apiFromObj(event.Obj)
-	cb := c.client.NewAPIBuilder()
-	id, err := cb.Use(api.API).Commit()
+	id, err := c.client.PutAPI(api.API)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +57,6 @@ func (c *apiRawAction) Delete(arg Arg) (Arg, error) {
 func (c *apiRawAction) Update(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	api := apiFromObj(event.Obj)
-	cb := c.client.NewAPIBuilder()
-	_, err := cb.Use(api.API).Commit()
+	_, err := c.client.PutAPI(api.API)
 	return api, err
 }
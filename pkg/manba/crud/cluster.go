@@ -1,8 +1,8 @@
 package crud
 
 import (
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/domgoer/manba-ingress/pkg/manba/state"
-	manba "github.com/fagongzi/gateway/pkg/client"
 )
 
 // clusterPostAction crud cluster in mem-db
@@ -38,8 +38,7 @@ func clusterFromObj(obj interface{}) *state.Cluster {
 func (c *clusterRawAction) Create(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	cluster := clusterFromObj(event.Obj)
-	cb := c.client.NewClusterBuilder()
-	id, err := cb.Use(cluster.Cluster).Commit()
+	id, err := c.client.PutCluster(cluster.Cluster)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +57,6 @@ func (c *clusterRawAction) Delete(arg Arg) (Arg, error) {
 func (c *clusterRawAction) Update(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	cluster := clusterFromObj(event.Obj)
-	cb := c.client.NewClusterBuilder()
-	_, err := cb.Use(cluster.Cluster).Commit()
+	_, err := c.client.PutCluster(cluster.Cluster)
 	return cluster, err
 }
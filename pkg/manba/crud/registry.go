@@ -1,8 +1,8 @@
 package crud
 
 import (
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/domgoer/manba-ingress/pkg/manba/state"
-	manba "github.com/fagongzi/gateway/pkg/client"
 	"github.com/pkg/errors"
 )
 
@@ -1,8 +1,8 @@
 package crud
 
 import (
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/domgoer/manba-ingress/pkg/manba/state"
-	manba "github.com/fagongzi/gateway/pkg/client"
 )
 
 // routingPostAction crud routing in mem-db
@@ -38,8 +38,7 @@ func routingFromObj(obj interface{}) *state.Routing {
 func (c *routingRawAction) Create(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	routing := routingFromObj(event.Obj)
-	cb := c.client.NewRoutingBuilder()
-	id, err := cb.Use(routing.Routing).Commit()
+	id, err := c.client.PutRouting(routing.Routing)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +57,6 @@ func (c *routingRawAction) Delete(arg Arg) (Arg, error) {
 func (c *routingRawAction) Update(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	routing := routingFromObj(event.Obj)
-	cb := c.client.NewRoutingBuilder()
-	_, err := cb.Use(routing.Routing).Commit()
+	_, err := c.client.PutRouting(routing.Routing)
 	return routing, err
 }
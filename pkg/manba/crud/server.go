@@ -1,8 +1,8 @@
 package crud
 
 import (
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/domgoer/manba-ingress/pkg/manba/state"
-	manba "github.com/fagongzi/gateway/pkg/client"
 )
 
 // serverPostAction crud server in mem-db
@@ -38,8 +38,7 @@ func serverFromObj(obj interface{}) *state.Server {
 func (c *serverRawAction) Create(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	server := serverFromObj(event.Obj)
-	cb := c.client.NewServerBuilder()
-	id, err := cb.Use(server.Server).Commit()
+	id, err := c.client.PutServer(server.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +57,6 @@ func (c *serverRawAction) Delete(arg Arg) (Arg, error) {
 func (c *serverRawAction) Update(arg Arg) (Arg, error) {
 	event := eventFromArg(arg)
 	server := serverFromObj(event.Obj)
-	cb := c.client.NewServerBuilder()
-	_, err := cb.Use(server.Server).Commit()
+	_, err := c.client.PutServer(server.Server)
 	return server, err
 }
@@ -33,6 +33,10 @@ func (sc *Syncer) deleteAPIs() error {
 
 func (sc *Syncer) deleteAPI(api *state.API) (*crud.Event, error) {
 	_, err := sc.targetState.APIs.Get(api.Identifier())
+	if err == state.ErrNotFound {
+		// renamed apis keep their ids
+		_, err = sc.targetState.APIs.Get(idStr(api.ID))
+	}
 	if err == state.ErrNotFound {
 		return &crud.Event{
 			Op:   crud.Delete,
@@ -72,6 +76,9 @@ func (sc *Syncer) createUpdateAPI(api *state.API) (*crud.Event, error) {
 	newAPI := &state.API{API: manbaAPI}
 
 	current, err := sc.currentState.APIs.Get(newAPI.Identifier())
+	if err == state.ErrNotFound {
+		current, err = sc.currentState.APIs.Get(idStr(newAPI.ID))
+	}
 	if err == state.ErrNotFound {
 		// api not present, create it
 		return &crud.Event{
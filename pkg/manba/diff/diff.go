@@ -2,6 +2,7 @@ package diff
 
 import (
 	"errors"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -180,3 +181,8 @@ func (sc *Syncer) delete() error {
 	}
 	return nil
 }
+
+// idStr formats id to look entities up by id
+func idStr(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
@@ -33,6 +33,10 @@ func (sc *Syncer) deleteRoutings() error {
 
 func (sc *Syncer) deleteRouting(routing *state.Routing) (*crud.Event, error) {
 	_, err := sc.targetState.Routings.Get(routing.Identifier())
+	if err == state.ErrNotFound {
+		// renamed routings keep their ids
+		_, err = sc.targetState.Routings.Get(idStr(routing.ID))
+	}
 	if err == state.ErrNotFound {
 		return &crud.Event{
 			Op:   crud.Delete,
@@ -72,6 +76,9 @@ func (sc *Syncer) createUpdateRouting(routing *state.Routing) (*crud.Event, erro
 	newRouting := &state.Routing{Routing: manbaRouting}
 
 	current, err := sc.currentState.Routings.Get(newRouting.Identifier())
+	if err == state.ErrNotFound {
+		current, err = sc.currentState.Routings.Get(idStr(newRouting.ID))
+	}
 	if err == state.ErrNotFound {
 		// routing not present, create it
 		return &crud.Event{
@@ -1,7 +1,7 @@
 package dump
 
 import (
-	manba "github.com/fagongzi/gateway/pkg/client"
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/fagongzi/gateway/pkg/pb/metapb"
 	"github.com/pkg/errors"
 )
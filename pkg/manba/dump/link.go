@@ -0,0 +1,64 @@
+package dump
+
+import (
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/golang/glog"
+)
+
+// LinkIDs sets ids of the relations of entities by their names, ids of entities must be set
+func (s *ManbaRawState) LinkIDs() {
+	serverAddrIDsMap := make(map[string]uint64, len(s.Servers))
+	clusterNameIDsMap := make(map[string]uint64, len(s.Clusters))
+	apiNameIDsMap := make(map[string]uint64, len(s.APIs))
+	for _, server := range s.Servers {
+		serverAddrIDsMap[server.GetAddr()] = server.GetID()
+	}
+	for _, cluster := range s.Clusters {
+		clusterNameIDsMap[cluster.GetName()] = cluster.GetID()
+	}
+	for _, api := range s.APIs {
+		apiNameIDsMap[api.Name] = api.ID
+	}
+
+	for _, bind := range s.Binds {
+		clusterID, ok := clusterNameIDsMap[bind.ClusterName]
+		if !ok {
+			glog.Warningf("not found cluster <%s> in bind", bind.ClusterName)
+			continue
+		}
+
+		serverID, ok := serverAddrIDsMap[bind.ServerAddr]
+		if !ok {
+			glog.Warningf("not found server <%s> in bind", bind.ServerAddr)
+			continue
+		}
+
+		bind.Bind = &metapb.Bind{
+			ClusterID: clusterID,
+			ServerID:  serverID,
+		}
+	}
+
+	for _, api := range s.APIs {
+		var nodes []*metapb.DispatchNode
+		for _, proxy := range api.Proxies {
+			n := proxy.DispatchNode
+			n.ClusterID = clusterNameIDsMap[proxy.ClusterName]
+			nodes = append(nodes, &n)
+		}
+		api.Nodes = nodes
+	}
+
+	for _, routing := range s.Routings {
+		if cid, ok := clusterNameIDsMap[routing.ClusterName]; !ok {
+			glog.Warningf("not found cluster name <%s>", routing.ClusterName)
+		} else {
+			routing.ClusterID = cid
+		}
+		if aid, ok := apiNameIDsMap[routing.APIName]; !ok {
+			glog.Warningf("not found api name <%s>", routing.APIName)
+		} else {
+			routing.API = aid
+		}
+	}
+}
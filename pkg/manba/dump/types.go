@@ -1,7 +1,6 @@
 package dump
 
 import (
-	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
 	"github.com/fagongzi/gateway/pkg/pb/metapb"
 )
 
@@ -42,5 +41,11 @@ type Routing struct {
 type API struct {
 	*metapb.API
 
-	Proxies []parser.Proxy
+	Proxies []Proxy
+}
+
+// Proxy is a dispatch node of api, it refers to the cluster by name until ids are linked
+type Proxy struct {
+	ClusterName string
+	metapb.DispatchNode
 }
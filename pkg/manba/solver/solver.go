@@ -3,10 +3,11 @@ package solver
 import (
 	"errors"
 	"strings"
+	"sync"
 
+	manba "github.com/domgoer/manba-ingress/pkg/manba/client"
 	"github.com/domgoer/manba-ingress/pkg/manba/crud"
 	"github.com/domgoer/manba-ingress/pkg/manba/diff"
-	manba "github.com/fagongzi/gateway/pkg/client"
 	"github.com/golang/glog"
 )
 
@@ -15,15 +16,37 @@ type Stats struct {
 	CreateOps int
 	UpdateOps int
 	DeleteOps int
+
+	// KindOps counts operations by kind, the key of inner map is
+	// the name of op
+	KindOps map[crud.Kind]map[string]int
 }
 
+// OpCallback is invoked after every operation sent to Manba,
+// err is nil if the operation succeeded.
+// It may be invoked concurrently.
+type OpCallback func(e crud.Event, err error)
+
 // Solve generates a diff and walks the graph.
+// onOp is optional and is called with the result of every operation.
 func Solve(doneCh chan struct{}, syncer *diff.Syncer,
-	client manba.Client, parallelism int) (Stats, error) {
+	client manba.Client, parallelism int, onOp OpCallback) (Stats, error) {
 	r := crud.NewRawRegistry(client)
 
 	var stats Stats
-	recordOp := func(op crud.Op) {
+	var lock sync.Mutex
+	recordOp := func(kind crud.Kind, op crud.Op) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if stats.KindOps == nil {
+			stats.KindOps = make(map[crud.Kind]map[string]int)
+		}
+		if stats.KindOps[kind] == nil {
+			stats.KindOps[kind] = make(map[string]int)
+		}
+		stats.KindOps[kind][op.String()]++
+
 		switch op {
 		case crud.Create:
 			stats.CreateOps = stats.CreateOps + 1
@@ -60,11 +83,14 @@ func Solve(doneCh chan struct{}, syncer *diff.Syncer,
 		// sync mode
 		// fire the request to Manba
 		result, err = r.Do(e.Kind, e.Op, e)
+		if onOp != nil {
+			onOp(e, err)
+		}
 		if err != nil {
 			return nil, err
 		}
 		// record operation in both: diff and sync commands
-		recordOp(e.Op)
+		recordOp(e.Kind, e.Op)
 
 		return result, nil
 	})
@@ -78,3 +104,25 @@ func Solve(doneCh chan struct{}, syncer *diff.Syncer,
 	return stats, errors.New(strings.Join(list, "\n"))
 
 }
+
+// Plan walks the diff without sending any request to Manba,
+// and returns the operations Solve would perform in order.
+func Plan(syncer *diff.Syncer) ([]crud.Event, error) {
+	var events []crud.Event
+	errs := syncer.Run(nil, 1, func(a crud.Arg) (crud.Arg, error) {
+		e, ok := a.(crud.Event)
+		if !ok {
+			return nil, errors.New("unknown operation")
+		}
+		events = append(events, e)
+		return e.Obj, nil
+	})
+	var list []string
+	for _, e := range errs {
+		list = append(list, e.Error())
+	}
+	if len(list) == 0 {
+		return events, nil
+	}
+	return events, errors.New(strings.Join(list, "\n"))
+}
@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/domgoer/manba-ingress/pkg/manba/crud"
+	"github.com/domgoer/manba-ingress/pkg/manba/dump"
+	"github.com/fagongzi/gateway/pkg/pb"
+)
+
+// InvalidEntity is an entity which would be rejected by Manba
+type InvalidEntity struct {
+	Kind       crud.Kind
+	Identifier string
+	Err        error
+}
+
+func (e InvalidEntity) String() string {
+	return fmt.Sprintf("%s <%s> is rejected by Manba validation: %v", e.Kind, e.Identifier, e.Err)
+}
+
+// Filter drops entities which would be rejected by Manba, ids of raw must be set
+func Filter(raw *dump.ManbaRawState) (*dump.ManbaRawState, []InvalidEntity) {
+	res := new(dump.ManbaRawState)
+	var invalid []InvalidEntity
+	validClusters := make(map[uint64]bool, len(raw.Clusters))
+	validServers := make(map[uint64]bool, len(raw.Servers))
+
+	for _, cluster := range raw.Clusters {
+		if err := pb.ValidateCluster(cluster.Cluster); err != nil {
+			invalid = append(invalid, InvalidEntity{"cluster", cluster.GetName(), err})
+			continue
+		}
+		validClusters[cluster.GetID()] = true
+		res.Clusters = append(res.Clusters, cluster)
+	}
+
+	for _, server := range raw.Servers {
+		if err := pb.ValidateServer(server.Server); err != nil {
+			invalid = append(invalid, InvalidEntity{"server", server.GetAddr(), err})
+			continue
+		}
+		validServers[server.GetID()] = true
+		res.Servers = append(res.Servers, server)
+	}
+
+	for _, bind := range raw.Binds {
+		if validServers[bind.GetServerID()] && validClusters[bind.GetClusterID()] {
+			res.Binds = append(res.Binds, bind)
+		} else {
+			invalid = append(invalid, InvalidEntity{"bind", fmt.Sprintf("%s-%s", bind.ClusterName, bind.ServerAddr),
+				fmt.Errorf("cluster: %d or server: %d is invalid", bind.GetClusterID(), bind.GetServerID())})
+		}
+	}
+
+	for _, api := range raw.APIs {
+		if err := pb.ValidateAPI(api.API); err != nil {
+			invalid = append(invalid, InvalidEntity{"api", api.GetName(), err})
+			continue
+		}
+		res.APIs = append(res.APIs, api)
+	}
+
+	for _, routing := range raw.Routings {
+		if err := pb.ValidateRouting(routing.Routing); err != nil {
+			invalid = append(invalid, InvalidEntity{"routing", routing.GetName(), err})
+			continue
+		}
+		res.Routings = append(res.Routings, routing)
+	}
+	return res, invalid
+}
+
+// Validate converts s and runs the validators of Manba the same way as a sync
+// without touching Manba, it returns the entities which would be dropped
+func Validate(s *parser.ManbaState) []InvalidEntity {
+	raw := s.Raw()
+	// entities of dry run only need unique IDs to be linked
+	var id uint64
+	nextID := func() uint64 {
+		id++
+		return id
+	}
+	for _, server := range raw.Servers {
+		server.ID = nextID()
+	}
+	for _, cluster := range raw.Clusters {
+		cluster.ID = nextID()
+	}
+	for _, api := range raw.APIs {
+		api.ID = nextID()
+	}
+	for _, routing := range raw.Routings {
+		routing.ID = nextID()
+	}
+	raw.LinkIDs()
+	_, invalid := Filter(raw)
+	return invalid
+}
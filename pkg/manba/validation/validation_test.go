@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/domgoer/manba-ingress/pkg/ingress/controller/parser"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	s := &parser.ManbaState{
+		APIs: []parser.API{
+			{API: metapb.API{Name: "default.ing.0000", URLPattern: "/"}},
+			{API: metapb.API{Name: "default.ing.0001", URLPattern: "("}},
+		},
+		Routings: []parser.Routing{
+			// linked to the api and cluster by name
+			{APIName: "default.ing.0000", ClusterName: "default.cls.v1.80.svc", Routing: metapb.Routing{Name: "default.ing.0000.split.0", TrafficRate: 50}},
+			{APIName: "default.ing.missing", ClusterName: "default.cls.v1.80.svc", Routing: metapb.Routing{Name: "default.ing.missing.split.0", TrafficRate: 50}},
+		},
+		Clusters: []parser.Cluster{
+			{Cluster: metapb.Cluster{Name: "default.cls.v1.80.svc"}},
+		},
+		Servers: []parser.Server{
+			{Server: metapb.Server{Addr: "1.1.1.1:80", MaxQPS: 100}},
+			{Server: metapb.Server{Addr: "2.2.2.2:80"}},
+		},
+	}
+
+	invalid := Validate(s)
+	var identifiers []string
+	for _, e := range invalid {
+		identifiers = append(identifiers, string(e.Kind)+"/"+e.Identifier)
+	}
+	assert.Equal(t, []string{"server/2.2.2.2:80", "api/default.ing.0001", "routing/default.ing.missing.split.0"}, identifiers)
+	assert.Equal(t, "server <2.2.2.2:80> is rejected by Manba validation: missing server max qps", invalid[0].String())
+}
@@ -0,0 +1,96 @@
+package promotion
+
+import (
+	"reflect"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// MaxHistory is the max number of promotions kept in status of a cluster
+const MaxHistory = 10
+
+// Controller records changes of active subsets of manba clusters in their status
+type Controller struct {
+	client   versioned.Interface
+	store    store.Store
+	isLeader func() bool
+
+	now func() time.Time
+}
+
+// New returns a promotion controller, only the leader updates clusters
+func New(client versioned.Interface, s store.Store, isLeader func() bool) *Controller {
+	return &Controller{
+		client:   client,
+		store:    s,
+		isLeader: isLeader,
+		now:      time.Now,
+	}
+}
+
+// Run checks clusters every period until stopCh is closed
+func (c *Controller) Run(period time.Duration, stopCh <-chan struct{}) {
+	wait.Until(c.syncAll, period, stopCh)
+}
+
+func (c *Controller) syncAll() {
+	if !c.isLeader() {
+		return
+	}
+	for _, cluster := range c.store.ListManbaClusters() {
+		if err := c.sync(cluster); err != nil {
+			glog.Errorf("syncing status of manba cluster %s/%s: %v", cluster.Namespace, cluster.Name, err)
+		}
+	}
+}
+
+func (c *Controller) sync(cluster *configurationv1beta1.ManbaCluster) error {
+	status := c.nextStatus(cluster)
+	if reflect.DeepEqual(status, cluster.Status) {
+		return nil
+	}
+
+	updated := cluster.DeepCopy()
+	updated.Status = status
+	_, err := c.client.ConfigurationV1beta1().ManbaClusters(cluster.Namespace).UpdateStatus(updated)
+	return errors.Wrap(err, "updating status")
+}
+
+// nextStatus returns the status of cluster with the change of active subset recorded
+func (c *Controller) nextStatus(cluster *configurationv1beta1.ManbaCluster) configurationv1beta1.ManbaClusterStatus {
+	status := *cluster.Status.DeepCopy()
+	status.PreviewSubset = cluster.Spec.PreviewSubset
+	from, to := status.ActiveSubset, cluster.Spec.ActiveSubset
+	if from == to {
+		return status
+	}
+	status.ActiveSubset = to
+	if to == "" {
+		return status
+	}
+
+	promotion := configurationv1beta1.ManbaClusterPromotion{
+		Type: configurationv1beta1.Promote,
+		From: from,
+		To:   to,
+		Time: metav1.NewTime(c.now()),
+	}
+	// going back to the subset active before the last promotion is a rollback
+	if n := len(status.History); n != 0 && status.History[n-1].From == to {
+		promotion.Type = configurationv1beta1.Rollback
+	}
+	glog.Infof("manba cluster %s/%s: %s from subset %q to %q", cluster.Namespace, cluster.Name, promotion.Type, from, to)
+
+	status.History = append(status.History, promotion)
+	if len(status.History) > MaxHistory {
+		status.History = status.History[len(status.History)-MaxHistory:]
+	}
+	return status
+}
@@ -0,0 +1,72 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/fake"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestController_NextStatus(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &Controller{now: func() time.Time { return now }}
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+	}
+
+	for _, active := range []string{"v1", "v2", "v1", "v3"} {
+		cluster.Spec.ActiveSubset = active
+		cluster.Status = c.nextStatus(cluster)
+		assert.Equal(t, active, cluster.Status.ActiveSubset)
+	}
+	// unchanged
+	cluster.Status = c.nextStatus(cluster)
+
+	var types []configurationv1beta1.PromotionType
+	for _, p := range cluster.Status.History {
+		types = append(types, p.Type)
+	}
+	assert.Equal(t, []configurationv1beta1.PromotionType{
+		configurationv1beta1.Promote,
+		configurationv1beta1.Promote,
+		configurationv1beta1.Rollback,
+		configurationv1beta1.Promote,
+	}, types)
+	assert.Equal(t, configurationv1beta1.ManbaClusterPromotion{
+		Type: configurationv1beta1.Rollback,
+		From: "v2",
+		To:   "v1",
+		Time: metav1.NewTime(now),
+	}, cluster.Status.History[2])
+
+	for i := 0; i < MaxHistory; i++ {
+		cluster.Spec.ActiveSubset = string(rune('a' + i))
+		cluster.Status = c.nextStatus(cluster)
+	}
+	assert.Len(t, cluster.Status.History, MaxHistory)
+}
+
+func TestController_Sync(t *testing.T) {
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			ActiveSubset:  "v1",
+			PreviewSubset: "v2",
+		},
+	}
+	client := fake.NewSimpleClientset(cluster)
+	s, err := store.NewFakeStore(nil, []runtime.Object{cluster})
+	assert.Nil(t, err)
+	New(client, s, func() bool { return true }).syncAll()
+
+	got, err := client.ConfigurationV1beta1().ManbaClusters("default").Get("cls", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", got.Status.ActiveSubset)
+	assert.Equal(t, "v2", got.Status.PreviewSubset)
+	assert.Len(t, got.Status.History, 1)
+}
@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	assert.Nil(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.NotNil(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy"})
+	assert.NotNil(t, err)
+
+	// forwarded addresses of untrusted peers are ignored
+	assert.Equal(t, "1.1.1.1", ClientIP("1.1.1.1:1234", []byte("2.2.2.2"), trusted))
+	assert.Equal(t, "1.1.1.1", ClientIP("1.1.1.1:1234", []byte("2.2.2.2"), nil))
+	assert.Equal(t, "10.0.0.1", ClientIP("10.0.0.1:1234", nil, trusted))
+	// the client is the last address not of trusted proxies
+	assert.Equal(t, "2.2.2.2", ClientIP("10.0.0.1:1234", []byte("2.2.2.2"), trusted))
+	assert.Equal(t, "2.2.2.2", ClientIP("10.0.0.1:1234", []byte("6.6.6.6, 2.2.2.2, 192.168.1.1"), trusted))
+	assert.Equal(t, "2001:db8::1", ClientIP("[fd00::1]:1234", []byte("2001:db8::1"), trusted))
+	assert.Equal(t, "10.0.0.2", ClientIP("10.0.0.1:1234", []byte("10.0.0.2, "), trusted))
+}
@@ -0,0 +1,10 @@
+module github.com/domgoer/manba-ingress/pkg/ratelimit
+
+go 1.13
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.5.1
+	gopkg.in/yaml.v2 v2.2.8 // indirect
+)
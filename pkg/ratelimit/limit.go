@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// TagName is the name of api tag carrying the rate limit of api
+const TagName = "manba-ingress.rate-limit"
+
+// sources of client keys, they're the ones of ManbaHTTPRateLimit
+const (
+	SourceHeader   = "header"
+	SourceQuery    = "query"
+	SourceCookie   = "cookie"
+	SourceClientIP = "clientIP"
+)
+
+// Limit is the rate limit of each client of an api
+type Limit struct {
+	// Scope names the quota, apis with the same scope share the quota of each client
+	Scope string `json:"scope"`
+	// Source and Name select the parameter of request identifying the client
+	Source string `json:"source"`
+	Name   string `json:"name,omitempty"`
+	QPS    uint32 `json:"qps"`
+	Burst  uint32 `json:"burst"`
+}
+
+// TagValue returns the value of api tag TagName carrying l
+func (l *Limit) TagValue() string {
+	// a struct of strings and integers always marshals
+	value, _ := json.Marshal(l)
+	return string(value)
+}
+
+// ParseTag returns the limit carried by value of api tag TagName
+func ParseTag(value string) (*Limit, error) {
+	l := new(Limit)
+	if err := json.Unmarshal([]byte(value), l); err != nil {
+		return nil, errors.Wrapf(err, "parsing tag %s", TagName)
+	}
+	return l, nil
+}
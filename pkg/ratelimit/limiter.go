@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIdle is how long buckets of clients are kept after their last request
+	DefaultIdle = time.Minute
+	// DefaultMaxBuckets is the number of buckets kept at most by default
+	DefaultMaxBuckets = 100000
+
+	// overflowClient is the client of the bucket shared by clients beyond the max buckets,
+	// it's not a value of any parameter
+	overflowClient = "\x01"
+)
+
+// Limiter holds a token bucket of each client in each scope
+type Limiter struct {
+	// buckets unused for idle are dropped, they're full again after burst/qps anyway
+	idle time.Duration
+	// maxBuckets bounds the memory used by clients, requests of new clients share
+	// one bucket of each scope once it's reached, until idle buckets are dropped
+	maxBuckets int
+	now        func() time.Time
+
+	lock      sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	qps, burst uint32
+	tokens     float64
+	// filled is the time tokens are counted to
+	filled time.Time
+	used   time.Time
+}
+
+// NewLimiter returns a limiter which drops buckets unused for idle and keeps maxBuckets at most
+func NewLimiter(idle time.Duration, maxBuckets int) *Limiter {
+	return &Limiter{
+		idle:       idle,
+		maxBuckets: maxBuckets,
+		now:        time.Now,
+		buckets:    make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether a request of client is allowed by limit, and takes a token if it is
+func (l *Limiter) Allow(limit *Limit, client string) bool {
+	now := l.now()
+	key := limit.Scope + "\x00" + client
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= l.maxBuckets {
+		key = limit.Scope + "\x00" + overflowClient
+		b, ok = l.buckets[key]
+	}
+	// the bucket is refilled if the limit changes
+	if !ok || b.qps != limit.QPS || b.burst != limit.Burst {
+		b = &bucket{
+			qps:    limit.QPS,
+			burst:  limit.Burst,
+			tokens: float64(limit.Burst),
+			filled: now,
+		}
+		l.buckets[key] = b
+	}
+	b.used = now
+	return b.take(now)
+}
+
+// take refills the bucket to now and takes a token if there's one
+func (b *bucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.filled); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * float64(b.qps)
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.filled = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep drops idle buckets, it runs once in idle at most
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.used) >= l.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTag(t *testing.T) {
+	limit := &Limit{Scope: "default/ing", Source: SourceHeader, Name: "X-Api-Key", QPS: 1, Burst: 2}
+
+	res, err := ParseTag(limit.TagValue())
+	assert.Nil(t, err)
+	assert.Equal(t, limit, res)
+
+	_, err = ParseTag("{")
+	assert.NotNil(t, err)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(time.Minute, DefaultMaxBuckets)
+	l.now = func() time.Time { return now }
+	limit := &Limit{Scope: "api", QPS: 1, Burst: 2}
+
+	assert.True(t, l.Allow(limit, "a"))
+	assert.True(t, l.Allow(limit, "a"))
+	assert.False(t, l.Allow(limit, "a"))
+	// clients and scopes have their own quotas
+	assert.True(t, l.Allow(limit, "b"))
+	assert.True(t, l.Allow(&Limit{Scope: "other", QPS: 1, Burst: 2}, "a"))
+
+	now = now.Add(time.Second)
+	assert.True(t, l.Allow(limit, "a"))
+	assert.False(t, l.Allow(limit, "a"))
+
+	// changing the limit refills the bucket
+	assert.True(t, l.Allow(&Limit{Scope: "api", QPS: 2, Burst: 2}, "a"))
+
+	now = now.Add(time.Minute)
+	l.Allow(limit, "a")
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_MaxBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(time.Minute, 2)
+	l.now = func() time.Time { return now }
+	limit := &Limit{Scope: "api", QPS: 1, Burst: 1}
+
+	assert.True(t, l.Allow(limit, "a"))
+	assert.True(t, l.Allow(limit, "b"))
+	// new clients share one bucket beyond the max
+	assert.True(t, l.Allow(limit, "c"))
+	assert.False(t, l.Allow(limit, "d"))
+	assert.False(t, l.Allow(limit, "a"))
+	assert.Len(t, l.buckets, 3)
+
+	// clients get their own buckets again after idle ones are dropped
+	now = now.Add(time.Minute)
+	assert.True(t, l.Allow(limit, "c"))
+	assert.True(t, l.Allow(limit, "d"))
+	assert.Len(t, l.buckets, 2)
+}
@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Controller reports schedules in their windows in status of manba ingresses and clusters
+type Controller struct {
+	client   versioned.Interface
+	store    store.Store
+	isLeader func() bool
+
+	now func() time.Time
+}
+
+// New returns a schedule controller, only the leader updates status
+func New(client versioned.Interface, s store.Store, isLeader func() bool) *Controller {
+	return &Controller{
+		client:   client,
+		store:    s,
+		isLeader: isLeader,
+		now:      time.Now,
+	}
+}
+
+// Run checks schedules every period until stopCh is closed
+func (c *Controller) Run(period time.Duration, stopCh <-chan struct{}) {
+	wait.Until(c.syncAll, period, stopCh)
+}
+
+func (c *Controller) syncAll() {
+	if !c.isLeader() {
+		return
+	}
+	now := c.now()
+	for _, ingress := range c.store.ListManbaIngresses() {
+		if err := c.syncIngress(ingress, now); err != nil {
+			glog.Errorf("syncing schedules of manba ingress %s/%s: %v", ingress.Namespace, ingress.Name, err)
+		}
+	}
+	for _, cluster := range c.store.ListManbaClusters() {
+		if err := c.syncCluster(cluster, now); err != nil {
+			glog.Errorf("syncing schedules of manba cluster %s/%s: %v", cluster.Namespace, cluster.Name, err)
+		}
+	}
+}
+
+func (c *Controller) syncIngress(ingress *configurationv1beta1.ManbaIngress, now time.Time) error {
+	var active []configurationv1beta1.ManbaActiveSchedule
+	for i, rule := range ingress.Spec.HTTP {
+		target := rule.Name
+		if target == "" {
+			target = fmt.Sprintf("http[%d]", i)
+		}
+		active = appendActive(active, target, rule.Schedules, now)
+	}
+	if equal(active, ingress.Status.ActiveSchedules) {
+		return nil
+	}
+
+	updated := ingress.DeepCopy()
+	updated.Status.ActiveSchedules = active
+	_, err := c.client.ConfigurationV1beta1().ManbaIngresses(ingress.Namespace).UpdateStatus(updated)
+	return errors.Wrap(err, "updating status")
+}
+
+func (c *Controller) syncCluster(cluster *configurationv1beta1.ManbaCluster, now time.Time) error {
+	var active []configurationv1beta1.ManbaActiveSchedule
+	for _, subset := range cluster.Spec.Subsets {
+		active = appendActive(active, subset.Name, subset.Schedules, now)
+	}
+	if equal(active, cluster.Status.ActiveSchedules) {
+		return nil
+	}
+
+	updated := cluster.DeepCopy()
+	updated.Status.ActiveSchedules = active
+	_, err := c.client.ConfigurationV1beta1().ManbaClusters(cluster.Namespace).UpdateStatus(updated)
+	return errors.Wrap(err, "updating status")
+}
+
+// appendActive appends the schedule of target in its window to active,
+// invalid schedules are skipped since the parser reports them
+func appendActive(active []configurationv1beta1.ManbaActiveSchedule, target string,
+	schedules []configurationv1beta1.ManbaSchedule, now time.Time) []configurationv1beta1.ManbaActiveSchedule {
+	schedule, _, err := configurationv1beta1.ActiveSchedule(schedules, now)
+	if err != nil || schedule == nil {
+		return active
+	}
+	_, until, _ := schedule.Window.Evaluate(now)
+	return append(active, configurationv1beta1.ManbaActiveSchedule{
+		Target:   target,
+		Schedule: schedule.Name,
+		Until:    metav1.NewTime(until.Local()),
+	})
+}
+
+// equal compares until by instants, since times read from the api server are in local time zone
+func equal(a, b []configurationv1beta1.ManbaActiveSchedule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Target != b[i].Target || a[i].Schedule != b[i].Schedule || !a[i].Until.Equal(&b[i].Until) {
+			return false
+		}
+	}
+	return true
+}
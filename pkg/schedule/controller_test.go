@@ -0,0 +1,65 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/fake"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestController_Sync(t *testing.T) {
+	night := []configurationv1beta1.ManbaSchedule{{
+		Name:   "night",
+		Window: configurationv1beta1.ManbaTimeWindow{Start: "22:00", End: "06:00"},
+	}}
+	ingress := &configurationv1beta1.ManbaIngress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ing", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaIngressSpec{
+			HTTP: []configurationv1beta1.ManbaHTTPRule{
+				{Name: "api", Schedules: night},
+				{Schedules: night},
+			},
+		},
+	}
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			Subsets: []configurationv1beta1.ManbaClusterSubSet{
+				{Name: "v1", Schedules: night},
+				{Name: "v2"},
+			},
+		},
+	}
+	client := fake.NewSimpleClientset(ingress, cluster)
+	s, err := store.NewFakeStore(nil, []runtime.Object{ingress, cluster})
+	assert.Nil(t, err)
+	c := New(client, s, func() bool { return true })
+	now := time.Date(2020, 1, 6, 23, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	c.syncAll()
+
+	until := metav1.NewTime(time.Date(2020, 1, 7, 6, 0, 0, 0, time.UTC).Local())
+	gotIngress, err := client.ConfigurationV1beta1().ManbaIngresses("default").Get("ing", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []configurationv1beta1.ManbaActiveSchedule{
+		{Target: "api", Schedule: "night", Until: until},
+		{Target: "http[1]", Schedule: "night", Until: until},
+	}, gotIngress.Status.ActiveSchedules)
+	gotCluster, err := client.ConfigurationV1beta1().ManbaClusters("default").Get("cls", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []configurationv1beta1.ManbaActiveSchedule{
+		{Target: "v1", Schedule: "night", Until: until},
+	}, gotCluster.Status.ActiveSchedules)
+
+	// the status read back is unchanged, and it's cleared out of the window
+	assert.Nil(t, c.syncCluster(gotCluster, now))
+	assert.Nil(t, c.syncCluster(gotCluster, now.Add(8*time.Hour)))
+	gotCluster, err = client.ConfigurationV1beta1().ManbaClusters("default").Get("cls", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, gotCluster.Status.ActiveSchedules)
+}
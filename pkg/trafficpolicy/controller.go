@@ -0,0 +1,70 @@
+package trafficpolicy
+
+import (
+	"time"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Controller reports effective traffic policies of subsets in status of manba clusters
+type Controller struct {
+	client   versioned.Interface
+	store    store.Store
+	resolver *Resolver
+	isLeader func() bool
+}
+
+// New returns a traffic policy controller, only the leader updates status
+func New(client versioned.Interface, s store.Store, resolver *Resolver, isLeader func() bool) *Controller {
+	return &Controller{
+		client:   client,
+		store:    s,
+		resolver: resolver,
+		isLeader: isLeader,
+	}
+}
+
+// Run checks traffic policies every period until stopCh is closed
+func (c *Controller) Run(period time.Duration, stopCh <-chan struct{}) {
+	wait.Until(c.syncAll, period, stopCh)
+}
+
+func (c *Controller) syncAll() {
+	if !c.isLeader() {
+		return
+	}
+	for _, cluster := range c.store.ListManbaClusters() {
+		if err := c.syncCluster(cluster); err != nil {
+			glog.Errorf("syncing traffic policies of manba cluster %s/%s: %v", cluster.Namespace, cluster.Name, err)
+		}
+	}
+}
+
+func (c *Controller) syncCluster(cluster *configurationv1beta1.ManbaCluster) error {
+	inherited, _, err := c.resolver.Inherited(cluster)
+	if err != nil {
+		return err
+	}
+	var policies []configurationv1beta1.ManbaSubsetTrafficPolicy
+	for i := range cluster.Spec.Subsets {
+		subset := &cluster.Spec.Subsets[i]
+		policies = append(policies, configurationv1beta1.ManbaSubsetTrafficPolicy{
+			Subset: subset.Name,
+			Policy: *Effective(cluster, subset, inherited),
+		})
+	}
+	if equality.Semantic.DeepEqual(policies, cluster.Status.TrafficPolicies) {
+		return nil
+	}
+
+	updated := cluster.DeepCopy()
+	updated.Status.TrafficPolicies = policies
+	_, err = c.client.ConfigurationV1beta1().ManbaClusters(cluster.Namespace).UpdateStatus(updated)
+	return errors.Wrap(err, "updating status")
+}
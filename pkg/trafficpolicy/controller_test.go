@@ -0,0 +1,78 @@
+package trafficpolicy
+
+import (
+	"testing"
+
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/client/clientset/versioned/fake"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestController_Sync(t *testing.T) {
+	str := func(s string) *string { return &s }
+	breaker := &metapb.CircuitBreaker{FailureRateToClose: 50}
+	shared := &configurationv1beta1.ManbaTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default"},
+		Spec:       configurationv1beta1.TrafficPolicy{CircuitBreaker: breaker, MaxQPS: 1000},
+	}
+	namespaceDefault := &configurationv1beta1.ManbaTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: configurationv1beta1.DefaultTrafficPolicyName, Namespace: "default"},
+		Spec:       configurationv1beta1.TrafficPolicy{LoadBalancer: str("IPHash"), MaxQPS: 10},
+	}
+	global := &configurationv1beta1.ManbaTrafficPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "global", Namespace: "manba"},
+		Spec:       configurationv1beta1.TrafficPolicy{LoadBalancer: str("RoundRobin"), MaxQPS: 1},
+	}
+	cluster := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cls", Namespace: "default"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			TrafficPolicyRef: "shared",
+			Subsets: []configurationv1beta1.ManbaClusterSubSet{
+				{Name: "v1", TrafficPolicy: &configurationv1beta1.TrafficPolicy{MaxQPS: 100}},
+				{Name: "v2"},
+			},
+		},
+	}
+	other := &configurationv1beta1.ManbaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+		Spec: configurationv1beta1.ManbaClusterSpec{
+			TrafficPolicyRef: "missing",
+			Subsets:          []configurationv1beta1.ManbaClusterSubSet{{Name: "v1"}},
+		},
+	}
+	client := fake.NewSimpleClientset(cluster, other)
+	s, err := store.NewFakeStore(nil, []runtime.Object{shared, namespaceDefault, global, cluster, other})
+	assert.Nil(t, err)
+	_, err = NewResolver(s, "global")
+	assert.NotNil(t, err)
+	resolver, err := NewResolver(s, "manba/global")
+	assert.Nil(t, err)
+
+	_, missing, err := resolver.Inherited(other)
+	assert.Nil(t, err)
+	assert.True(t, missing)
+
+	c := New(client, s, resolver, func() bool { return true })
+	c.syncAll()
+
+	got, err := client.ConfigurationV1beta1().ManbaClusters("default").Get("cls", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []configurationv1beta1.ManbaSubsetTrafficPolicy{
+		{Subset: "v1", Policy: configurationv1beta1.TrafficPolicy{LoadBalancer: str("IPHash"), MaxQPS: 100, CircuitBreaker: breaker}},
+		{Subset: "v2", Policy: configurationv1beta1.TrafficPolicy{LoadBalancer: str("IPHash"), MaxQPS: 1000, CircuitBreaker: breaker}},
+	}, got.Status.TrafficPolicies)
+	got, err = client.ConfigurationV1beta1().ManbaClusters("other").Get("other", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []configurationv1beta1.ManbaSubsetTrafficPolicy{
+		{Subset: "v1", Policy: configurationv1beta1.TrafficPolicy{LoadBalancer: str("RoundRobin"), MaxQPS: 1}},
+	}, got.Status.TrafficPolicies)
+
+	// the status read back is unchanged
+	client.ClearActions()
+	assert.Nil(t, c.syncCluster(got))
+	assert.Empty(t, client.Actions())
+}
@@ -0,0 +1,80 @@
+package trafficpolicy
+
+import (
+	configurationv1beta1 "github.com/domgoer/manba-ingress/pkg/apis/configuration/v1beta1"
+	"github.com/domgoer/manba-ingress/pkg/ingress/store"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Resolver looks up the traffic policies manba clusters inherit
+type Resolver struct {
+	store store.Store
+	// namespace and name of ManbaTrafficPolicy inherited by all manba clusters, they're empty if it's not set
+	namespace, name string
+}
+
+// NewResolver returns a resolver, global is the namespace/name of ManbaTrafficPolicy
+// inherited by all manba clusters, it can be empty
+func NewResolver(s store.Store, global string) (*Resolver, error) {
+	r := &Resolver{store: s}
+	if global == "" {
+		return r, nil
+	}
+	var err error
+	r.namespace, r.name, err = cache.SplitMetaNamespaceKey(global)
+	if err != nil || r.namespace == "" || r.name == "" {
+		return nil, errors.Errorf("%q is not in format namespace/name", global)
+	}
+	return r, nil
+}
+
+// Inherited returns the policies cluster inherits from the nearest one: the referenced one,
+// the default one of its namespace and the global one, the ones not found are skipped,
+// missing is true if the referenced one is not found
+func (r *Resolver) Inherited(cluster *configurationv1beta1.ManbaCluster) (policies []*configurationv1beta1.TrafficPolicy, missing bool, err error) {
+	if ref := cluster.Spec.TrafficPolicyRef; ref != "" {
+		policy, err := r.get(cluster.Namespace, ref)
+		if err != nil {
+			return nil, false, err
+		}
+		missing = policy == nil
+		policies = append(policies, policy)
+	}
+	policy, err := r.get(cluster.Namespace, configurationv1beta1.DefaultTrafficPolicyName)
+	if err != nil {
+		return nil, false, err
+	}
+	policies = append(policies, policy)
+	if r.name != "" {
+		policy, err := r.get(r.namespace, r.name)
+		if err != nil {
+			return nil, false, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, missing, nil
+}
+
+// get returns the spec of ManbaTrafficPolicy, it's nil if the policy is not found
+func (r *Resolver) get(namespace, name string) (*configurationv1beta1.TrafficPolicy, error) {
+	policy, err := r.store.GetManbaTrafficPolicy(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting ManbaTrafficPolicy %s/%s", namespace, name)
+	}
+	return &policy.Spec, nil
+}
+
+// Effective returns the traffic policy of subset merged with the ones of cluster and inherited,
+// defaults are filled in fields still not set
+func Effective(cluster *configurationv1beta1.ManbaCluster, subset *configurationv1beta1.ManbaClusterSubSet,
+	inherited []*configurationv1beta1.TrafficPolicy) *configurationv1beta1.TrafficPolicy {
+	policies := append([]*configurationv1beta1.TrafficPolicy{subset.TrafficPolicy, cluster.Spec.TrafficPolicy}, inherited...)
+	policy := configurationv1beta1.MergeTrafficPolicies(policies...)
+	configurationv1beta1.SetTrafficPolicyDefaults(policy)
+	return policy
+}
@@ -0,0 +1,48 @@
+module github.com/domgoer/manba-ingress/plugins/ratelimit
+
+go 1.13
+
+// The proxy is built in this module with the plugin, the versions are the ones in Gopkg.lock of
+// manba v2.5.1, commits without a release tag there are required by their hashes,
+// go mod tidy replaces them with pseudo-versions.
+require (
+	github.com/beorn7/perks v0.0.0-20180321164747-3a771d992973 // indirect
+	github.com/buger/jsonparser v0.0.0-20180318095312-2cac668e8456 // indirect
+	github.com/coreos/etcd v3.3.8+incompatible // indirect
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
+	github.com/domgoer/manba-ingress/pkg/ratelimit v0.0.0-00010101000000-000000000000
+	github.com/fagongzi/gateway v2.5.1+incompatible
+	github.com/fagongzi/goetty v1.1.3 // indirect
+	github.com/fagongzi/grpcx v1.0.1 // indirect
+	github.com/fagongzi/log 9a647df25e0e
+	github.com/fagongzi/util 4acf02da76a9 // indirect
+	github.com/garyburd/redigo v1.6.0 // indirect
+	github.com/gogo/protobuf v0.0.0-20171007142547-342cbe0a0415 // indirect
+	github.com/golang/protobuf v1.1.0 // indirect
+	github.com/gorilla/websocket v1.3.0 // indirect
+	github.com/klauspost/compress v1.4.0 // indirect
+	github.com/klauspost/cpuid v0.0.0-20170728055534-ae7887de9fa5 // indirect
+	github.com/koding/websocketproxy 0fa3f994f6e7 // indirect
+	github.com/labstack/echo v3.3.5+incompatible // indirect
+	github.com/labstack/gommon v0.0.0-20180613044413-d6898124de91 // indirect
+	github.com/mattn/go-colorable v0.0.9 // indirect
+	github.com/mattn/go-isatty v0.0.3 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/client_golang v0.8.0 // indirect
+	github.com/prometheus/client_model v0.0.0-20180712105110-5c3871d89910 // indirect
+	github.com/prometheus/common v0.0.0-20180518154759-7600349dcfe1 // indirect
+	github.com/prometheus/procfs ae68e2d4c00f // indirect
+	github.com/soheilhy/cmux v0.1.4 // indirect
+	github.com/valyala/bytebufferpool e746df99fe4a // indirect
+	github.com/valyala/fasthttp v0.0.0-20171207120941-e5f51c11919d // indirect
+	github.com/valyala/fasttemplate v0.0.0-20170224212429-dcecefd839c4 // indirect
+	golang.org/x/crypto v0.0.0-20180621125126-a49355c7e3f8 // indirect
+	golang.org/x/net v0.0.0-20180712202826-d0887baf81f4 // indirect
+	golang.org/x/sys ac767d655b30 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	golang.org/x/time v0.0.0-20180412165947-fbb02b2291d2 // indirect
+	google.golang.org/genproto v0.0.0-20180716172848-2731d4fa720b // indirect
+	google.golang.org/grpc v1.13.0 // indirect
+)
+
+replace github.com/domgoer/manba-ingress/pkg/ratelimit => ../../pkg/ratelimit
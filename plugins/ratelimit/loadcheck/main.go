@@ -0,0 +1,42 @@
+// Command loadcheck loads the filter plugin the way manba proxy does and initializes it with a config file,
+// loading fails if the plugin and the program are built with different versions of the packages they share
+//
+//	loadcheck /path/to/ratelimit.so [/path/to/config.json]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"plugin"
+
+	"github.com/fagongzi/gateway/pkg/filter"
+)
+
+func main() {
+	flag.Parse()
+	if err := load(flag.Arg(0), flag.Arg(1)); err != nil {
+		fmt.Fprintf(os.Stderr, "loading %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func load(file, cfg string) error {
+	p, err := plugin.Open(file)
+	if err != nil {
+		return err
+	}
+	s, err := p.Lookup("NewExternalFilter")
+	if err != nil {
+		return err
+	}
+	newFilter, ok := s.(func() (filter.Filter, error))
+	if !ok {
+		return fmt.Errorf("NewExternalFilter is %T", s)
+	}
+	f, err := newFilter()
+	if err != nil {
+		return err
+	}
+	return f.Init(cfg)
+}
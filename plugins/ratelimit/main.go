@@ -0,0 +1,144 @@
+// Package main is the manba proxy filter enforcing rate limits of ManbaIngress rules on each client,
+// the limits are read from tags of apis set by manba-ingress.
+//
+// Go plugins only load into programs built with the same go version and versions of the packages
+// they share, so the plugin and the proxy are both built in this module, see docs/guides/2.setting-up-api.md:
+//
+//	go build -o proxy github.com/fagongzi/gateway/cmd/proxy
+//	go build -buildmode=plugin -o ratelimit.so .
+//
+// and loaded by proxy with --filter CLIENT-RATE-LIMITING:/path/to/ratelimit.so[:/path/to/config.json]
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/domgoer/manba-ingress/pkg/ratelimit"
+	"github.com/fagongzi/gateway/pkg/filter"
+	"github.com/fagongzi/gateway/pkg/pb/metapb"
+	"github.com/fagongzi/log"
+)
+
+const (
+	// FilterName is the name of filter
+	FilterName = "CLIENT-RATE-LIMITING"
+
+	// decidedAttr is the user value of request holding whether it's allowed,
+	// apis with several dispatch nodes run the filter for each node but take one token
+	decidedAttr = "__manba_ingress_rate_limited__"
+)
+
+// ErrRateLimited is returned when the client runs out of its quota
+var ErrRateLimited = errors.New("Err, too many requests of client")
+
+// Config is the configuration of filter, it's read from the json file passed to proxy
+type Config struct {
+	// TrustedProxies are the addresses and CIDRs of proxies in front of manba proxy,
+	// X-Forwarded-For of requests is only used to find client ip if they come from one of them
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+	// MaxClients is the number of client quotas kept in memory,
+	// new clients beyond it share one quota of each api, ratelimit.DefaultMaxBuckets by default
+	MaxClients int `json:"maxClients,omitempty"`
+}
+
+// Filter limits requests of each client of apis with a rate limit tag
+type Filter struct {
+	filter.BaseFilter
+	limiter *ratelimit.Limiter
+	trusted []*net.IPNet
+}
+
+// NewExternalFilter returns the filter, it's looked up by manba proxy
+func NewExternalFilter() (filter.Filter, error) {
+	return &Filter{limiter: ratelimit.NewLimiter(ratelimit.DefaultIdle, ratelimit.DefaultMaxBuckets)}, nil
+}
+
+// Init reads the config file cfg, the defaults are used if it's empty
+func (f *Filter) Init(cfg string) error {
+	if cfg == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(cfg)
+	if err != nil {
+		return err
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	f.trusted, err = ratelimit.ParseTrustedProxies(c.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if c.MaxClients > 0 {
+		f.limiter = ratelimit.NewLimiter(ratelimit.DefaultIdle, c.MaxClients)
+	}
+	return nil
+}
+
+// Name returns the name of filter
+func (f *Filter) Name() string {
+	return FilterName
+}
+
+// Pre rejects requests of clients running out of quota
+func (f *Filter) Pre(c filter.Context) (statusCode int, err error) {
+	ctx := c.OriginRequest()
+	if limited, ok := ctx.UserValue(decidedAttr).(bool); ok {
+		return f.result(c, limited)
+	}
+
+	limit, err := limitOf(c.API())
+	if err != nil {
+		// a broken limit doesn't block the api
+		log.Errorf("api %s: %v", c.API().Name, err)
+		return f.BaseFilter.Pre(c)
+	}
+	if limit == nil {
+		return f.BaseFilter.Pre(c)
+	}
+
+	limited := !f.limiter.Allow(limit, f.clientKey(c, limit))
+	ctx.SetUserValue(decidedAttr, limited)
+	return f.result(c, limited)
+}
+
+// limitOf returns the limit carried by tags of api, it's nil if there's none
+func limitOf(api *metapb.API) (*ratelimit.Limit, error) {
+	for _, tag := range api.Tags {
+		if tag != nil && tag.Name == ratelimit.TagName {
+			return ratelimit.ParseTag(tag.Value)
+		}
+	}
+	return nil, nil
+}
+
+func (f *Filter) result(c filter.Context, limited bool) (int, error) {
+	if limited {
+		return http.StatusTooManyRequests, ErrRateLimited
+	}
+	return f.BaseFilter.Pre(c)
+}
+
+// clientKey returns the parameter of request identifying the client, requests without it share a quota
+func (f *Filter) clientKey(c filter.Context, limit *ratelimit.Limit) string {
+	ctx := c.OriginRequest()
+	switch limit.Source {
+	case ratelimit.SourceHeader:
+		return string(ctx.Request.Header.Peek(limit.Name))
+	case ratelimit.SourceQuery:
+		return string(ctx.QueryArgs().Peek(limit.Name))
+	case ratelimit.SourceCookie:
+		return string(ctx.Request.Header.Cookie(limit.Name))
+	case ratelimit.SourceClientIP:
+		// unlike the blacklist filter of manba, clients can't pick their key by X-Forwarded-For
+		return ratelimit.ClientIP(ctx.RemoteAddr().String(), ctx.Request.Header.Peek("X-Forwarded-For"), f.trusted)
+	}
+	return ""
+}
+
+func main() {}
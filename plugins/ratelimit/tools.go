@@ -0,0 +1,7 @@
+//go:build tools
+// +build tools
+
+package main
+
+// the proxy is built in this module as well, so its packages have the versions the plugin is built with
+import _ "github.com/fagongzi/gateway/cmd/proxy"